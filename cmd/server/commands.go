@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/config"
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/redact"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/transcript"
+)
+
+// runCommand executes a one-off administrative command instead of starting the server
+func runCommand(name string, args []string, cfg *config.Config, db *database.Database, log logger.Logger) error {
+	switch name {
+	case "migrate":
+		// Migrations already ran during startup
+		return nil
+	case "bootstrap":
+		return bootstrap(args, db, log)
+	case "purge":
+		// Apply retention policies once, e.g. from an external cron job
+		return newRetentionScheduler(cfg, db, log).RunOnce(context.Background())
+	case "import":
+		return importTranscripts(args, cfg, db, log)
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+// bootstrap creates an organization with its first user, an admin, and prints
+// an API key for that user
+func bootstrap(args []string, db *database.Database, log logger.Logger) error {
+	fs := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	orgName := fs.String("org", "", "organization name")
+	username := fs.String("username", "", "username of the first user")
+	email := fs.String("email", "", "email of the first user")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *orgName == "" || *username == "" || *email == "" {
+		return fmt.Errorf("bootstrap requires -org, -username and -email")
+	}
+
+	orgRepo := database.NewOrganizationRepository(db.DB, log)
+	userRepo := database.NewUserRepository(db.DB, log)
+	keyRepo := database.NewAPIKeyRepository(db.DB, log)
+
+	org := &models.Organization{Name: *orgName}
+	if err := orgRepo.CreateOrganization(context.Background(), org); err != nil {
+		return err
+	}
+
+	ctx := auth.ForOrganization(context.Background(), org.ID)
+
+	user := &models.User{Username: *username, Email: *email, Role: auth.RoleAdmin}
+	if err := userRepo.CreateUser(ctx, user); err != nil {
+		return err
+	}
+
+	token, hash, err := auth.GenerateKey()
+	if err != nil {
+		return err
+	}
+	key := &models.APIKey{
+		UserID:  user.ID,
+		Name:    "bootstrap",
+		Prefix:  auth.DisplayPrefix(token),
+		KeyHash: hash,
+	}
+	if err := keyRepo.CreateAPIKey(ctx, key); err != nil {
+		return err
+	}
+
+	fmt.Printf("organization_id: %s\nuser_id: %s\napi_key: %s\n", org.ID, user.ID, token)
+	return nil
+}
+
+// importTranscripts creates sessions for a user from a file of OpenAI-style
+// transcripts or session exports
+func importTranscripts(args []string, cfg *config.Config, db *database.Database, log logger.Logger) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	orgID := fs.String("org", "", "organization ID")
+	userID := fs.String("user", "", "ID of the user who will own the sessions")
+	file := fs.String("file", "", "path of the JSON file to import")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *orgID == "" || *userID == "" || *file == "" {
+		return fmt.Errorf("import requires -org, -user and -file")
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	exports, err := transcript.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	ctx := auth.ForOrganization(context.Background(), *orgID)
+	user, err := database.NewUserRepository(db.DB, log).GetUserByID(ctx, *userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("user %s not found in organization %s", *userID, *orgID)
+	}
+
+	redactor, err := redact.New(cfg.Redaction.Patterns)
+	if err != nil {
+		return err
+	}
+	redactor, vault, err := newMessageRedaction(&cfg.Redaction, redactor)
+	if err != nil {
+		return err
+	}
+
+	importer := &transcript.Importer{
+		Store:      database.NewChatRepository(db.DB, log),
+		Assistants: database.NewAssistantRepository(db.DB, log),
+		Redactor:   redactor,
+		Vault:      vault,
+	}
+	imported, err := importer.Import(ctx, user.ID, exports)
+	for _, s := range imported {
+		fmt.Printf("session_id: %s messages: %d\n", s.SessionID, s.Messages)
+	}
+	return err
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,9 +15,14 @@ import (
 
 	"github.com/Ai-chat-agent/Chat-Agent.git/internal/config"
 	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels"
 	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/handlers"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
 	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
 	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/middleware"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/redact"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/usage"
 )
 
 func main() {
@@ -40,6 +46,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Redact personal data from logs
+	redactor, err := redact.New(cfg.Redaction.Patterns)
+	if err != nil {
+		log.Fatal("Failed to configure redaction", logger.F("error", err.Error()))
+	}
+	if cfg.Redaction.Logs {
+		log = logger.NewRedactingLogger(log, redactor.Redact)
+	}
+
 	log.Info("Starting Chat Agent Server",
 		logger.F("version", cfg.App.Version),
 		logger.F("environment", cfg.App.Environment),
@@ -57,13 +72,127 @@ func main() {
 		log.Fatal("Failed to run database migrations", logger.F("error", err.Error()))
 	}
 
+	// Run a one-off command instead of the server if one was given
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:], cfg, db, log); err != nil {
+			log.Fatal("Command failed",
+				logger.F("command", os.Args[1]),
+				logger.F("error", err.Error()),
+			)
+		}
+		return
+	}
+
 	// Initialize repositories
 	userRepo := database.NewUserRepository(db.DB, log)
 	chatRepo := database.NewChatRepository(db.DB, log)
+	apiKeyRepo := database.NewAPIKeyRepository(db.DB, log)
+	promptRepo := database.NewPromptRepository(db.DB, log)
+	assistantRepo := database.NewAssistantRepository(db.DB, log)
+	moderationRepo := database.NewModerationRepository(db.DB, log)
+	retentionRepo := database.NewRetentionRepository(db.DB, log)
+	auditRepo := database.NewAuditRepository(db.DB, log)
+	feedbackRepo := database.NewFeedbackRepository(db.DB, log)
+	usageRepo := database.NewUsageRepository(db.DB, log)
+	knowledgeRepo := database.NewKnowledgeBaseRepository(db.DB, log)
+	webhookRepo := database.NewWebhookRepository(db.DB, log)
+	channelRepo := database.NewChannelRepository(db.DB, log)
+
+	// Initialize the model providers behind a router, metered for usage
+	// accounting, and moderation
+	modelRouter, err := newRouter(&cfg.LLM, log)
+	if err != nil {
+		log.Fatal("Failed to configure model providers", logger.F("error", err.Error()))
+	}
+	prices := newPriceTable(&cfg.Usage)
+	var provider llm.Provider = usage.NewMeteredProvider(modelRouter, usageRepo, prices, log)
+	quota := usage.NewEnforcer(usageRepo, newQuotas(&cfg.Usage))
+	moderator, err := newModerationChain(&cfg.Moderation, provider)
+	if err != nil {
+		log.Fatal("Failed to configure moderation", logger.F("error", err.Error()))
+	}
+	messageRedactor, vault, err := newMessageRedaction(&cfg.Redaction, redactor)
+	if err != nil {
+		log.Fatal("Failed to configure message redaction", logger.F("error", err.Error()))
+	}
+	responseCache, err := newResponseCache(&cfg.Cache, log)
+	if err != nil {
+		log.Fatal("Failed to configure the response cache", logger.F("error", err.Error()))
+	}
+	semanticCache, err := newSemanticCache(&cfg.Cache.Semantic, modelRouter, knowledgeRepo, usageRepo, prices, redactor, log)
+	if err != nil {
+		log.Fatal("Failed to configure the semantic cache", logger.F("error", err.Error()))
+	}
+	jobPool, err := newJobPool(&cfg.Jobs, db, apiKeyRepo, log)
+	if err != nil {
+		log.Fatal("Failed to configure background jobs", logger.F("error", err.Error()))
+	}
+	dispatcher, err := newWebhookDispatcher(&cfg.Webhooks, webhookRepo, log)
+	if err != nil {
+		log.Fatal("Failed to configure webhooks", logger.F("error", err.Error()))
+	}
 
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler()
-	chatHandler := handlers.NewChatHandler(log)
+	chatHandler := handlers.NewChatHandler(handlers.ChatDependencies{
+		ChatRepo:       chatRepo,
+		UserRepo:       userRepo,
+		PromptRepo:     promptRepo,
+		AssistantRepo:  assistantRepo,
+		ModerationRepo: moderationRepo,
+		AuditRepo:      auditRepo,
+		FeedbackRepo:   feedbackRepo,
+		KnowledgeRepo:  knowledgeRepo,
+		Provider:       provider,
+		Moderator:      moderator,
+		Redactor:       messageRedactor,
+		Vault:          vault,
+		Quota:          quota,
+		Cache:          responseCache,
+		SemanticCache:  semanticCache,
+		Jobs:           jobPool,
+		Events:         dispatcher,
+	}, &cfg.Chat, log)
+	userHandler := handlers.NewUserHandler(userRepo, auditRepo, vault, log)
+	apiKeyHandler := handlers.NewAPIKeyHandler(userRepo, apiKeyRepo, log)
+	promptHandler := handlers.NewPromptHandler(promptRepo, log)
+	assistantHandler := handlers.NewAssistantHandler(assistantRepo, modelRouter.Providers(), log)
+	moderationHandler := handlers.NewModerationHandler(moderationRepo, log)
+	retentionHandler := handlers.NewRetentionHandler(retentionRepo, assistantRepo, log)
+	auditHandler := handlers.NewAuditHandler(auditRepo, log)
+	feedbackHandler := handlers.NewFeedbackHandler(feedbackRepo, log)
+	usageHandler := handlers.NewUsageHandler(usageRepo, quota, log)
+	knowledgeHandler := handlers.NewKnowledgeBaseHandler(knowledgeRepo, log)
+	webhookHandler := handlers.NewWebhookHandler(webhookRepo, dispatcher, log)
+
+	// Initialize the messaging channels
+	slackChannel, err := newSlackChannel(&cfg.Channels.Slack)
+	if err != nil {
+		log.Fatal("Failed to configure the Slack channel", logger.F("error", err.Error()))
+	}
+	smsChannel, err := newSMSChannel(&cfg.Channels.SMS)
+	if err != nil {
+		log.Fatal("Failed to configure the SMS channel", logger.F("error", err.Error()))
+	}
+	emailChannel, err := newEmailChannel(&cfg.Channels.Email)
+	if err != nil {
+		log.Fatal("Failed to configure the email channel", logger.F("error", err.Error()))
+	}
+	var enabledChannels []channels.Channel
+	if slackChannel != nil {
+		enabledChannels = append(enabledChannels, slackChannel)
+	}
+	if smsChannel != nil {
+		enabledChannels = append(enabledChannels, smsChannel)
+	}
+	if emailChannel != nil {
+		enabledChannels = append(enabledChannels, emailChannel)
+	}
+	if len(enabledChannels) > 0 && cfg.Channels.Timeout <= 0 {
+		log.Fatal("Failed to configure messaging channels", logger.F("error", "channels.timeout must be positive"))
+	}
+	channelHandler := handlers.NewChannelHandler(chatHandler, channelRepo, enabledChannels,
+		time.Duration(cfg.Channels.Timeout)*time.Second, log)
 
 	// Initialize Gin router
 	if cfg.App.Environment == "production" {
@@ -84,23 +213,167 @@ func main() {
 	router.GET("/health/ready", healthHandler.Ready)
 	router.GET("/health/live", healthHandler.Live)
 
+	// Messaging channels, authenticated by their own signatures
+	if slackChannel != nil {
+		router.POST("/channels/slack/events", channelHandler.Webhook(slackChannel))
+	}
+	if smsChannel != nil {
+		router.POST("/channels/sms", channelHandler.Webhook(smsChannel))
+	}
+
+	// OpenAI-compatible API, so existing SDKs and tools can use assistants
+	// as models
+	openai := router.Group("/v1")
+	openai.Use(middleware.AuthMiddleware(apiKeyRepo, log))
+	openai.Use(middleware.AuditMiddleware(auditRepo, "POST /v1/chat/completions"))
+	openai.Use(middleware.RequirePermission(auth.PermChat))
+	{
+		openai.POST("/chat/completions", chatHandler.ChatCompletions)
+		openai.GET("/models", chatHandler.ListModels)
+	}
+
 	// API routes
 	v1 := router.Group("/api/v1")
+	v1.Use(middleware.AuthMiddleware(apiKeyRepo, log))
+	// Conversation traffic is not recorded request by request; deletions
+	// and restores are still audited by the repository
+	v1.Use(middleware.AuditMiddleware(auditRepo,
+		"POST /api/v1/chat/message",
+		"PUT /api/v1/chat/message/:messageID",
+		"POST /api/v1/chat/message/:messageID/regenerate",
+		"POST /api/v1/chat/message/:messageID/feedback",
+		"PUT /api/v1/sessions/:sessionID/active",
+	))
 	{
 		// Chat endpoints
 		chat := v1.Group("/chat")
+		chat.Use(middleware.RequirePermission(auth.PermChat))
 		{
 			chat.POST("/message", chatHandler.SendMessage)
 			chat.GET("/history/:userID", chatHandler.GetChatHistory)
 			chat.DELETE("/message/:messageID", chatHandler.DeleteMessage)
+			chat.POST("/message/:messageID/restore", chatHandler.RestoreMessage)
+			chat.PUT("/message/:messageID", chatHandler.EditMessage)
+			chat.POST("/message/:messageID/regenerate", chatHandler.RegenerateMessage)
+			chat.POST("/message/:messageID/feedback", chatHandler.SubmitFeedback)
+			chat.GET("/message/:messageID/original", middleware.RequirePermission(auth.PermRevealPII), chatHandler.RevealMessage)
 		}
 
-		// User endpoints (placeholder for future implementation)
+		// Asynchronous replies
+		jobsGroup := v1.Group("/jobs")
+		jobsGroup.Use(middleware.RequirePermission(auth.PermChat))
+		{
+			jobsGroup.GET("/:jobID", chatHandler.GetJob)
+		}
+
+		// Session endpoints
+		sessions := v1.Group("/sessions")
+		sessions.Use(middleware.RequirePermission(auth.PermChat))
+		{
+			sessions.POST("/import", chatHandler.ImportSessions)
+			sessions.GET("/:sessionID/history", chatHandler.GetSessionHistory)
+			sessions.GET("/:sessionID/export", chatHandler.ExportSession)
+			sessions.PUT("/:sessionID/active", chatHandler.SelectBranch)
+			sessions.POST("/:sessionID/close", chatHandler.CloseSession)
+		}
+
+		// User endpoints
 		users := v1.Group("/users")
 		{
-			users.GET("/", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "Users endpoint - Coming Soon"})
-			})
+			users.POST("/", middleware.RequirePermission(auth.PermManageUsers), userHandler.CreateUser)
+			users.GET("/", middleware.RequirePermission(auth.PermManageUsers), userHandler.ListUsers)
+			users.GET("/me", userHandler.Me)
+			users.GET("/:id", userHandler.GetUser)
+			users.PUT("/:id", userHandler.UpdateUser)
+			users.GET("/:id/export", userHandler.ExportUser)
+			users.PUT("/:id/role", middleware.RequirePermission(auth.PermManageRoles), userHandler.UpdateRole)
+			users.DELETE("/:id", middleware.RequirePermission(auth.PermManageUsers), userHandler.DeleteUser)
+
+			// API keys
+			users.POST("/:id/keys", apiKeyHandler.CreateAPIKey)
+			users.GET("/:id/keys", apiKeyHandler.ListAPIKeys)
+			users.DELETE("/:id/keys/:keyID", apiKeyHandler.RevokeAPIKey)
+		}
+
+		// Assistant profiles
+		assistants := v1.Group("/assistants")
+		assistants.Use(middleware.RequirePermission(auth.PermChat))
+		{
+			assistants.GET("/", assistantHandler.ListAssistants)
+			assistants.GET("/:id", assistantHandler.GetAssistant)
+			assistants.POST("/", middleware.RequirePermission(auth.PermManageAssistants), assistantHandler.CreateAssistant)
+			assistants.PUT("/:id", middleware.RequirePermission(auth.PermManageAssistants), assistantHandler.UpdateAssistant)
+			assistants.DELETE("/:id", middleware.RequirePermission(auth.PermManageAssistants), assistantHandler.DeleteAssistant)
+		}
+
+		// Knowledge base versions
+		knowledge := v1.Group("/knowledge-bases")
+		knowledge.Use(middleware.RequirePermission(auth.PermManageAssistants))
+		{
+			knowledge.GET("/", knowledgeHandler.ListKnowledgeBases)
+			knowledge.POST("/:name/changes", knowledgeHandler.RecordChange)
+		}
+
+		// Prompt template registry
+		prompts := v1.Group("/prompts")
+		prompts.Use(middleware.RequirePermission(auth.PermManagePrompts))
+		{
+			prompts.GET("/", promptHandler.ListTemplates)
+			prompts.GET("/:name", promptHandler.ListVersions)
+			prompts.POST("/:name/versions", promptHandler.CreateVersion)
+			prompts.POST("/:name/versions/:version/promote", promptHandler.PromoteVersion)
+		}
+
+		// Moderation review
+		moderationGroup := v1.Group("/moderation")
+		moderationGroup.Use(middleware.RequirePermission(auth.PermReviewModeration))
+		{
+			moderationGroup.GET("/flags", moderationHandler.ListFlags)
+		}
+
+		// Data retention policies
+		retentionGroup := v1.Group("/retention")
+		retentionGroup.Use(middleware.RequirePermission(auth.PermManageRetention))
+		{
+			retentionGroup.GET("/policies", retentionHandler.ListPolicies)
+			retentionGroup.PUT("/policies", retentionHandler.SavePolicy)
+			retentionGroup.DELETE("/policies/:id", retentionHandler.DeletePolicy)
+		}
+
+		// Audit log
+		auditGroup := v1.Group("/audit")
+		auditGroup.Use(middleware.RequirePermission(auth.PermReadAudit))
+		{
+			auditGroup.GET("/events", auditHandler.ListEvents)
+			auditGroup.GET("/verify", auditHandler.VerifyChain)
+		}
+
+		// Ratings of assistant replies
+		feedbackGroup := v1.Group("/feedback")
+		feedbackGroup.Use(middleware.RequirePermission(auth.PermReadFeedback))
+		{
+			feedbackGroup.GET("/summary", feedbackHandler.Summary)
+		}
+
+		// Outbound webhooks
+		webhooksGroup := v1.Group("/webhooks")
+		webhooksGroup.Use(middleware.RequirePermission(auth.PermManageWebhooks))
+		{
+			webhooksGroup.POST("/", webhookHandler.CreateEndpoint)
+			webhooksGroup.GET("/", webhookHandler.ListEndpoints)
+			webhooksGroup.GET("/:id", webhookHandler.GetEndpoint)
+			webhooksGroup.PUT("/:id", webhookHandler.UpdateEndpoint)
+			webhooksGroup.DELETE("/:id", webhookHandler.DeleteEndpoint)
+			webhooksGroup.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+			webhooksGroup.POST("/deliveries/:deliveryID/redeliver", webhookHandler.Redeliver)
+		}
+
+		// Token usage and quotas
+		usageGroup := v1.Group("/usage")
+		usageGroup.Use(middleware.RequirePermission(auth.PermChat))
+		{
+			usageGroup.GET("", usageHandler.GetUsage)
+			usageGroup.GET("/quota", usageHandler.GetQuota)
 		}
 	}
 
@@ -112,9 +385,11 @@ func main() {
 			"environment": cfg.App.Environment,
 			"status":      "running",
 			"endpoints": map[string]string{
-				"health":   "/health",
-				"api_docs": "/api/v1",
-				"chat":     "/api/v1/chat",
+				"health":     "/health",
+				"api_docs":   "/api/v1",
+				"chat":       "/api/v1/chat",
+				"users":      "/api/v1/users",
+				"assistants": "/api/v1/assistants",
 			},
 		})
 	})
@@ -128,6 +403,21 @@ func main() {
 		IdleTimeout:  time.Duration(cfg.Server.IdleTimeout) * time.Second,
 	}
 
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	if cfg.Retention.Enabled {
+		go newRetentionScheduler(cfg, db, log).Start(jobsCtx)
+	}
+	go jobPool.Start(jobsCtx, chatHandler)
+	if dispatcher != nil {
+		go dispatcher.Start(jobsCtx)
+	}
+	if emailChannel != nil {
+		go channelHandler.Poll(jobsCtx, emailChannel, time.Duration(cfg.Channels.Email.PollInterval)*time.Second)
+	}
+
 	// Start server in a goroutine
 	go func() {
 		log.Info("Server starting",
@@ -140,17 +430,38 @@ func main() {
 		}
 	}()
 
+	// The gRPC API listens on its own port
+	grpcServer := newGRPCServer(&cfg.Server, apiKeyRepo, auditRepo, chatHandler, log)
+	if grpcServer != nil {
+		listener, err := net.Listen("tcp", net.JoinHostPort(cfg.Server.Host, cfg.Server.GRPCPort))
+		if err != nil {
+			log.Fatal("gRPC server failed to start", logger.F("error", err.Error()))
+		}
+		go func() {
+			log.Info("gRPC server starting", logger.F("address", listener.Addr().String()))
+
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatal("gRPC server failed", logger.F("error", err.Error()))
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Info("Server shutting down...")
+	stopJobs()
 
 	// Give outstanding requests a deadline for completion
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if grpcServer != nil {
+		stopGRPC(ctx, grpcServer)
+	}
+
 	// Attempt graceful shutdown
 	if err := server.Shutdown(ctx); err != nil {
 		log.Error("Server forced to shutdown", logger.F("error", err.Error()))
@@ -158,8 +469,4 @@ func main() {
 	}
 
 	log.Info("Server exited gracefully")
-
-	// Suppress unused variable warnings (remove these when implementing actual functionality)
-	_ = userRepo
-	_ = chatRepo
 }
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	chatv1 "github.com/Ai-chat-agent/Chat-Agent.git/api/chat/v1"
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/config"
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/retention"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/cache"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels/email"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels/slack"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels/sms"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/handlers"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/jobs"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/middleware"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/moderation"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/redact"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/usage"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/webhooks"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+)
+
+// newRouter creates the configured model providers behind a router that
+// retries and falls back between them
+func newRouter(cfg *config.LLMConfig, log logger.Logger) (*llm.Router, error) {
+	client := &http.Client{}
+	providers := make(map[string]llm.Provider, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		if p.Name == "" {
+			return nil, fmt.Errorf("llm.providers: every provider needs a name")
+		}
+		if _, dup := providers[p.Name]; dup {
+			return nil, fmt.Errorf("llm.providers: duplicate provider %q", p.Name)
+		}
+		switch p.Type {
+		case "echo":
+			providers[p.Name] = llm.NewEchoProvider()
+		case "openai":
+			if p.BaseURL == "" {
+				return nil, fmt.Errorf("llm.providers: %s needs a base_url", p.Name)
+			}
+			providers[p.Name] = llm.NewOpenAIProvider(p.Name, p.BaseURL, p.APIKey, client)
+		default:
+			return nil, fmt.Errorf("llm.providers: unknown type %q for %s", p.Type, p.Name)
+		}
+	}
+
+	fallbacks := make([]llm.Target, len(cfg.Fallbacks))
+	for i, f := range cfg.Fallbacks {
+		fallbacks[i] = llm.Target{Provider: f.Provider, Model: f.Model}
+	}
+
+	return llm.NewRouter(cfg.DefaultProvider, providers, llm.RouterOptions{
+		Fallbacks: fallbacks,
+		Retry: llm.RetryPolicy{
+			MaxAttempts: cfg.Retry.MaxAttempts,
+			BaseDelay:   time.Duration(cfg.Retry.BaseDelay) * time.Millisecond,
+			MaxDelay:    time.Duration(cfg.Retry.MaxDelay) * time.Millisecond,
+		},
+		AttemptTimeout:   time.Duration(cfg.Timeout) * time.Second,
+		FailureThreshold: cfg.CircuitBreaker.FailureThreshold,
+		Cooldown:         time.Duration(cfg.CircuitBreaker.Cooldown) * time.Second,
+	}, log)
+}
+
+// newResponseCache creates the configured response cache, or returns nil
+// when caching is disabled
+func newResponseCache(cfg *config.CacheConfig, log logger.Logger) (*cache.ResponseCache, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	var store cache.Store
+	switch cfg.Backend {
+	case "memory":
+		store = cache.NewMemoryStore(cfg.MaxEntries)
+	case "redis":
+		client := newRedisClient(&cfg.Redis)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			return nil, fmt.Errorf("cache: redis is unreachable: %w", err)
+		}
+		store = cache.NewRedisStore(client, "chat-agent:")
+	default:
+		return nil, fmt.Errorf("cache: unknown backend %q", cfg.Backend)
+	}
+	return cache.NewResponseCache(store, time.Duration(cfg.TTL)*time.Second, log), nil
+}
+
+// newSemanticCache creates the semantic cache, or returns nil when it is
+// disabled. Question embeddings are metered like completions. Cached entries
+// are shared between users, so they are always redacted.
+func newSemanticCache(cfg *config.SemanticCacheConfig, modelRouter *llm.Router, store cache.SemanticStore, recorder usage.Recorder, prices usage.PriceTable, redactor *redact.Redactor, log logger.Logger) (*cache.SemanticCache, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.Threshold <= 0 || cfg.Threshold > 1 {
+		return nil, fmt.Errorf("cache.semantic.threshold must be in (0, 1]")
+	}
+
+	embedder, ok := modelRouter.Embedder(cfg.Provider)
+	if !ok {
+		return nil, fmt.Errorf("cache.semantic: provider %q cannot embed text", cfg.Provider)
+	}
+	return cache.NewSemanticCache(usage.NewMeteredEmbedder(embedder, recorder, prices, log), store, cache.SemanticOptions{
+		Model:         cfg.Model,
+		Threshold:     cfg.Threshold,
+		TTL:           time.Duration(cfg.TTL) * time.Second,
+		MaxCandidates: cfg.MaxCandidates,
+		Redactor:      redactor,
+	}, log), nil
+}
+
+func newRedisClient(cfg *config.RedisConfig) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+}
+
+// newJobPool creates the worker pool for asynchronous replies on the
+// configured queue
+func newJobPool(cfg *config.JobsConfig, db *database.Database, secrets jobs.SecretSource, log logger.Logger) (*jobs.Pool, error) {
+	if cfg.Lease <= cfg.Timeout {
+		return nil, fmt.Errorf("jobs.lease must exceed jobs.timeout")
+	}
+
+	var queue jobs.Queue
+	switch cfg.Backend {
+	case "postgres":
+		queue = database.NewJobRepository(db.DB, log)
+	case "memory":
+		queue = jobs.NewMemoryQueue()
+	default:
+		return nil, fmt.Errorf("jobs: unknown backend %q", cfg.Backend)
+	}
+	return jobs.NewPool(queue, secrets, jobs.PoolOptions{
+		Workers:              cfg.Workers,
+		PollInterval:         time.Duration(cfg.PollInterval) * time.Millisecond,
+		Timeout:              time.Duration(cfg.Timeout) * time.Second,
+		Lease:                time.Duration(cfg.Lease) * time.Second,
+		MaxAttempts:          cfg.MaxAttempts,
+		WebhookTimeout:       time.Duration(cfg.WebhookTimeout) * time.Second,
+		AllowPrivateNetworks: cfg.AllowPrivateNetworks,
+	}, log), nil
+}
+
+// newWebhookDispatcher creates the dispatcher that delivers events to
+// tenants' webhook endpoints, or returns nil when webhooks are disabled
+func newWebhookDispatcher(cfg *config.WebhooksConfig, store webhooks.Store, log logger.Logger) (*webhooks.Dispatcher, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.Timeout <= 0 {
+		return nil, fmt.Errorf("webhooks.timeout must be positive")
+	}
+	return webhooks.NewDispatcher(store, webhooks.Options{
+		PollInterval:         time.Duration(cfg.PollInterval) * time.Millisecond,
+		BatchSize:            cfg.BatchSize,
+		Timeout:              time.Duration(cfg.Timeout) * time.Second,
+		MaxAttempts:          cfg.MaxAttempts,
+		BaseDelay:            time.Duration(cfg.BaseDelay) * time.Second,
+		MaxDelay:             time.Duration(cfg.MaxDelay) * time.Second,
+		Retention:            time.Duration(cfg.Retention) * 24 * time.Hour,
+		AllowPrivateNetworks: cfg.AllowPrivateNetworks,
+	}, log), nil
+}
+
+// newGRPCServer creates the gRPC server for the chat API, or returns nil when
+// no gRPC port is configured. Calls are authenticated and audited like the
+// REST API's and require the chat permission.
+func newGRPCServer(cfg *config.ServerConfig, resolver middleware.IdentityResolver, recorder middleware.AuditRecorder, chatHandler *handlers.ChatHandler, log logger.Logger) *grpc.Server {
+	if cfg.GRPCPort == "" {
+		return nil
+	}
+	// As on the REST API, conversation traffic and reads are not recorded
+	// call by call
+	unaudited := []string{
+		chatv1.ChatService_SendMessage_FullMethodName,
+		chatv1.ChatService_StreamMessage_FullMethodName,
+		chatv1.ChatService_SelectBranch_FullMethodName,
+		chatv1.ChatService_GetSessionHistory_FullMethodName,
+		chatv1.ChatService_GetChatHistory_FullMethodName,
+	}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			middleware.UnaryRecoveryInterceptor(log),
+			middleware.UnaryAuthInterceptor(resolver, log),
+			middleware.UnaryAuditInterceptor(recorder, unaudited...),
+			middleware.UnaryPermissionInterceptor(auth.PermChat),
+		),
+		grpc.ChainStreamInterceptor(
+			middleware.StreamRecoveryInterceptor(log),
+			middleware.StreamAuthInterceptor(resolver, log),
+			middleware.StreamAuditInterceptor(recorder, unaudited...),
+			middleware.StreamPermissionInterceptor(auth.PermChat),
+		),
+	)
+	chatv1.RegisterChatServiceServer(server, handlers.NewChatService(chatHandler))
+	return server
+}
+
+// stopGRPC lets in-flight calls finish, cancelling any still running when ctx
+// expires
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
+
+// newSlackChannel creates the Slack channel, or returns nil when it is
+// disabled
+func newSlackChannel(cfg *config.SlackConfig) (*slack.Channel, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.SigningSecret == "" {
+		return nil, fmt.Errorf("channels.slack.signing_secret is required")
+	}
+	workspaces := make([]slack.Workspace, 0, len(cfg.Workspaces))
+	for _, ws := range cfg.Workspaces {
+		if ws.TeamID == "" || ws.OrganizationID == "" || ws.BotToken == "" {
+			return nil, fmt.Errorf("channels.slack.workspaces need a team_id, organization_id and bot_token")
+		}
+		workspaces = append(workspaces, slack.Workspace{
+			TeamID:         ws.TeamID,
+			OrganizationID: ws.OrganizationID,
+			BotToken:       ws.BotToken,
+			AssistantID:    ws.AssistantID,
+		})
+	}
+	client := slack.NewClient(cfg.APIBaseURL, &http.Client{Timeout: 30 * time.Second})
+	return slack.NewChannel(cfg.SigningSecret, client, workspaces), nil
+}
+
+// newSMSChannel creates the SMS channel, or returns nil when it is disabled
+func newSMSChannel(cfg *config.SMSConfig) (*sms.Channel, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.AccountSID == "" || cfg.AuthToken == "" {
+		return nil, fmt.Errorf("channels.sms.account_sid and auth_token are required")
+	}
+	if cfg.WebhookURL == "" {
+		return nil, fmt.Errorf("channels.sms.webhook_url is required to verify requests")
+	}
+	numbers := make([]sms.Number, 0, len(cfg.Numbers))
+	for _, n := range cfg.Numbers {
+		if n.Number == "" || n.OrganizationID == "" {
+			return nil, fmt.Errorf("channels.sms.numbers need a number and organization_id")
+		}
+		numbers = append(numbers, sms.Number{
+			Number:         n.Number,
+			OrganizationID: n.OrganizationID,
+			AssistantID:    n.AssistantID,
+		})
+	}
+	return sms.NewChannel(sms.Options{
+		AccountSID: cfg.AccountSID,
+		AuthToken:  cfg.AuthToken,
+		APIBaseURL: cfg.APIBaseURL,
+		WebhookURL: cfg.WebhookURL,
+		Numbers:    numbers,
+	}, &http.Client{Timeout: 30 * time.Second}), nil
+}
+
+// newEmailChannel creates the email channel, or returns nil when it is
+// disabled
+func newEmailChannel(cfg *config.EmailConfig) (*email.Channel, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.PollInterval <= 0 || cfg.Timeout <= 0 {
+		return nil, fmt.Errorf("channels.email.poll_interval and timeout must be positive")
+	}
+	mailboxes := make([]email.Mailbox, 0, len(cfg.Mailboxes))
+	for _, mb := range cfg.Mailboxes {
+		if mb.Address == "" || mb.OrganizationID == "" || mb.IMAPAddr == "" || mb.SMTPAddr == "" {
+			return nil, fmt.Errorf("channels.email.mailboxes need an address, organization_id, imap_addr and smtp_addr")
+		}
+		mailboxes = append(mailboxes, email.Mailbox{
+			Address:        mb.Address,
+			OrganizationID: mb.OrganizationID,
+			AssistantID:    mb.AssistantID,
+			IMAPAddr:       mb.IMAPAddr,
+			IMAPTLS:        mb.IMAPTLS,
+			Folder:         mb.Folder,
+			SMTPAddr:       mb.SMTPAddr,
+			Username:       mb.Username,
+			Password:       mb.Password,
+		})
+	}
+	return email.NewChannel(mailboxes, time.Duration(cfg.Timeout)*time.Second), nil
+}
+
+// newModerationChain builds the moderation chain described by the config, or
+// returns nil when moderation is disabled
+func newModerationChain(cfg *config.ModerationConfig, provider llm.Provider) (*moderation.Chain, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	var moderators []moderation.Moderator
+	if cfg.MaxLength > 0 {
+		moderators = append(moderators, moderation.NewLengthModerator(cfg.MaxLength))
+	}
+
+	blocklist, err := moderation.NewBlocklistModerator(cfg.BlockedKeywords, cfg.BlockedPatterns)
+	if err != nil {
+		return nil, err
+	}
+	moderators = append(moderators, blocklist)
+
+	if cfg.InjectionScreening {
+		injection, err := moderation.NewInjectionModerator(cfg.InjectionPatterns...)
+		if err != nil {
+			return nil, err
+		}
+		moderators = append(moderators, injection)
+	}
+
+	moderators = append(moderators, moderation.NewRepetitionModerator(cfg.MaxCharRun, cfg.MinUniqueWordRatio))
+
+	if cfg.Classifier.Enabled {
+		moderators = append(moderators, moderation.NewLLMClassifier(provider, cfg.Classifier.Model))
+	}
+
+	return moderation.NewChain(cfg.FailOpen, moderators...), nil
+}
+
+// newMessageRedaction returns the redactor and vault applied to stored
+// messages. Both are nil when message redaction is disabled, and the vault is
+// nil unless vault mode is on.
+func newMessageRedaction(cfg *config.RedactionConfig, redactor *redact.Redactor) (*redact.Redactor, *redact.Vault, error) {
+	if !cfg.Messages {
+		return nil, nil, nil
+	}
+	if !cfg.Vault {
+		return redactor, nil, nil
+	}
+	if cfg.VaultKey == "" {
+		return nil, nil, fmt.Errorf("redaction.vault_key is required when the vault is enabled")
+	}
+	vault, err := redact.NewVault(cfg.VaultKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return redactor, vault, nil
+}
+
+// newRetentionScheduler creates the scheduler that applies retention policies
+func newRetentionScheduler(cfg *config.Config, db *database.Database, log logger.Logger) *retention.Scheduler {
+	return retention.NewScheduler(
+		database.NewOrganizationRepository(db.DB, log),
+		database.NewRetentionRepository(db.DB, log),
+		database.NewAuditRepository(db.DB, log),
+		time.Duration(cfg.Retention.Interval)*time.Second,
+		cfg.Retention.BatchSize,
+		log,
+	)
+}
+
+// newPriceTable converts the configured model prices
+func newPriceTable(cfg *config.UsageConfig) usage.PriceTable {
+	prices := make(usage.PriceTable, len(cfg.Prices))
+	for _, p := range cfg.Prices {
+		prices[p.Model] = usage.Price{Prompt: p.Prompt, Completion: p.Completion}
+	}
+	return prices
+}
+
+// newQuotas converts the configured token quotas
+func newQuotas(cfg *config.UsageConfig) usage.Quotas {
+	return usage.Quotas{
+		usage.ScopeUser:   {Daily: cfg.Quotas.User.Daily, Monthly: cfg.Quotas.User.Monthly},
+		usage.ScopeKey:    {Daily: cfg.Quotas.Key.Daily, Monthly: cfg.Quotas.Key.Monthly},
+		usage.ScopeTenant: {Daily: cfg.Quotas.Tenant.Daily, Monthly: cfg.Quotas.Tenant.Monthly},
+	}
+}
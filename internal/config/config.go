@@ -10,10 +10,20 @@ import (
 
 // Config holds all configuration for our application
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Log      LogConfig      `mapstructure:"log"`
-	App      AppConfig      `mapstructure:"app"`
+	Server     ServerConfig     `mapstructure:"server"`
+	Database   DatabaseConfig   `mapstructure:"database"`
+	Log        LogConfig        `mapstructure:"log"`
+	App        AppConfig        `mapstructure:"app"`
+	Chat       ChatConfig       `mapstructure:"chat"`
+	LLM        LLMConfig        `mapstructure:"llm"`
+	Cache      CacheConfig      `mapstructure:"cache"`
+	Moderation ModerationConfig `mapstructure:"moderation"`
+	Redaction  RedactionConfig  `mapstructure:"redaction"`
+	Retention  RetentionConfig  `mapstructure:"retention"`
+	Usage      UsageConfig      `mapstructure:"usage"`
+	Jobs       JobsConfig       `mapstructure:"jobs"`
+	Webhooks   WebhooksConfig   `mapstructure:"webhooks"`
+	Channels   ChannelsConfig   `mapstructure:"channels"`
 }
 
 type ServerConfig struct {
@@ -22,6 +32,8 @@ type ServerConfig struct {
 	ReadTimeout  int    `mapstructure:"read_timeout"`
 	WriteTimeout int    `mapstructure:"write_timeout"`
 	IdleTimeout  int    `mapstructure:"idle_timeout"`
+	// GRPCPort serves the gRPC API on the same host; empty disables it
+	GRPCPort string `mapstructure:"grpc_port"`
 }
 
 type DatabaseConfig struct {
@@ -31,6 +43,9 @@ type DatabaseConfig struct {
 	Password string `mapstructure:"password"`
 	DBName   string `mapstructure:"dbname"`
 	SSLMode  string `mapstructure:"sslmode"`
+	// RowLevelSecurity installs Postgres policies that back the application's
+	// organization scoping
+	RowLevelSecurity bool `mapstructure:"row_level_security"`
 }
 
 type LogConfig struct {
@@ -44,6 +59,286 @@ type AppConfig struct {
 	Environment string `mapstructure:"environment"`
 }
 
+type ChatConfig struct {
+	// RestoreWindow is how long, in seconds, a deleted message can be restored
+	RestoreWindow int `mapstructure:"restore_window"`
+	// SystemPrompt names the prompt template whose active version new sessions use
+	SystemPrompt string `mapstructure:"system_prompt"`
+}
+
+type LLMConfig struct {
+	// DefaultProvider serves assistants that do not name a provider
+	DefaultProvider string           `mapstructure:"default_provider"`
+	Providers       []ProviderConfig `mapstructure:"providers"`
+	// Fallbacks are tried in order after an assistant's own provider and fallbacks
+	Fallbacks []ModelTargetConfig `mapstructure:"fallbacks"`
+	// Timeout bounds each provider call, in seconds
+	Timeout        int                  `mapstructure:"timeout"`
+	Retry          RetryConfig          `mapstructure:"retry"`
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+}
+
+type ProviderConfig struct {
+	Name string `mapstructure:"name"`
+	// Type is "openai" for OpenAI-compatible APIs or "echo"
+	Type    string `mapstructure:"type"`
+	BaseURL string `mapstructure:"base_url"`
+	APIKey  string `mapstructure:"api_key"`
+}
+
+type ModelTargetConfig struct {
+	Provider string `mapstructure:"provider"`
+	Model    string `mapstructure:"model"`
+}
+
+type RetryConfig struct {
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// BaseDelay and MaxDelay bound the jittered backoff, in milliseconds
+	BaseDelay int `mapstructure:"base_delay"`
+	MaxDelay  int `mapstructure:"max_delay"`
+}
+
+type CircuitBreakerConfig struct {
+	// FailureThreshold consecutive failures open a provider's circuit
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// Cooldown is how long, in seconds, an open circuit rejects calls
+	Cooldown int `mapstructure:"cooldown"`
+}
+
+type CacheConfig struct {
+	// Enabled turns on the response cache for assistants that opt in
+	Enabled bool `mapstructure:"enabled"`
+	// Backend is "memory" for a per-process LRU or "redis" to share entries
+	Backend string `mapstructure:"backend"`
+	// TTL is how long, in seconds, a cached reply is served
+	TTL int `mapstructure:"ttl"`
+	// MaxEntries bounds the memory backend
+	MaxEntries int                 `mapstructure:"max_entries"`
+	Redis      RedisConfig         `mapstructure:"redis"`
+	Semantic   SemanticCacheConfig `mapstructure:"semantic"`
+}
+
+// SemanticCacheConfig configures the semantic cache, which answers opening
+// questions from earlier answers to similar ones. It is independent of the
+// response cache's enabled flag and backend; entries live in the database.
+type SemanticCacheConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Provider embeds questions; empty uses llm.default_provider
+	Provider string `mapstructure:"provider"`
+	// Model is the embedding model
+	Model string `mapstructure:"model"`
+	// Threshold is the cosine similarity a question must reach to be
+	// answered from the cache, for assistants that set none
+	Threshold float64 `mapstructure:"threshold"`
+	// TTL is how long, in seconds, a cached answer is served
+	TTL int `mapstructure:"ttl"`
+	// MaxCandidates bounds how many cached questions a lookup compares
+	MaxCandidates int `mapstructure:"max_candidates"`
+}
+
+type RedisConfig struct {
+	Addr     string `mapstructure:"addr"`
+	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db"`
+}
+
+// JobsConfig configures the background workers that generate replies for
+// messages sent with async=true
+type JobsConfig struct {
+	// Backend is "postgres" to share the queue between instances or "memory"
+	Backend string `mapstructure:"backend"`
+	Workers int    `mapstructure:"workers"`
+	// PollInterval is how often, in milliseconds, idle workers check the queue
+	PollInterval int `mapstructure:"poll_interval"`
+	// Timeout bounds, in seconds, the generation of one reply
+	Timeout int `mapstructure:"timeout"`
+	// Lease is how long, in seconds, a job is held before it is assumed
+	// abandoned and retried; it must exceed Timeout
+	Lease       int `mapstructure:"lease"`
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// WebhookTimeout bounds, in seconds, each call to a job's webhook
+	WebhookTimeout int `mapstructure:"webhook_timeout"`
+	// AllowPrivateNetworks lets job webhooks call loopback and private
+	// addresses; leave it off unless every caller is trusted
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"`
+}
+
+// WebhooksConfig configures the delivery of events to tenants' webhook
+// endpoints
+type WebhooksConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// PollInterval is how often, in milliseconds, due deliveries are checked
+	PollInterval int `mapstructure:"poll_interval"`
+	BatchSize    int `mapstructure:"batch_size"`
+	// Timeout bounds, in seconds, each call to an endpoint
+	Timeout     int `mapstructure:"timeout"`
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// BaseDelay and MaxDelay, in seconds, bound the exponential backoff
+	// between attempts
+	BaseDelay int `mapstructure:"base_delay"`
+	MaxDelay  int `mapstructure:"max_delay"`
+	// Retention is how many days finished deliveries are kept in the log;
+	// zero keeps them forever
+	Retention int `mapstructure:"retention"`
+	// AllowPrivateNetworks lets endpoints on loopback and private addresses
+	// be called
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"`
+}
+
+// ChannelsConfig configures the messaging channels users can chat through
+// besides the API
+type ChannelsConfig struct {
+	// Timeout bounds, in seconds, generating and sending each reply
+	Timeout int         `mapstructure:"timeout"`
+	Slack   SlackConfig `mapstructure:"slack"`
+	SMS     SMSConfig   `mapstructure:"sms"`
+	Email   EmailConfig `mapstructure:"email"`
+}
+
+type SlackConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// SigningSecret is the Slack app's secret for verifying event requests
+	SigningSecret string `mapstructure:"signing_secret"`
+	// APIBaseURL is the Web API replies are posted to; tests point it at a stub
+	APIBaseURL string                 `mapstructure:"api_base_url"`
+	Workspaces []SlackWorkspaceConfig `mapstructure:"workspaces"`
+}
+
+// SlackWorkspaceConfig connects a Slack workspace to an organization
+type SlackWorkspaceConfig struct {
+	TeamID         string `mapstructure:"team_id"`
+	OrganizationID string `mapstructure:"organization_id"`
+	BotToken       string `mapstructure:"bot_token"`
+	// AssistantID is the assistant new conversations start with; empty uses
+	// chat.system_prompt
+	AssistantID string `mapstructure:"assistant_id"`
+}
+
+// SMSConfig configures the SMS channel for a Twilio account, or a provider
+// compatible with Twilio's API
+type SMSConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	AccountSID string `mapstructure:"account_sid"`
+	// AuthToken authenticates sends and verifies webhook signatures
+	AuthToken string `mapstructure:"auth_token"`
+	// APIBaseURL is the REST API replies are sent through; tests point it at
+	// a stub
+	APIBaseURL string `mapstructure:"api_base_url"`
+	// WebhookURL is the public URL of /channels/sms exactly as configured
+	// with the provider, which signs requests for it
+	WebhookURL string            `mapstructure:"webhook_url"`
+	Numbers    []SMSNumberConfig `mapstructure:"numbers"`
+}
+
+// SMSNumberConfig connects a phone number to an organization
+type SMSNumberConfig struct {
+	// Number is in E.164 form, such as +15551234567
+	Number         string `mapstructure:"number"`
+	OrganizationID string `mapstructure:"organization_id"`
+	// AssistantID is the assistant new conversations start with; empty uses
+	// chat.system_prompt
+	AssistantID string `mapstructure:"assistant_id"`
+}
+
+// EmailConfig configures the email channel, which polls mailboxes over IMAP
+// and answers over SMTP
+type EmailConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// PollInterval is how often, in seconds, mailboxes are checked
+	PollInterval int `mapstructure:"poll_interval"`
+	// Timeout bounds, in seconds, each IMAP session and SMTP delivery
+	Timeout   int             `mapstructure:"timeout"`
+	Mailboxes []MailboxConfig `mapstructure:"mailboxes"`
+}
+
+// MailboxConfig connects an email address to an organization
+type MailboxConfig struct {
+	Address        string `mapstructure:"address"`
+	OrganizationID string `mapstructure:"organization_id"`
+	// AssistantID is the assistant new conversations start with; empty uses
+	// chat.system_prompt
+	AssistantID string `mapstructure:"assistant_id"`
+	// IMAPAddr is the host:port messages are fetched from; IMAPTLS selects
+	// implicit TLS, as on port 993
+	IMAPAddr string `mapstructure:"imap_addr"`
+	IMAPTLS  bool   `mapstructure:"imap_tls"`
+	// Folder is the folder polled, INBOX by default
+	Folder string `mapstructure:"folder"`
+	// SMTPAddr is the host:port replies are sent through, upgraded with
+	// STARTTLS when the server offers it
+	SMTPAddr string `mapstructure:"smtp_addr"`
+	// Username and Password sign in to both servers
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+type ModerationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// FailOpen lets messages through when a moderator, such as the LLM
+	// classifier, is unavailable
+	FailOpen           bool                       `mapstructure:"fail_open"`
+	MaxLength          int                        `mapstructure:"max_length"`
+	BlockedKeywords    []string                   `mapstructure:"blocked_keywords"`
+	BlockedPatterns    []string                   `mapstructure:"blocked_patterns"`
+	InjectionScreening bool                       `mapstructure:"injection_screening"`
+	InjectionPatterns  []string                   `mapstructure:"injection_patterns"`
+	MaxCharRun         int                        `mapstructure:"max_char_run"`
+	MinUniqueWordRatio float64                    `mapstructure:"min_unique_word_ratio"`
+	Classifier         ModerationClassifierConfig `mapstructure:"classifier"`
+}
+
+type ModerationClassifierConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Model   string `mapstructure:"model"`
+}
+
+type RedactionConfig struct {
+	// Logs redacts personal data from every log line
+	Logs bool `mapstructure:"logs"`
+	// Messages redacts personal data from chat messages before they are stored
+	Messages bool `mapstructure:"messages"`
+	// Vault keeps an encrypted copy of each redacted message that admins can read
+	Vault bool `mapstructure:"vault"`
+	// VaultKey is the base64-encoded 32-byte AES key for the vault
+	VaultKey string `mapstructure:"vault_key"`
+	// Patterns adds custom regular expressions, keyed by placeholder name
+	Patterns map[string]string `mapstructure:"patterns"`
+}
+
+type RetentionConfig struct {
+	// Enabled runs the purge scheduler in the server process
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is how often, in seconds, retention policies are applied
+	Interval int `mapstructure:"interval"`
+	// BatchSize caps the rows removed by a single delete statement
+	BatchSize int `mapstructure:"batch_size"`
+}
+
+type UsageConfig struct {
+	// Prices lists what each model costs; models without a price cost nothing
+	Prices []ModelPriceConfig `mapstructure:"prices"`
+	Quotas QuotasConfig       `mapstructure:"quotas"`
+}
+
+type ModelPriceConfig struct {
+	Model string `mapstructure:"model"`
+	// Prompt and Completion are in US dollars per million tokens
+	Prompt     float64 `mapstructure:"prompt"`
+	Completion float64 `mapstructure:"completion"`
+}
+
+type QuotasConfig struct {
+	User   QuotaConfig `mapstructure:"user"`
+	Key    QuotaConfig `mapstructure:"key"`
+	Tenant QuotaConfig `mapstructure:"tenant"`
+}
+
+// QuotaConfig caps the tokens used per UTC day and calendar month; zero is unlimited
+type QuotaConfig struct {
+	Daily   int64 `mapstructure:"daily"`
+	Monthly int64 `mapstructure:"monthly"`
+}
+
 // Load reads configuration from file and environment variables
 func Load() (*Config, error) {
 	config := &Config{}
@@ -75,9 +370,24 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("unable to decode config into struct: %w", err)
 	}
 
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return config, nil
 }
 
+// validate rejects settings the server cannot run with
+func (c *Config) validate() error {
+	if c.Retention.Interval <= 0 {
+		return fmt.Errorf("retention.interval must be positive, got %d", c.Retention.Interval)
+	}
+	if c.Retention.BatchSize <= 0 {
+		return fmt.Errorf("retention.batch_size must be positive, got %d", c.Retention.BatchSize)
+	}
+	return nil
+}
+
 func setDefaults() {
 	// Server defaults
 	viper.SetDefault("server.port", "8080")
@@ -85,6 +395,7 @@ func setDefaults() {
 	viper.SetDefault("server.read_timeout", 10)
 	viper.SetDefault("server.write_timeout", 10)
 	viper.SetDefault("server.idle_timeout", 60)
+	viper.SetDefault("server.grpc_port", "9090")
 
 	// Database defaults
 	viper.SetDefault("database.host", "localhost")
@@ -93,6 +404,7 @@ func setDefaults() {
 	viper.SetDefault("database.password", "")
 	viper.SetDefault("database.dbname", "chat_agent")
 	viper.SetDefault("database.sslmode", "disable")
+	viper.SetDefault("database.row_level_security", false)
 
 	// Log defaults
 	viper.SetDefault("log.level", "info")
@@ -102,6 +414,87 @@ func setDefaults() {
 	viper.SetDefault("app.name", "Chat Agent")
 	viper.SetDefault("app.version", "1.0.0")
 	viper.SetDefault("app.environment", getEnv("ENVIRONMENT", "development"))
+
+	// Chat defaults
+	viper.SetDefault("chat.restore_window", 300)
+	viper.SetDefault("chat.system_prompt", "default")
+
+	// LLM defaults
+	viper.SetDefault("llm.default_provider", "echo")
+	viper.SetDefault("llm.providers", []map[string]interface{}{{"name": "echo", "type": "echo"}})
+	viper.SetDefault("llm.timeout", 60)
+	viper.SetDefault("llm.retry.max_attempts", 2)
+	viper.SetDefault("llm.retry.base_delay", 200)
+	viper.SetDefault("llm.retry.max_delay", 2000)
+	viper.SetDefault("llm.circuit_breaker.failure_threshold", 5)
+	viper.SetDefault("llm.circuit_breaker.cooldown", 30)
+
+	// Cache defaults
+	viper.SetDefault("cache.enabled", false)
+	viper.SetDefault("cache.backend", "memory")
+	viper.SetDefault("cache.ttl", 3600)
+	viper.SetDefault("cache.max_entries", 10000)
+	viper.SetDefault("cache.redis.addr", "localhost:6379")
+	viper.SetDefault("cache.semantic.enabled", false)
+	viper.SetDefault("cache.semantic.threshold", 0.92)
+	viper.SetDefault("cache.semantic.ttl", 86400)
+	viper.SetDefault("cache.semantic.max_candidates", 500)
+
+	// Jobs defaults
+	viper.SetDefault("jobs.backend", "postgres")
+	viper.SetDefault("jobs.workers", 4)
+	viper.SetDefault("jobs.poll_interval", 1000)
+	viper.SetDefault("jobs.timeout", 600)
+	viper.SetDefault("jobs.lease", 900)
+	viper.SetDefault("jobs.max_attempts", 3)
+	viper.SetDefault("jobs.webhook_timeout", 10)
+	viper.SetDefault("jobs.allow_private_networks", false)
+
+	// Webhooks defaults
+	viper.SetDefault("webhooks.enabled", true)
+	viper.SetDefault("webhooks.poll_interval", 1000)
+	viper.SetDefault("webhooks.batch_size", 50)
+	viper.SetDefault("webhooks.timeout", 10)
+	viper.SetDefault("webhooks.max_attempts", 8)
+	viper.SetDefault("webhooks.base_delay", 30)
+	viper.SetDefault("webhooks.max_delay", 3600)
+	viper.SetDefault("webhooks.retention", 30)
+	viper.SetDefault("webhooks.allow_private_networks", false)
+
+	// Channels defaults
+	viper.SetDefault("channels.timeout", 120)
+	viper.SetDefault("channels.slack.enabled", false)
+	viper.SetDefault("channels.slack.api_base_url", "https://slack.com/api")
+	viper.SetDefault("channels.sms.enabled", false)
+	viper.SetDefault("channels.sms.api_base_url", "https://api.twilio.com")
+	viper.SetDefault("channels.email.enabled", false)
+	viper.SetDefault("channels.email.poll_interval", 30)
+	viper.SetDefault("channels.email.timeout", 60)
+
+	// Moderation defaults
+	viper.SetDefault("moderation.enabled", true)
+	viper.SetDefault("moderation.fail_open", true)
+	viper.SetDefault("moderation.max_length", 1000)
+	viper.SetDefault("moderation.injection_screening", true)
+	viper.SetDefault("moderation.max_char_run", 50)
+	viper.SetDefault("moderation.min_unique_word_ratio", 0.2)
+	viper.SetDefault("moderation.classifier.enabled", false)
+
+	// Redaction defaults
+	viper.SetDefault("redaction.logs", true)
+	viper.SetDefault("redaction.messages", false)
+	viper.SetDefault("redaction.vault", false)
+
+	// Retention defaults
+	viper.SetDefault("retention.enabled", true)
+	viper.SetDefault("retention.interval", 3600)
+	viper.SetDefault("retention.batch_size", 500)
+
+	// Usage defaults
+	for _, scope := range []string{"user", "key", "tenant"} {
+		viper.SetDefault("usage.quotas."+scope+".daily", 0)
+		viper.SetDefault("usage.quotas."+scope+".monthly", 0)
+	}
 }
 
 func getEnv(key, defaultValue string) string {
@@ -136,6 +529,7 @@ server:
   read_timeout: 10
   write_timeout: 10
   idle_timeout: 60
+  grpc_port: "9090"
 
 database:
   host: "localhost"
@@ -144,6 +538,7 @@ database:
   password: ""
   dbname: "chat_agent"
   sslmode: "disable"
+  row_level_security: false
 
 log:
   level: "info"
@@ -153,9 +548,150 @@ app:
   name: "Chat Agent"
   version: "1.0.0"
   environment: "development"
+
+chat:
+  restore_window: 300
+  system_prompt: "default"
+
+llm:
+  default_provider: "echo"
+  providers:
+    - name: "echo"
+      type: "echo"
+    # - name: "openai"
+    #   type: "openai"
+    #   base_url: "https://api.openai.com/v1"
+    #   api_key: ""
+  fallbacks: []
+  timeout: 60
+  retry:
+    max_attempts: 2
+    base_delay: 200
+    max_delay: 2000
+  circuit_breaker:
+    failure_threshold: 5
+    cooldown: 30
+
+cache:
+  enabled: false
+  backend: "memory"
+  ttl: 3600
+  max_entries: 10000
+  redis:
+    addr: "localhost:6379"
+    password: ""
+    db: 0
+  semantic:
+    enabled: false
+    provider: ""
+    model: ""
+    threshold: 0.92
+    ttl: 86400
+    max_candidates: 500
+
+moderation:
+  enabled: true
+  fail_open: true
+  max_length: 1000
+  blocked_keywords: []
+  blocked_patterns: []
+  injection_screening: true
+  injection_patterns: []
+  max_char_run: 50
+  min_unique_word_ratio: 0.2
+  classifier:
+    enabled: false
+    model: ""
+
+redaction:
+  logs: true
+  messages: false
+  vault: false
+  vault_key: ""
+  patterns: {}
+
+retention:
+  enabled: true
+  interval: 3600
+  batch_size: 500
+
+usage:
+  prices:
+    - model: "echo"
+      prompt: 0
+      completion: 0
+  quotas:
+    user:
+      daily: 0
+      monthly: 0
+    key:
+      daily: 0
+      monthly: 0
+    tenant:
+      daily: 0
+      monthly: 0
+
+jobs:
+  backend: "postgres"
+  workers: 4
+  poll_interval: 1000
+  timeout: 600
+  lease: 900
+  max_attempts: 3
+  webhook_timeout: 10
+  allow_private_networks: false
+
+webhooks:
+  enabled: true
+  poll_interval: 1000
+  batch_size: 50
+  timeout: 10
+  max_attempts: 8
+  base_delay: 30
+  max_delay: 3600
+  retention: 30
+  allow_private_networks: false
+
+channels:
+  timeout: 120
+  slack:
+    enabled: false
+    signing_secret: ""
+    api_base_url: "https://slack.com/api"
+    workspaces: []
+    # workspaces:
+    #   - team_id: "T0123456"
+    #     organization_id: ""
+    #     bot_token: ""
+    #     assistant_id: ""
+  sms:
+    enabled: false
+    account_sid: ""
+    auth_token: ""
+    api_base_url: "https://api.twilio.com"
+    webhook_url: ""
+    numbers: []
+    # numbers:
+    #   - number: "+15551234567"
+    #     organization_id: ""
+    #     assistant_id: ""
+  email:
+    enabled: false
+    poll_interval: 30
+    timeout: 60
+    mailboxes: []
+    # mailboxes:
+    #   - address: "support@example.com"
+    #     organization_id: ""
+    #     assistant_id: ""
+    #     imap_addr: "imap.example.com:993"
+    #     imap_tls: true
+    #     folder: "INBOX"
+    #     smtp_addr: "smtp.example.com:587"
+    #     username: ""
+    #     password: ""
 `
 		return os.WriteFile(configFile, []byte(sampleConfig), 0644)
 	}
 	return nil
 }
-
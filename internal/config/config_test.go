@@ -0,0 +1,19 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRetention(t *testing.T) {
+	config := &Config{Retention: RetentionConfig{Interval: 3600, BatchSize: 500}}
+	assert.NoError(t, config.validate())
+
+	config.Retention.Interval = 0
+	assert.ErrorContains(t, config.validate(), "retention.interval")
+
+	config.Retention.Interval = 3600
+	config.Retention.BatchSize = -1
+	assert.ErrorContains(t, config.validate(), "retention.batch_size")
+}
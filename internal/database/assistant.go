@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// AssistantRepository handles assistant-related database operations
+type AssistantRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewAssistantRepository creates a new assistant repository
+func NewAssistantRepository(db *gorm.DB, logger logger.Logger) *AssistantRepository {
+	return &AssistantRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateAssistant creates a new assistant
+func (r *AssistantRepository) CreateAssistant(ctx context.Context, assistant *models.Assistant) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(assistant).Error; err != nil {
+				return err
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionAssistantCreate,
+				TargetType: "assistant",
+				TargetID:   assistant.ID,
+				Diff:       diffValues(nil, assistantFields(assistant)),
+			})
+		})
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrConflict
+		}
+		r.logger.Error("Failed to create assistant", logger.F("error", err.Error()))
+		return err
+	}
+	r.logger.Info("Assistant created", logger.F("assistant_id", assistant.ID))
+	return nil
+}
+
+// GetAssistantByID retrieves an assistant by ID
+func (r *AssistantRepository) GetAssistantByID(ctx context.Context, id string) (*models.Assistant, error) {
+	var assistant models.Assistant
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("id = ?", id).First(&assistant).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get assistant", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return &assistant, nil
+}
+
+// GetAssistantByName retrieves an assistant by its name, which is unique
+// within an organization
+func (r *AssistantRepository) GetAssistantByName(ctx context.Context, name string) (*models.Assistant, error) {
+	var assistant models.Assistant
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("name = ?", name).First(&assistant).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get assistant", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return &assistant, nil
+}
+
+// ListAssistants returns all assistants ordered by name
+func (r *AssistantRepository) ListAssistants(ctx context.Context) ([]models.Assistant, error) {
+	var assistants []models.Assistant
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Order("name ASC").Find(&assistants).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to list assistants", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return assistants, nil
+}
+
+// UpdateAssistant persists every field of an existing assistant
+func (r *AssistantRepository) UpdateAssistant(ctx context.Context, assistant *models.Assistant) error {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			var before models.Assistant
+			if err := tx.Where("id = ?", assistant.ID).Limit(1).Find(&before).Error; err != nil {
+				return err
+			}
+			result := tx.Model(assistant).
+				Select("name", "description", "system_prompt", "prompt_name", "provider", "model", "fallbacks", "temperature", "allowed_tools", "knowledge_bases", "cache_responses", "semantic_cache", "semantic_threshold").
+				Updates(assistant)
+			rows = result.RowsAffected
+			if result.Error != nil || rows == 0 {
+				return result.Error
+			}
+			if err := dropSemanticCache(tx, assistant.ID); err != nil {
+				return err
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionAssistantUpdate,
+				TargetType: "assistant",
+				TargetID:   assistant.ID,
+				Diff:       diffValues(assistantFields(&before), assistantFields(assistant)),
+			})
+		})
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrConflict
+		}
+		r.logger.Error("Failed to update assistant", logger.F("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	r.logger.Info("Assistant updated", logger.F("assistant_id", assistant.ID))
+	return nil
+}
+
+// DeleteAssistant soft-deletes an assistant. Existing sessions keep their reference.
+func (r *AssistantRepository) DeleteAssistant(ctx context.Context, id string) error {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			result := tx.Where("id = ?", id).Delete(&models.Assistant{})
+			rows = result.RowsAffected
+			if result.Error != nil || rows == 0 {
+				return result.Error
+			}
+			if err := dropSemanticCache(tx, id); err != nil {
+				return err
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionAssistantDelete,
+				TargetType: "assistant",
+				TargetID:   id,
+			})
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to delete assistant", logger.F("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	r.logger.Info("Assistant deleted", logger.F("assistant_id", id))
+	return nil
+}
+
+// assistantFields returns the configurable fields of an assistant for audit diffs
+func assistantFields(a *models.Assistant) map[string]interface{} {
+	return map[string]interface{}{
+		"name":               a.Name,
+		"description":        a.Description,
+		"system_prompt":      a.SystemPrompt,
+		"prompt_name":        a.PromptName,
+		"provider":           a.Provider,
+		"model":              a.Model,
+		"fallbacks":          []llm.Target(a.Fallbacks),
+		"temperature":        a.Temperature,
+		"allowed_tools":      []string(a.AllowedTools),
+		"knowledge_bases":    []string(a.KnowledgeBases),
+		"cache_responses":    a.CacheResponses,
+		"semantic_cache":     a.SemanticCache,
+		"semantic_threshold": a.SemanticThreshold,
+	}
+}
+
+// dropSemanticCache deletes an assistant's semantic cache entries, which were
+// generated with a configuration that no longer applies
+func dropSemanticCache(tx *gorm.DB, assistantID string) error {
+	return tx.Where("assistant_id = ?", assistantID).Delete(&models.SemanticCacheEntry{}).Error
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+func TestAssistantRepositoryCRUD(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	repo := NewAssistantRepository(db, logger.NewLogrusLogger("error", "text"))
+	ctx := orgContext("org-a")
+
+	assistant := &models.Assistant{Name: "support", Model: "gpt-4o"}
+	require.NoError(t, repo.CreateAssistant(ctx, assistant))
+	assert.NotEmpty(t, assistant.ID)
+	assert.Equal(t, "org-a", assistant.OrganizationID)
+
+	_, err := repo.GetAssistantByID(ctx, assistant.ID)
+	require.NoError(t, err)
+	_, err = repo.GetAssistantByName(ctx, "support")
+	require.NoError(t, err)
+	_, err = repo.ListAssistants(ctx)
+	require.NoError(t, err)
+
+	// Dry runs match no rows, so updates and deletes report not found
+	assistant.Model = "gpt-4o-mini"
+	assert.ErrorIs(t, repo.UpdateAssistant(ctx, assistant), ErrNotFound)
+	assert.ErrorIs(t, repo.DeleteAssistant(ctx, assistant.ID), ErrNotFound)
+
+	var inserts, deletes int
+	for _, stmt := range statements() {
+		switch {
+		case strings.HasPrefix(stmt.SQL, `INSERT INTO "assistants"`):
+			inserts++
+			assert.Contains(t, stmt.Vars, "org-a")
+		case strings.HasPrefix(stmt.SQL, `INSERT INTO "audit_events"`):
+		case strings.HasPrefix(stmt.SQL, `UPDATE "assistants" SET "deleted_at"`):
+			// Deletion is soft, within the organization
+			deletes++
+			assert.Contains(t, stmt.SQL, `"assistants"."organization_id" = `)
+			assert.Contains(t, stmt.Vars, "org-a")
+		default:
+			assert.Contains(t, stmt.SQL, `"organization_id" = `, stmt.SQL)
+			assert.Contains(t, stmt.Vars, "org-a", stmt.SQL)
+			assert.NotContains(t, stmt.SQL, "DELETE FROM", stmt.SQL)
+		}
+	}
+	assert.Equal(t, 1, inserts)
+	assert.Equal(t, 1, deletes)
+}
+
+func TestAssistantNameReusableAfterDelete(t *testing.T) {
+	s, err := schema.Parse(&models.Assistant{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+
+	index := s.LookIndex("idx_assistants_org_live_name")
+	require.NotNil(t, index)
+	assert.Equal(t, "UNIQUE", index.Class)
+	assert.Equal(t, "deleted_at IS NULL", index.Where)
+	require.Len(t, index.Fields, 2)
+	assert.Equal(t, "organization_id", index.Fields[0].DBName)
+	assert.Equal(t, "name", index.Fields[1].DBName)
+}
@@ -0,0 +1,253 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// verifyBatchSize is how many events VerifyChain loads at a time
+const verifyBatchSize = 1000
+
+// AuditFilter narrows an audit event query. Empty fields match everything.
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditVerification is the result of checking an organization's hash chain
+type AuditVerification struct {
+	Valid   bool   `json:"valid"`
+	Checked int64  `json:"checked"`
+	EventID string `json:"event_id,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// AuditRepository stores and queries audit events
+type AuditRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *gorm.DB, logger logger.Logger) *AuditRepository {
+	return &AuditRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Record appends an event to the organization's audit log
+func (r *AuditRepository) Record(ctx context.Context, event *models.AuditEvent) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			return appendAudit(ctx, tx, event)
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to record audit event",
+			logger.F("action", event.Action),
+			logger.F("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// ListEvents returns a page of events matching filter, newest first, together
+// with the total count
+func (r *AuditRepository) ListEvents(ctx context.Context, filter AuditFilter, offset, limit int) ([]models.AuditEvent, int64, error) {
+	var (
+		events []models.AuditEvent
+		total  int64
+	)
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		query := tx.Model(&models.AuditEvent{})
+		if filter.ActorID != "" {
+			query = query.Where("actor_id = ?", filter.ActorID)
+		}
+		if filter.Action != "" {
+			query = query.Where("action = ?", filter.Action)
+		}
+		if filter.TargetType != "" {
+			query = query.Where("target_type = ?", filter.TargetType)
+		}
+		if filter.TargetID != "" {
+			query = query.Where("target_id = ?", filter.TargetID)
+		}
+		if filter.From != nil {
+			query = query.Where("created_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			query = query.Where("created_at < ?", *filter.To)
+		}
+
+		if err := query.Count(&total).Error; err != nil {
+			return err
+		}
+		return query.Order("sequence DESC").Offset(offset).Limit(limit).Find(&events).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to list audit events", logger.F("error", err.Error()))
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// VerifyChain recomputes the organization's hash chain and reports the first
+// event that was altered, or that follows a removed event
+func (r *AuditRepository) VerifyChain(ctx context.Context) (*AuditVerification, error) {
+	result := &AuditVerification{Valid: true}
+	var (
+		prevHash string
+		prevSeq  int64
+	)
+
+	for {
+		var events []models.AuditEvent
+		err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+			return tx.Where("sequence > ?", prevSeq).Order("sequence").Limit(verifyBatchSize).Find(&events).Error
+		})
+		if err != nil {
+			r.logger.Error("Failed to verify audit log", logger.F("error", err.Error()))
+			return nil, err
+		}
+
+		checked, eventID, reason := verifyChain(prevHash, prevSeq, events)
+		result.Checked += int64(checked)
+		if reason != "" {
+			result.Valid = false
+			result.EventID = eventID
+			result.Reason = reason
+			r.logger.Warn("Audit log chain is broken",
+				logger.F("event_id", eventID),
+				logger.F("reason", reason),
+			)
+			return result, nil
+		}
+		if len(events) < verifyBatchSize {
+			return result, nil
+		}
+
+		last := events[len(events)-1]
+		prevHash, prevSeq = last.Hash, last.Sequence
+	}
+}
+
+// verifyChain checks that events continue a chain ending at prevSeq with
+// prevHash. It returns how many events passed and, for the first that did
+// not, its ID and why.
+func verifyChain(prevHash string, prevSeq int64, events []models.AuditEvent) (int, string, string) {
+	for i := range events {
+		event := &events[i]
+		switch {
+		case event.Sequence != prevSeq+1:
+			return i, event.ID, fmt.Sprintf("expected sequence %d, found %d", prevSeq+1, event.Sequence)
+		case event.PrevHash != prevHash:
+			return i, event.ID, "previous hash does not match the preceding event"
+		}
+
+		hash, err := event.ComputeHash()
+		if err != nil {
+			return i, event.ID, err.Error()
+		}
+		if hash != event.Hash {
+			return i, event.ID, "hash does not match the event's contents"
+		}
+		prevHash, prevSeq = event.Hash, event.Sequence
+	}
+	return len(events), "", ""
+}
+
+// appendAudit adds an event to the end of its organization's hash chain. It
+// must run inside the transaction that makes the audited change, so the two
+// commit or roll back together. The actor, IP and request ID are taken from
+// ctx when the event does not set them.
+func appendAudit(ctx context.Context, tx *gorm.DB, event *models.AuditEvent) error {
+	orgID, ok := auth.OrganizationID(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	identity, _ := auth.FromContext(ctx)
+	req := audit.RequestFrom(ctx)
+
+	event.OrganizationID = orgID
+	if event.ActorID == "" {
+		event.ActorID = identity.UserID
+	}
+	if event.IP == "" {
+		event.IP = req.IP
+	}
+	if event.RequestID == "" {
+		event.RequestID = req.RequestID
+	}
+
+	// Serialize appends per organization so each event sees its predecessor
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "audit_events:"+orgID).Error; err != nil {
+		return err
+	}
+
+	var last models.AuditEvent
+	if err := tx.Select("sequence", "hash").Order("sequence DESC").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+
+	if event.ID == "" {
+		event.ID = models.NewID()
+	}
+	event.Sequence = last.Sequence + 1
+	event.PrevHash = last.Hash
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	hash, err := event.ComputeHash()
+	if err != nil {
+		return err
+	}
+	event.Hash = hash
+
+	return tx.Create(event).Error
+}
+
+// diffValues returns the fields whose values differ between before and after
+func diffValues(before, after map[string]interface{}) models.AuditDiff {
+	diff := make(models.AuditDiff)
+	for field, to := range after {
+		from := before[field]
+		if !reflect.DeepEqual(from, to) {
+			diff[field] = models.AuditChange{From: from, To: to}
+		}
+	}
+	return diff
+}
+
+// protectAuditLog makes the audit table append-only at the database level
+func (d *Database) protectAuditLog() error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_events is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events`,
+		`CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+		FOR EACH ROW EXECUTE FUNCTION audit_events_append_only()`,
+	}
+	for _, sql := range statements {
+		if err := d.DB.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to protect audit log: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+func auditChain(t *testing.T, n int) []models.AuditEvent {
+	t.Helper()
+	events := make([]models.AuditEvent, n)
+	prev := ""
+	for i := range events {
+		e := &events[i]
+		e.ID = models.NewID()
+		e.OrganizationID = "org-1"
+		e.Sequence = int64(i + 1)
+		e.Action = "user.delete"
+		e.TargetID = "user-1"
+		e.Metadata = models.AuditMetadata{"n": i}
+		e.CreatedAt = time.Date(2024, 5, 1, 12, 0, i, 123456000, time.UTC)
+		e.PrevHash = prev
+
+		hash, err := e.ComputeHash()
+		require.NoError(t, err)
+		e.Hash = hash
+		prev = hash
+	}
+	return events
+}
+
+func TestVerifyChain(t *testing.T) {
+	checked, _, reason := verifyChain("", 0, auditChain(t, 3))
+	assert.Equal(t, 3, checked)
+	assert.Empty(t, reason)
+
+	tampered := auditChain(t, 3)
+	tampered[1].TargetID = "user-2"
+	checked, id, reason := verifyChain("", 0, tampered)
+	assert.Equal(t, 1, checked)
+	assert.Equal(t, tampered[1].ID, id)
+	assert.NotEmpty(t, reason)
+
+	removed := auditChain(t, 3)
+	removed = append(removed[:1], removed[2])
+	checked, id, reason = verifyChain("", 0, removed)
+	assert.Equal(t, 1, checked)
+	assert.Equal(t, removed[1].ID, id)
+	assert.NotEmpty(t, reason)
+}
+
+func TestComputeHashSurvivesStorage(t *testing.T) {
+	event := auditChain(t, 1)[0]
+	event.Metadata = models.AuditMetadata{"erased": map[string]int64{"chat_messages": 3}}
+	before, err := event.ComputeHash()
+	require.NoError(t, err)
+
+	// Simulate the jsonb round trip, which turns numbers into float64
+	stored, err := event.Metadata.Value()
+	require.NoError(t, err)
+	var loaded models.AuditMetadata
+	require.NoError(t, loaded.Scan(stored))
+	event.Metadata = loaded
+
+	after, err := event.ComputeHash()
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func TestDiffValues(t *testing.T) {
+	diff := diffValues(
+		map[string]interface{}{"model": "a", "temperature": 0.7, "allowed_tools": []string{"x"}},
+		map[string]interface{}{"model": "b", "temperature": 0.7, "allowed_tools": []string{"x"}},
+	)
+	assert.Equal(t, models.AuditDiff{"model": {From: "a", To: "b"}}, diff)
+}
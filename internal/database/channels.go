@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// ChannelRepository links accounts on messaging channels to users
+type ChannelRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewChannelRepository creates a new channel repository
+func NewChannelRepository(db *gorm.DB, logger logger.Logger) *ChannelRepository {
+	return &ChannelRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// LinkedUser returns the user a channel account chats as. The first time the
+// account is seen, newUser is created and linked to it. It returns nil if
+// the linked user has since been deleted, which blocks the account.
+func (r *ChannelRepository) LinkedUser(ctx context.Context, channel, externalID string, newUser *models.User) (*models.User, error) {
+	user, err := r.linkedUser(ctx, channel, externalID, newUser)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// Another request linked the account first
+		user, err = r.linkedUser(ctx, channel, externalID, newUser)
+	}
+	if err != nil {
+		r.logger.Error("Failed to resolve channel account",
+			logger.F("channel", channel),
+			logger.F("error", err.Error()),
+		)
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *ChannelRepository) linkedUser(ctx context.Context, channel, externalID string, newUser *models.User) (*models.User, error) {
+	var user *models.User
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			var account models.ChannelAccount
+			err := tx.Where("channel = ? AND external_id = ?", channel, externalID).Limit(1).Find(&account).Error
+			if err != nil {
+				return err
+			}
+
+			if account.ID != "" {
+				var linked models.User
+				if err := tx.Where("id = ?", account.UserID).Limit(1).Find(&linked).Error; err != nil {
+					return err
+				}
+				if linked.ID != "" {
+					user = &linked
+				}
+				return nil
+			}
+
+			if err := tx.Create(newUser).Error; err != nil {
+				return err
+			}
+			user = newUser
+			return tx.Create(&models.ChannelAccount{
+				Channel:    channel,
+				ExternalID: externalID,
+				UserID:     newUser.ID,
+			}).Error
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if user == newUser {
+		r.logger.Info("Channel account linked",
+			logger.F("channel", channel),
+			logger.F("user_id", user.ID),
+		)
+	}
+	return user, nil
+}
+
+// GetSessionByThread returns the user's session held in a channel thread, or
+// nil if there is none
+func (r *ChatRepository) GetSessionByThread(ctx context.Context, channel, thread, userID string) (*models.ChatSession, error) {
+	var session models.ChatSession
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("channel = ? AND channel_thread = ? AND user_id = ?", channel, thread, userID).
+			Order("created_at DESC").
+			First(&session).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get session by thread", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return &session, nil
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,10 +11,19 @@ import (
 	gormlogger "gorm.io/gorm/logger"
 
 	"github.com/Ai-chat-agent/Chat-Agent.git/internal/config"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
 	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
 	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
 )
 
+var (
+	// ErrNotFound is returned when a record to modify does not exist
+	ErrNotFound = errors.New("database: record not found")
+	// ErrConflict is returned when a write violates a unique constraint
+	ErrConflict = errors.New("database: record already exists")
+)
+
 // Database wraps the database connection
 type Database struct {
 	DB     *gorm.DB
@@ -31,7 +42,8 @@ func New(cfg *config.DatabaseConfig, log logger.Logger) (*Database, error) {
 	}
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: gormLogger,
+		Logger:         gormLogger,
+		TranslateError: true,
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
@@ -47,6 +59,10 @@ func New(cfg *config.DatabaseConfig, log logger.Logger) (*Database, error) {
 		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 
+	if err := db.Use(&TenantPlugin{RowLevelSecurity: cfg.RowLevelSecurity}); err != nil {
+		return nil, fmt.Errorf("failed to register tenant plugin: %w", err)
+	}
+
 	sqlDB.SetMaxIdleConns(10)
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
@@ -64,12 +80,47 @@ func New(cfg *config.DatabaseConfig, log logger.Logger) (*Database, error) {
 	return database, nil
 }
 
+// tenantModels lists the tables whose rows belong to a single organization
+var tenantModels = []interface{}{
+	&models.User{},
+	&models.ChatSession{},
+	&models.ChatMessage{},
+	&models.PromptTemplate{},
+	&models.Assistant{},
+	&models.ModerationFlag{},
+	&models.MessageVault{},
+	&models.RetentionPolicy{},
+	&models.AuditEvent{},
+	&models.MessageFeedback{},
+	&models.UsageRecord{},
+	&models.KnowledgeBase{},
+	&models.SemanticCacheEntry{},
+	&models.WebhookEndpoint{},
+	&models.ChannelAccount{},
+}
+
 // AutoMigrate runs database migrations
 func (d *Database) AutoMigrate() error {
 	err := d.DB.AutoMigrate(
+		&models.Organization{},
+		&models.APIKey{},
 		&models.User{},
 		&models.ChatSession{},
 		&models.ChatMessage{},
+		&models.PromptTemplate{},
+		&models.Assistant{},
+		&models.ModerationFlag{},
+		&models.MessageVault{},
+		&models.RetentionPolicy{},
+		&models.AuditEvent{},
+		&models.MessageFeedback{},
+		&models.UsageRecord{},
+		&models.KnowledgeBase{},
+		&models.SemanticCacheEntry{},
+		&models.Job{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.ChannelAccount{},
 	)
 
 	if err != nil {
@@ -77,10 +128,45 @@ func (d *Database) AutoMigrate() error {
 		return fmt.Errorf("database migration failed: %w", err)
 	}
 
+	if err := d.dropLegacySchema(); err != nil {
+		d.logger.Error("Dropping legacy schema failed", logger.F("error", err.Error()))
+		return err
+	}
+
+	if err := d.protectAuditLog(); err != nil {
+		d.logger.Error("Protecting the audit log failed", logger.F("error", err.Error()))
+		return err
+	}
+
+	if rowLevelSecurity(d.DB) {
+		if err := d.enableRowLevelSecurity(tenantModels...); err != nil {
+			d.logger.Error("Enabling row-level security failed", logger.F("error", err.Error()))
+			return err
+		}
+	}
+
 	d.logger.Info("Database migration completed successfully")
 	return nil
 }
 
+// legacySchema drops unique constraints and indexes replaced by ones that
+// apply per organization, to rows that are not deleted. AutoMigrate does not
+// drop them, so databases created before the change would still enforce them.
+var legacySchema = []string{
+	"ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_key",
+	"ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key",
+	"DROP INDEX IF EXISTS idx_assistants_org_name",
+}
+
+func (d *Database) dropLegacySchema() error {
+	for _, sql := range legacySchema {
+		if err := d.DB.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to drop legacy schema: %w", err)
+		}
+	}
+	return nil
+}
+
 // Close closes the database connection
 func (d *Database) Close() error {
 	sqlDB, err := d.DB.DB()
@@ -100,6 +186,9 @@ func (d *Database) Health() error {
 }
 
 // Repository interfaces and implementations
+//
+// Repository methods take a context carrying the caller's organization (see
+// auth.WithIdentity); every query is restricted to that organization.
 
 // UserRepository handles user-related database operations
 type UserRepository struct {
@@ -116,8 +205,14 @@ func NewUserRepository(db *gorm.DB, logger logger.Logger) *UserRepository {
 }
 
 // CreateUser creates a new user
-func (r *UserRepository) CreateUser(user *models.User) error {
-	if err := r.db.Create(user).Error; err != nil {
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Create(user).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrConflict
+		}
 		r.logger.Error("Failed to create user", logger.F("error", err.Error()))
 		return err
 	}
@@ -126,9 +221,12 @@ func (r *UserRepository) CreateUser(user *models.User) error {
 }
 
 // GetUserByID retrieves a user by ID
-func (r *UserRepository) GetUserByID(id string) (*models.User, error) {
+func (r *UserRepository) GetUserByID(ctx context.Context, id string) (*models.User, error) {
 	var user models.User
-	if err := r.db.Where("id = ?", id).First(&user).Error; err != nil {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("id = ?", id).First(&user).Error
+	})
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
@@ -138,6 +236,111 @@ func (r *UserRepository) GetUserByID(id string) (*models.User, error) {
 	return &user, nil
 }
 
+// ListUsers returns a page of users ordered by creation time, together with the total count
+func (r *UserRepository) ListUsers(ctx context.Context, offset, limit int) ([]models.User, int64, error) {
+	var (
+		users []models.User
+		total int64
+	)
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Count(&total).Error; err != nil {
+			return err
+		}
+		return tx.Order("created_at ASC").Offset(offset).Limit(limit).Find(&users).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to list users", logger.F("error", err.Error()))
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// UpdateUser persists the profile fields of an existing user
+func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		result := tx.Model(user).Select("username", "email", "display_name").Updates(user)
+		rows = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrConflict
+		}
+		r.logger.Error("Failed to update user", logger.F("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	r.logger.Info("User updated", logger.F("user_id", user.ID))
+	return nil
+}
+
+// UpdateUserRole changes a user's role
+func (r *UserRepository) UpdateUserRole(ctx context.Context, id string, role auth.Role) error {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			var user models.User
+			if err := tx.Select("id", "role").Where("id = ?", id).Limit(1).Find(&user).Error; err != nil {
+				return err
+			}
+			result := tx.Model(&models.User{}).Where("id = ?", id).Update("role", role)
+			rows = result.RowsAffected
+			if result.Error != nil || rows == 0 {
+				return result.Error
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionUserRoleChange,
+				TargetType: "user",
+				TargetID:   id,
+				Diff:       models.AuditDiff{"role": {From: user.Role, To: role}},
+			})
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to update user role", logger.F("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	r.logger.Info("User role updated",
+		logger.F("user_id", id),
+		logger.F("role", role),
+	)
+	return nil
+}
+
+// DeleteUser soft-deletes a user by ID
+func (r *UserRepository) DeleteUser(ctx context.Context, id string) error {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			result := tx.Where("id = ?", id).Delete(&models.User{})
+			rows = result.RowsAffected
+			if result.Error != nil || rows == 0 {
+				return result.Error
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionUserDelete,
+				TargetType: "user",
+				TargetID:   id,
+			})
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to delete user", logger.F("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	r.logger.Info("User deleted", logger.F("user_id", id))
+	return nil
+}
+
 // ChatRepository handles chat-related database operations
 type ChatRepository struct {
 	db     *gorm.DB
@@ -152,9 +355,72 @@ func NewChatRepository(db *gorm.DB, logger logger.Logger) *ChatRepository {
 	}
 }
 
+// CreateSession creates a new chat session
+func (r *ChatRepository) CreateSession(ctx context.Context, session *models.ChatSession) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Create(session).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to create session", logger.F("error", err.Error()))
+		return err
+	}
+	r.logger.Info("Session created", logger.F("session_id", session.ID))
+	return nil
+}
+
+// GetSessionByID retrieves a session by ID
+func (r *ChatRepository) GetSessionByID(ctx context.Context, id string) (*models.ChatSession, error) {
+	var session models.ChatSession
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("id = ?", id).First(&session).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get session", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetSessionByConversation returns the user's open session with the
+// assistant whose conversation has the given digest, or nil if there is none
+func (r *ChatRepository) GetSessionByConversation(ctx context.Context, userID, assistantID, digest string) (*models.ChatSession, error) {
+	var session models.ChatSession
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("conversation_digest = ? AND user_id = ? AND assistant_id = ? AND is_active = ?", digest, userID, assistantID, true).
+			Order("updated_at DESC").
+			First(&session).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get session by conversation", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return &session, nil
+}
+
+// SetConversationDigest records the digest of a session's conversation
+func (r *ChatRepository) SetConversationDigest(ctx context.Context, sessionID, digest string) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Model(&models.ChatSession{}).Where("id = ?", sessionID).Update("conversation_digest", digest).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to set conversation digest", logger.F("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
 // CreateMessage creates a new chat message
-func (r *ChatRepository) CreateMessage(message *models.ChatMessage) error {
-	if err := r.db.Create(message).Error; err != nil {
+func (r *ChatRepository) CreateMessage(ctx context.Context, message *models.ChatMessage) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Create(message).Error
+	})
+	if err != nil {
 		r.logger.Error("Failed to create message", logger.F("error", err.Error()))
 		return err
 	}
@@ -162,30 +428,101 @@ func (r *ChatRepository) CreateMessage(message *models.ChatMessage) error {
 	return nil
 }
 
-// GetMessagesByUserID retrieves messages for a specific user
-func (r *ChatRepository) GetMessagesByUserID(userID string, limit int) ([]models.ChatMessage, error) {
-	var messages []models.ChatMessage
-	query := r.db.Where("user_id = ?", userID).Order("created_at DESC")
-
-	if limit > 0 {
-		query = query.Limit(limit)
+// GetMessageByID retrieves a message by ID
+func (r *ChatRepository) GetMessageByID(ctx context.Context, id string) (*models.ChatMessage, error) {
+	var message models.ChatMessage
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("id = ?", id).First(&message).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get message", logger.F("error", err.Error()))
+		return nil, err
 	}
+	return &message, nil
+}
 
-	if err := query.Find(&messages).Error; err != nil {
-		r.logger.Error("Failed to get messages", logger.F("error", err.Error()))
+// GetMessageVault retrieves the encrypted original of a redacted message
+func (r *ChatRepository) GetMessageVault(ctx context.Context, messageID string) (*models.MessageVault, error) {
+	var vault models.MessageVault
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("message_id = ?", messageID).First(&vault).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get message vault", logger.F("error", err.Error()))
 		return nil, err
 	}
-
-	return messages, nil
+	return &vault, nil
 }
 
-// DeleteMessage deletes a message by ID
-func (r *ChatRepository) DeleteMessage(messageID string) error {
-	if err := r.db.Where("id = ?", messageID).Delete(&models.ChatMessage{}).Error; err != nil {
+// DeleteMessage soft-deletes a message by ID. When ownerID is not empty only a
+// message sent by that user is deleted; otherwise ErrNotFound is returned.
+func (r *ChatRepository) DeleteMessage(ctx context.Context, messageID, ownerID string) error {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			query := tx.Where("id = ?", messageID)
+			if ownerID != "" {
+				query = query.Where("user_id = ?", ownerID)
+			}
+			result := query.Delete(&models.ChatMessage{})
+			rows = result.RowsAffected
+			if result.Error != nil || rows == 0 {
+				return result.Error
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionMessageDelete,
+				TargetType: "message",
+				TargetID:   messageID,
+			})
+		})
+	})
+	if err != nil {
 		r.logger.Error("Failed to delete message", logger.F("error", err.Error()))
 		return err
 	}
+	if rows == 0 {
+		return ErrNotFound
+	}
 	r.logger.Info("Message deleted", logger.F("message_id", messageID))
 	return nil
 }
 
+// RestoreMessage undoes the deletion of a message deleted less than window ago.
+// ownerID restricts the restore the same way as in DeleteMessage.
+func (r *ChatRepository) RestoreMessage(ctx context.Context, messageID, ownerID string, window time.Duration) error {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			query := tx.Unscoped().Model(&models.ChatMessage{}).
+				Where("id = ? AND deleted_at IS NOT NULL AND deleted_at > ?", messageID, time.Now().UTC().Add(-window))
+			if ownerID != "" {
+				query = query.Where("user_id = ?", ownerID)
+			}
+			result := query.Update("deleted_at", nil)
+			rows = result.RowsAffected
+			if result.Error != nil || rows == 0 {
+				return result.Error
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionMessageRestore,
+				TargetType: "message",
+				TargetID:   messageID,
+			})
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to restore message", logger.F("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	r.logger.Info("Message restored", logger.F("message_id", messageID))
+	return nil
+}
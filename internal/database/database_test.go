@@ -0,0 +1,40 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+)
+
+func TestDeleteAndRestoreMessageOwnership(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	repo := NewChatRepository(db, logger.NewLogrusLogger("error", "text"))
+	ctx := orgContext("org-a")
+
+	// Dry runs match no rows: another user's message is reported as not found
+	assert.ErrorIs(t, repo.DeleteMessage(ctx, "msg-1", "user-1"), ErrNotFound)
+	assert.ErrorIs(t, repo.RestoreMessage(ctx, "msg-1", "user-1", time.Hour), ErrNotFound)
+	// Moderators delete and restore without an owner
+	assert.ErrorIs(t, repo.DeleteMessage(ctx, "msg-2", ""), ErrNotFound)
+	assert.ErrorIs(t, repo.RestoreMessage(ctx, "msg-2", "", time.Hour), ErrNotFound)
+
+	built := statements()
+	require.Len(t, built, 4)
+	for i, stmt := range built {
+		assert.Contains(t, stmt.SQL, `UPDATE "chat_messages" SET "deleted_at"`)
+		assert.Contains(t, stmt.SQL, `"chat_messages"."organization_id" = `)
+		assert.Contains(t, stmt.Vars, "org-a")
+		if i < 2 {
+			assert.Contains(t, stmt.SQL, "user_id = ")
+			assert.Contains(t, stmt.Vars, "user-1")
+		} else {
+			assert.NotContains(t, stmt.SQL, "user_id = ")
+		}
+	}
+	// Only messages deleted within the window can be restored
+	assert.Contains(t, built[1].SQL, "deleted_at IS NOT NULL AND deleted_at > ")
+}
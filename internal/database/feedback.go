@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// Dimensions feedback can be aggregated by
+const (
+	FeedbackByAssistant = "assistant"
+	FeedbackByPrompt    = "prompt"
+	FeedbackByModel     = "model"
+)
+
+// feedbackGroups maps each dimension to the columns it groups by
+var feedbackGroups = map[string][]string{
+	FeedbackByAssistant: {"assistant_id"},
+	FeedbackByPrompt:    {"prompt_name", "prompt_version"},
+	FeedbackByModel:     {"model"},
+}
+
+// ValidFeedbackDimension reports whether feedback can be aggregated by by
+func ValidFeedbackDimension(by string) bool {
+	_, ok := feedbackGroups[by]
+	return ok
+}
+
+// FeedbackFilter narrows a feedback aggregation. Empty fields match everything.
+type FeedbackFilter struct {
+	AssistantID string
+	PromptName  string
+	From        *time.Time
+	To          *time.Time
+}
+
+// FeedbackRepository stores ratings of assistant messages
+type FeedbackRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewFeedbackRepository creates a new feedback repository
+func NewFeedbackRepository(db *gorm.DB, logger logger.Logger) *FeedbackRepository {
+	return &FeedbackRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveFeedback stores a user's rating of a message, replacing any earlier
+// rating they gave it. When a rating is replaced, feedback is given the ID
+// and creation time of the stored row.
+func (r *FeedbackRepository) SaveFeedback(ctx context.Context, feedback *models.MessageFeedback) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Clauses(
+			clause.OnConflict{
+				Columns:   []clause.Column{{Name: "message_id"}, {Name: "user_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"rating", "reason", "comment", "updated_at"}),
+			},
+			clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "created_at"}}},
+		).Create(feedback).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to save feedback", logger.F("error", err.Error()))
+		return err
+	}
+	r.logger.Info("Feedback saved",
+		logger.F("message_id", feedback.MessageID),
+		logger.F("rating", feedback.Rating),
+	)
+	return nil
+}
+
+// AggregateFeedback counts ratings per assistant, prompt version or model,
+// busiest first
+func (r *FeedbackRepository) AggregateFeedback(ctx context.Context, by string, filter FeedbackFilter) ([]models.FeedbackAggregate, error) {
+	columns, ok := feedbackGroups[by]
+	if !ok {
+		return nil, fmt.Errorf("unknown feedback dimension %q", by)
+	}
+
+	var aggregates []models.FeedbackAggregate
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		query := tx.Model(&models.MessageFeedback{})
+		if filter.AssistantID != "" {
+			query = query.Where("assistant_id = ?", filter.AssistantID)
+		}
+		if filter.PromptName != "" {
+			query = query.Where("prompt_name = ?", filter.PromptName)
+		}
+		if filter.From != nil {
+			query = query.Where("created_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			query = query.Where("created_at < ?", *filter.To)
+		}
+
+		selects := append([]string(nil), columns...)
+		if by == FeedbackByAssistant {
+			selects[0] = "COALESCE(assistant_id, '') AS assistant_id"
+		}
+		selects = append(selects,
+			fmt.Sprintf("COUNT(*) FILTER (WHERE rating = '%s') AS up", models.RatingUp),
+			fmt.Sprintf("COUNT(*) FILTER (WHERE rating = '%s') AS down", models.RatingDown),
+			"COUNT(*) AS total",
+		)
+		return query.Select(selects).Group(strings.Join(columns, ", ")).Order("total DESC").Scan(&aggregates).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to aggregate feedback", logger.F("error", err.Error()))
+		return nil, err
+	}
+
+	for i := range aggregates {
+		if aggregates[i].Total > 0 {
+			aggregates[i].Satisfaction = float64(aggregates[i].Up) / float64(aggregates[i].Total)
+		}
+	}
+	return aggregates, nil
+}
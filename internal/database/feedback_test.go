@@ -0,0 +1,30 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+func TestSaveFeedbackReturnsStoredRow(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	repo := NewFeedbackRepository(db, logger.NewLogrusLogger("error", "text"))
+
+	feedback := &models.MessageFeedback{MessageID: "msg-1", UserID: "user-1", SessionID: "session-1", Rating: models.RatingDown}
+	require.NoError(t, repo.SaveFeedback(orgContext("org-a"), feedback))
+	assert.Equal(t, "org-a", feedback.OrganizationID)
+
+	built := statements()
+	require.Len(t, built, 1)
+	sql := built[0].SQL
+	assert.Contains(t, sql, `ON CONFLICT ("message_id","user_id") DO UPDATE SET`)
+	// A replaced rating keeps the ID and creation time of the first one,
+	// which the database hands back rather than the values assigned here
+	assert.Contains(t, sql, `RETURNING "id","created_at"`)
+	assert.NotContains(t, sql, `"id"="excluded"."id"`)
+	assert.NotContains(t, sql, `"created_at"="excluded"."created_at"`)
+}
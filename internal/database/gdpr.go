@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// ExportUserData collects everything stored about a user, including deleted
+// messages and a soft-deleted user's profile. Messages come with their
+// sealed vault originals, which the caller decrypts.
+func (r *UserRepository) ExportUserData(ctx context.Context, userID string) (*models.UserExport, error) {
+	export := &models.UserExport{ExportedAt: time.Now().UTC()}
+
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("id = ?", userID).First(&export.User).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Order("created_at").Find(&export.Sessions).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Preload("Vault").Where("user_id = ?", userID).Order("created_at").Find(&export.Messages).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Order("created_at").Find(&export.ModerationFlags).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Order("created_at").Find(&export.Feedback).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Order("created_at").Find(&export.Usage).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Order("created_at").Find(&export.APIKeys).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Order("created_at").Find(&export.ChannelAccounts).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to export user data", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return export, nil
+}
+
+// EraseUser permanently deletes a user and every row that belongs to them in a
+// single transaction, and records the erasure in the audit log. Existing audit
+// events are kept. It returns the number of rows
+// removed per table.
+func (r *UserRepository) EraseUser(ctx context.Context, userID string) (map[string]int64, error) {
+	erased := make(map[string]int64)
+
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			// Children first; message vault rows go with their messages
+			for _, model := range []interface{}{
+				&models.SemanticCacheEntry{},
+				&models.Job{},
+				&models.WebhookDelivery{},
+				&models.MessageFeedback{},
+				&models.ChatMessage{},
+				&models.ChatSession{},
+				&models.ModerationFlag{},
+				&models.ChannelAccount{},
+				&models.APIKey{},
+			} {
+				result := tx.Unscoped().Where("user_id = ?", userID).Delete(model)
+				if result.Error != nil {
+					return result.Error
+				}
+				erased[result.Statement.Table] = result.RowsAffected
+			}
+
+			// Usage records are kept for billing but no longer name the user
+			result := tx.Model(&models.UsageRecord{}).Where("user_id = ?", userID).
+				Updates(map[string]interface{}{"user_id": "", "api_key_id": ""})
+			if result.Error != nil {
+				return result.Error
+			}
+			erased[result.Statement.Table] = result.RowsAffected
+
+			result = tx.Unscoped().Where("id = ?", userID).Delete(&models.User{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrNotFound
+			}
+			erased["users"] = result.RowsAffected
+
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionUserErase,
+				TargetType: "user",
+				TargetID:   userID,
+				Metadata:   models.AuditMetadata{"erased": erased},
+			})
+		})
+	})
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		r.logger.Error("Failed to erase user", logger.F("error", err.Error()))
+		return nil, err
+	}
+	r.logger.Info("User erased", logger.F("user_id", userID))
+	return erased, nil
+}
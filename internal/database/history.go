@@ -0,0 +1,259 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// pathCTE walks from the messages selected by start up to the root of their
+// tree. Deleted messages are kept while walking so that a deleted turn does
+// not cut its descendants off; callers filter them from the result.
+func pathCTE(start string) string {
+	return fmt.Sprintf(`WITH RECURSIVE path AS (
+	%s
+	UNION ALL
+	SELECT p.* FROM chat_messages p
+	JOIN path c ON p.id = c.parent_id
+	WHERE p.organization_id = @org
+)`, start)
+}
+
+// GetActivePath returns the messages on a session's active branch, oldest first
+func (r *ChatRepository) GetActivePath(ctx context.Context, sessionID string) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		orgID, _ := auth.OrganizationID(ctx)
+		sql := pathCTE(`SELECT m.* FROM chat_messages m
+	JOIN chat_sessions s ON s.active_message_id = m.id
+	WHERE s.id = @session AND s.organization_id = @org AND m.organization_id = @org`) +
+			` SELECT * FROM path WHERE deleted_at IS NULL ORDER BY created_at ASC`
+		return tx.Raw(sql, map[string]interface{}{"org": orgID, "session": sessionID}).Scan(&messages).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to get active path", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return messages, nil
+}
+
+// GetPathTo returns a message and its ancestors, oldest first
+func (r *ChatRepository) GetPathTo(ctx context.Context, messageID string) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		orgID, _ := auth.OrganizationID(ctx)
+		sql := pathCTE(`SELECT m.* FROM chat_messages m
+	WHERE m.id = @message AND m.organization_id = @org`) +
+			` SELECT * FROM path WHERE deleted_at IS NULL ORDER BY created_at ASC`
+		return tx.Raw(sql, map[string]interface{}{"org": orgID, "message": messageID}).Scan(&messages).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to get message path", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return messages, nil
+}
+
+// GetMessagesByUserID retrieves the most recent messages on the active branch
+// of each of a user's sessions
+func (r *ChatRepository) GetMessagesByUserID(ctx context.Context, userID string, limit int) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		orgID, _ := auth.OrganizationID(ctx)
+		sql := pathCTE(`SELECT m.* FROM chat_messages m
+	JOIN chat_sessions s ON s.active_message_id = m.id
+	WHERE s.user_id = @user AND s.organization_id = @org AND m.organization_id = @org`) +
+			` SELECT * FROM path WHERE deleted_at IS NULL ORDER BY created_at DESC`
+		if limit > 0 {
+			sql += fmt.Sprintf(" LIMIT %d", limit)
+		}
+		return tx.Raw(sql, map[string]interface{}{"org": orgID, "user": userID}).Scan(&messages).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to get messages", logger.F("error", err.Error()))
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// SetActiveMessage makes messageID the leaf of the session's active branch
+func (r *ChatRepository) SetActiveMessage(ctx context.Context, sessionID, messageID string) error {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		result := tx.Model(&models.ChatSession{}).Where("id = ?", sessionID).Update("active_message_id", messageID)
+		rows = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to set active message", logger.F("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CloseSession marks a session as closed so it accepts no more messages. It
+// reports whether the session was open.
+func (r *ChatRepository) CloseSession(ctx context.Context, sessionID string) (bool, error) {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		result := tx.Model(&models.ChatSession{}).
+			Where("id = ? AND is_active = ?", sessionID, true).
+			Update("is_active", false)
+		rows = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to close session", logger.F("error", err.Error()))
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// getSessionTree returns the id, parent, creation and deletion time of every
+// message in a session, oldest first. Deleted messages are included, like in
+// pathCTE, so their descendants stay linked to the tree.
+func (r *ChatRepository) getSessionTree(ctx context.Context, sessionID string) ([]models.ChatMessage, error) {
+	var nodes []models.ChatMessage
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Unscoped().Select("id", "parent_id", "created_at", "deleted_at").
+			Where("session_id = ?", sessionID).
+			Order("created_at ASC").
+			Find(&nodes).Error
+	})
+	return nodes, err
+}
+
+// GetSessionMessages returns every message of a session across all branches,
+// oldest first. Deleted messages are included so callers can link their
+// children to a surviving ancestor; check DeletedAt before showing them.
+func (r *ChatRepository) GetSessionMessages(ctx context.Context, sessionID string) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Unscoped().Where("session_id = ?", sessionID).Order("created_at ASC").Find(&messages).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to get session messages", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return messages, nil
+}
+
+// SelectBranch switches a session to the branch containing messageID and
+// returns the new active leaf: the most recent live descendant of messageID
+func (r *ChatRepository) SelectBranch(ctx context.Context, sessionID, messageID string) (string, error) {
+	nodes, err := r.getSessionTree(ctx, sessionID)
+	if err != nil {
+		r.logger.Error("Failed to load session tree", logger.F("error", err.Error()))
+		return "", err
+	}
+
+	children := childrenByParent(nodes)
+	found := false
+	for _, n := range nodes {
+		if n.ID == messageID && !n.DeletedAt.Valid {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", ErrNotFound
+	}
+
+	leaf := latestLeaf(children, messageID)
+	if err := r.SetActiveMessage(ctx, sessionID, leaf); err != nil {
+		return "", err
+	}
+	r.logger.Info("Branch selected",
+		logger.F("session_id", sessionID),
+		logger.F("active_message_id", leaf),
+	)
+	return leaf, nil
+}
+
+// GetSessionHistory returns the session's active branch, oldest first, with
+// the alternatives available at each turn
+func (r *ChatRepository) GetSessionHistory(ctx context.Context, sessionID string) ([]models.HistoryMessage, error) {
+	path, err := r.GetActivePath(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := r.getSessionTree(ctx, sessionID)
+	if err != nil {
+		r.logger.Error("Failed to load session tree", logger.F("error", err.Error()))
+		return nil, err
+	}
+	byID := messagesByID(nodes)
+	children := childrenByParent(nodes)
+
+	history := make([]models.HistoryMessage, len(path))
+	for i, m := range path {
+		history[i] = models.HistoryMessage{
+			ChatMessage: m,
+			SiblingIDs:  children[liveAncestor(byID, m.ParentID)],
+		}
+	}
+	return history, nil
+}
+
+// childrenByParent groups the IDs of live messages by their nearest live
+// ancestor, preserving order, the way a session export links them. Messages
+// without one are grouped under the empty string.
+func childrenByParent(nodes []models.ChatMessage) map[string][]string {
+	byID := messagesByID(nodes)
+	children := make(map[string][]string)
+	for _, n := range nodes {
+		if n.DeletedAt.Valid {
+			continue
+		}
+		key := liveAncestor(byID, n.ParentID)
+		children[key] = append(children[key], n.ID)
+	}
+	return children
+}
+
+func messagesByID(nodes []models.ChatMessage) map[string]models.ChatMessage {
+	byID := make(map[string]models.ChatMessage, len(nodes))
+	for _, n := range nodes {
+		byID[n.ID] = n
+	}
+	return byID
+}
+
+// liveAncestor returns parentID if that message is live, or else its nearest
+// live ancestor. It returns "" when none is left.
+func liveAncestor(byID map[string]models.ChatMessage, parentID *string) string {
+	for seen := 0; parentID != nil && seen <= len(byID); seen++ {
+		parent, ok := byID[*parentID]
+		if !ok {
+			return ""
+		}
+		if !parent.DeletedAt.Valid {
+			return parent.ID
+		}
+		parentID = parent.ParentID
+	}
+	return ""
+}
+
+// latestLeaf follows the most recent child from messageID down to a leaf.
+// Given childrenByParent, deleted messages are passed over.
+func latestLeaf(children map[string][]string, messageID string) string {
+	leaf := messageID
+	for {
+		kids := children[leaf]
+		if len(kids) == 0 {
+			return leaf
+		}
+		leaf = kids[len(kids)-1]
+	}
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+func ptr(s string) *string { return &s }
+
+func TestChildrenByParentAndLatestLeaf(t *testing.T) {
+	// u1 -> a1
+	//    -> a2 -> u2 -> a3
+	// u3 (edited sibling of u1) -> a4
+	nodes := []models.ChatMessage{
+		{ID: "u1"},
+		{ID: "a1", ParentID: ptr("u1")},
+		{ID: "a2", ParentID: ptr("u1")},
+		{ID: "u2", ParentID: ptr("a2")},
+		{ID: "a3", ParentID: ptr("u2")},
+		{ID: "u3"},
+		{ID: "a4", ParentID: ptr("u3")},
+	}
+
+	children := childrenByParent(nodes)
+
+	assert.Equal(t, []string{"u1", "u3"}, children[""])
+	assert.Equal(t, []string{"a1", "a2"}, children["u1"])
+
+	assert.Equal(t, "a3", latestLeaf(children, "u1"))
+	assert.Equal(t, "a1", latestLeaf(children, "a1"))
+	assert.Equal(t, "a4", latestLeaf(children, "u3"))
+}
+
+func TestChildrenByParentPassesOverDeletedMessages(t *testing.T) {
+	// u1 -> a1
+	//    -> a2 (deleted) -> u2 -> a3
+	//                    -> u4 (deleted)
+	deleted := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	nodes := []models.ChatMessage{
+		{ID: "u1"},
+		{ID: "a1", ParentID: ptr("u1")},
+		{ID: "a2", ParentID: ptr("u1"), DeletedAt: deleted},
+		{ID: "u2", ParentID: ptr("a2")},
+		{ID: "a3", ParentID: ptr("u2")},
+		{ID: "u4", ParentID: ptr("a2"), DeletedAt: deleted},
+	}
+
+	children := childrenByParent(nodes)
+
+	assert.Equal(t, []string{"a1", "u2"}, children["u1"])
+	assert.NotContains(t, children, "a2")
+	assert.Equal(t, "a3", latestLeaf(children, "u1"))
+	assert.Equal(t, "u1", liveAncestor(messagesByID(nodes), ptr("a2")))
+}
+
+func TestGetSessionTreeIncludesDeletedMessages(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	repo := NewChatRepository(db, logger.NewLogrusLogger("error", "text"))
+
+	_, err := repo.getSessionTree(orgContext("org-a"), "session-1")
+	require.NoError(t, err)
+
+	built := statements()
+	require.Len(t, built, 1)
+	assert.Contains(t, built[0].SQL, `"deleted_at"`)
+	assert.NotContains(t, built[0].SQL, "deleted_at\" IS NULL")
+	assert.Contains(t, built[0].SQL, "organization_id")
+}
+
+func TestGetSessionByConversationMatchesOpenSessions(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	repo := NewChatRepository(db, logger.NewLogrusLogger("error", "text"))
+
+	_, err := repo.GetSessionByConversation(orgContext("org-a"), "user-1", "assistant-1", "digest")
+	require.NoError(t, err)
+
+	built := statements()
+	require.Len(t, built, 1)
+	assert.Contains(t, built[0].SQL, "conversation_digest = $1 AND user_id = $2 AND assistant_id = $3 AND is_active = $4")
+	assert.Contains(t, built[0].SQL, "organization_id")
+	assert.Equal(t, []interface{}{"digest", "user-1", "assistant-1", true}, built[0].Vars[:4])
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// importBatchSize bounds the number of messages inserted per statement
+const importBatchSize = 500
+
+// ImportSession stores an imported session together with all of its messages.
+// Timestamps and the open or closed state set on the records are kept; either
+// everything is stored or nothing.
+func (r *ChatRepository) ImportSession(ctx context.Context, session *models.ChatSession, messages []models.ChatMessage) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			closed := !session.IsActive
+			if err := tx.Omit("Messages").Create(session).Error; err != nil {
+				return err
+			}
+			if closed {
+				// A false IsActive is a zero value, so Create stored the
+				// column's default instead
+				if err := tx.Model(session).UpdateColumn("is_active", false).Error; err != nil {
+					return err
+				}
+			}
+			if len(messages) == 0 {
+				return nil
+			}
+			return tx.CreateInBatches(&messages, importBatchSize).Error
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to import session", logger.F("error", err.Error()))
+		return err
+	}
+	r.logger.Info("Session imported",
+		logger.F("session_id", session.ID),
+		logger.F("messages", len(messages)),
+	)
+	return nil
+}
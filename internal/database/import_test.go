@@ -0,0 +1,30 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+func TestImportSessionKeepsClosedState(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	repo := NewChatRepository(db, logger.NewLogrusLogger("error", "text"))
+
+	session := &models.ChatSession{ID: "session-1", UserID: "user-1"}
+	require.NoError(t, repo.ImportSession(orgContext("org-a"), session, nil))
+
+	built := statements()
+	require.Len(t, built, 2)
+	assert.Contains(t, built[0].SQL, `INSERT INTO "chat_sessions"`)
+	assert.Contains(t, built[1].SQL, `UPDATE "chat_sessions" SET "is_active"=$1`)
+	assert.Equal(t, false, built[1].Vars[0])
+
+	// An open session needs no update
+	open := &models.ChatSession{ID: "session-2", UserID: "user-1", IsActive: true}
+	require.NoError(t, repo.ImportSession(orgContext("org-a"), open, nil))
+	assert.Len(t, statements(), 3)
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/jobs"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// JobRepository is a jobs.Queue backed by Postgres. Workers on any number of
+// instances claim jobs with FOR UPDATE SKIP LOCKED, so each job goes to one
+// worker without blocking the others.
+type JobRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *gorm.DB, logger logger.Logger) *JobRepository {
+	return &JobRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Enqueue implements jobs.Queue
+func (r *JobRepository) Enqueue(ctx context.Context, job *models.Job) error {
+	job.Status = models.JobQueued
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Create(job).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to enqueue job", logger.F("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// Claim implements jobs.Queue. It runs across organizations.
+func (r *JobRepository) Claim(ctx context.Context, lease time.Duration, maxAttempts int) (*models.Job, error) {
+	now := time.Now().UTC()
+	var claimed []models.Job
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Job{}).
+			Where("status = ? AND lease_until <= ? AND attempts >= ?", models.JobRunning, now, maxAttempts).
+			Updates(map[string]interface{}{
+				"status":      models.JobFailed,
+				"error":       jobs.AbandonedMessage,
+				"lease_until": nil,
+				"finished_at": now,
+				"updated_at":  now,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Raw(`
+			UPDATE jobs SET status = ?, attempts = attempts + 1, lease_until = ?, started_at = ?, updated_at = ?
+			WHERE id = (
+				SELECT id FROM jobs
+				WHERE status = ? OR (status = ? AND lease_until <= ?)
+				ORDER BY created_at
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *`,
+			models.JobRunning, now.Add(lease), now, now,
+			models.JobQueued, models.JobRunning, now,
+		).Scan(&claimed).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to claim job", logger.F("error", err.Error()))
+		return nil, err
+	}
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+	return &claimed[0], nil
+}
+
+// Finish implements jobs.Queue
+func (r *JobRepository) Finish(ctx context.Context, job *models.Job) error {
+	now := time.Now().UTC()
+	err := r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND status = ? AND attempts = ?", job.ID, models.JobRunning, job.Attempts).
+		Updates(map[string]interface{}{
+			"status":      job.Status,
+			"error":       job.Error,
+			"reply_id":    job.ReplyID,
+			"lease_until": nil,
+			"finished_at": now,
+			"updated_at":  now,
+		}).Error
+	if err != nil {
+		r.logger.Error("Failed to finish job", logger.F("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// GetJob implements jobs.Queue
+func (r *JobRepository) GetJob(ctx context.Context, id string) (*models.Job, error) {
+	var job models.Job
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("id = ?", id).First(&job).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get job", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return &job, nil
+}
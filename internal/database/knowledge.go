@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// KnowledgeBaseRepository tracks knowledge base versions and the semantic
+// cache entries generated from them
+type KnowledgeBaseRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewKnowledgeBaseRepository creates a new knowledge base repository
+func NewKnowledgeBaseRepository(db *gorm.DB, logger logger.Logger) *KnowledgeBaseRepository {
+	return &KnowledgeBaseRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ListKnowledgeBases returns every knowledge base that has been versioned,
+// ordered by name
+func (r *KnowledgeBaseRepository) ListKnowledgeBases(ctx context.Context) ([]models.KnowledgeBase, error) {
+	var bases []models.KnowledgeBase
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Order("name ASC").Find(&bases).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to list knowledge bases", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return bases, nil
+}
+
+// KnowledgeBaseVersions returns the current version of each named knowledge
+// base. Knowledge bases that were never versioned are left out.
+func (r *KnowledgeBaseRepository) KnowledgeBaseVersions(ctx context.Context, names []string) (map[string]int, error) {
+	versions := make(map[string]int, len(names))
+	if len(names) == 0 {
+		return versions, nil
+	}
+
+	var bases []models.KnowledgeBase
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("name IN ?", names).Find(&bases).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to get knowledge base versions", logger.F("error", err.Error()))
+		return nil, err
+	}
+	for _, b := range bases {
+		versions[b.Name] = b.Version
+	}
+	return versions, nil
+}
+
+// RecordChange bumps the version of a knowledge base, creating it at version
+// 1 on its first change, and drops the semantic cache entries that drew on
+// it
+func (r *KnowledgeBaseRepository) RecordChange(ctx context.Context, name string) (*models.KnowledgeBase, error) {
+	var (
+		base    models.KnowledgeBase
+		dropped int64
+	)
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "organization_id"}, {Name: "name"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"version":    gorm.Expr("knowledge_bases.version + 1"),
+					"updated_at": time.Now().UTC(),
+				}),
+			}).Create(&models.KnowledgeBase{Name: name, Version: 1}).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Where("name = ?", name).First(&base).Error; err != nil {
+				return err
+			}
+
+			filter, err := json.Marshal([]string{name})
+			if err != nil {
+				return err
+			}
+			result := tx.Where("knowledge_bases @> ?", string(filter)).Delete(&models.SemanticCacheEntry{})
+			if result.Error != nil {
+				return result.Error
+			}
+			dropped = result.RowsAffected
+
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionKnowledgeBaseChange,
+				TargetType: "knowledge_base",
+				TargetID:   base.ID,
+				Diff:       diffValues(nil, map[string]interface{}{"name": name, "version": base.Version}),
+			})
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to record knowledge base change", logger.F("error", err.Error()))
+		return nil, err
+	}
+	r.logger.Info("Knowledge base changed",
+		logger.F("name", name),
+		logger.F("version", base.Version),
+		logger.F("dropped_cache_entries", dropped),
+	)
+	return &base, nil
+}
+
+// SemanticCandidates implements cache.SemanticStore
+func (r *KnowledgeBaseRepository) SemanticCandidates(ctx context.Context, assistantID, fingerprint string, limit int) ([]models.SemanticCacheEntry, error) {
+	var entries []models.SemanticCacheEntry
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		q := tx.Where("assistant_id = ? AND fingerprint = ?", assistantID, fingerprint).
+			Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC()).
+			Order("created_at DESC")
+		if limit > 0 {
+			q = q.Limit(limit)
+		}
+		return q.Find(&entries).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to get semantic cache entries", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return entries, nil
+}
+
+// SaveSemanticEntry implements cache.SemanticStore. It also drops the
+// assistant's expired entries, so they do not pile up.
+func (r *KnowledgeBaseRepository) SaveSemanticEntry(ctx context.Context, entry *models.SemanticCacheEntry) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			err := tx.Where("assistant_id = ?", entry.AssistantID).
+				Where("expires_at <= ?", time.Now().UTC()).
+				Delete(&models.SemanticCacheEntry{}).Error
+			if err != nil {
+				return err
+			}
+			return tx.Create(entry).Error
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to save semantic cache entry", logger.F("error", err.Error()))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// ModerationRepository stores messages flagged by moderation
+type ModerationRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewModerationRepository creates a new moderation repository
+func NewModerationRepository(db *gorm.DB, logger logger.Logger) *ModerationRepository {
+	return &ModerationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// RecordFlag stores a flagged message
+func (r *ModerationRepository) RecordFlag(ctx context.Context, flag *models.ModerationFlag) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Create(flag).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to record moderation flag", logger.F("error", err.Error()))
+		return err
+	}
+	r.logger.Info("Message flagged by moderation",
+		logger.F("flag_id", flag.ID),
+		logger.F("user_id", flag.UserID),
+	)
+	return nil
+}
+
+// ListFlags returns a page of flags, newest first, together with the total count
+func (r *ModerationRepository) ListFlags(ctx context.Context, offset, limit int) ([]models.ModerationFlag, int64, error) {
+	var (
+		flags []models.ModerationFlag
+		total int64
+	)
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ModerationFlag{}).Count(&total).Error; err != nil {
+			return err
+		}
+		return tx.Order("created_at DESC").Offset(offset).Limit(limit).Find(&flags).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to list moderation flags", logger.F("error", err.Error()))
+		return nil, 0, err
+	}
+	return flags, total, nil
+}
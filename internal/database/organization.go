@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// OrganizationRepository handles tenant-related database operations
+type OrganizationRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewOrganizationRepository creates a new organization repository
+func NewOrganizationRepository(db *gorm.DB, logger logger.Logger) *OrganizationRepository {
+	return &OrganizationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateOrganization creates a new organization
+func (r *OrganizationRepository) CreateOrganization(ctx context.Context, org *models.Organization) error {
+	if err := r.db.WithContext(ctx).Create(org).Error; err != nil {
+		r.logger.Error("Failed to create organization", logger.F("error", err.Error()))
+		return err
+	}
+	r.logger.Info("Organization created", logger.F("organization_id", org.ID))
+	return nil
+}
+
+// GetOrganizationByID retrieves an organization by ID
+func (r *OrganizationRepository) GetOrganizationByID(ctx context.Context, id string) (*models.Organization, error) {
+	var org models.Organization
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&org).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get organization", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return &org, nil
+}
+
+// ListOrganizations returns every organization. It is used by background jobs
+// that work through each tenant in turn.
+func (r *OrganizationRepository) ListOrganizations(ctx context.Context) ([]models.Organization, error) {
+	var orgs []models.Organization
+	if err := r.db.WithContext(ctx).Order("id").Find(&orgs).Error; err != nil {
+		r.logger.Error("Failed to list organizations", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// APIKeyRepository handles API key storage and resolution
+type APIKeyRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *gorm.DB, logger logger.Logger) *APIKeyRepository {
+	return &APIKeyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateAPIKey stores a new key for the user in the context's organization
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(key).Error; err != nil {
+				return err
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionAPIKeyCreate,
+				TargetType: "api_key",
+				TargetID:   key.ID,
+				Metadata:   models.AuditMetadata{"user_id": key.UserID, "name": key.Name},
+			})
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to create api key", logger.F("error", err.Error()))
+		return err
+	}
+	r.logger.Info("API key created",
+		logger.F("api_key_id", key.ID),
+		logger.F("user_id", key.UserID),
+	)
+	return nil
+}
+
+// ResolveAPIKey maps a bearer token to the identity it was issued for. The
+// key's user must still exist in the key's organization.
+func (r *APIKeyRepository) ResolveAPIKey(ctx context.Context, token string) (*auth.Identity, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", auth.HashKey(token)).First(&key).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, auth.ErrInvalidKey
+		}
+		r.logger.Error("Failed to resolve api key", logger.F("error", err.Error()))
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, auth.ErrInvalidKey
+	}
+
+	identity := auth.Identity{
+		UserID:         key.UserID,
+		OrganizationID: key.OrganizationID,
+		APIKeyID:       key.ID,
+	}
+
+	var user models.User
+	err = withTenant(auth.ForOrganization(ctx, key.OrganizationID), r.db, func(tx *gorm.DB) error {
+		return tx.Where("id = ?", key.UserID).First(&user).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, auth.ErrInvalidKey
+		}
+		r.logger.Error("Failed to load api key user", logger.F("error", err.Error()))
+		return nil, err
+	}
+
+	identity.Role = user.Role
+
+	if err := r.db.WithContext(ctx).Model(&key).Update("last_used_at", now).Error; err != nil {
+		r.logger.Warn("Failed to record api key usage", logger.F("error", err.Error()))
+	}
+
+	return &identity, nil
+}
+
+// ListAPIKeys returns the keys issued to a user
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to list api keys", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return keys, nil
+}
+
+// WebhookSecret returns the secret job webhooks of a key are signed with. It
+// is empty when the key no longer exists or was issued without one.
+func (r *APIKeyRepository) WebhookSecret(ctx context.Context, keyID string) (string, error) {
+	var keys []models.APIKey
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Select("webhook_secret").Where("id = ?", keyID).Limit(1).Find(&keys).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to get api key webhook secret", logger.F("error", err.Error()))
+		return "", err
+	}
+	if len(keys) == 0 {
+		return "", nil
+	}
+	return keys[0].WebhookSecret, nil
+}
+
+// RevokeAPIKey marks a user's key as revoked
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.APIKey{}).
+				Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+				Update("revoked_at", time.Now().UTC())
+			rows = result.RowsAffected
+			if result.Error != nil || rows == 0 {
+				return result.Error
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionAPIKeyRevoke,
+				TargetType: "api_key",
+				TargetID:   keyID,
+				Metadata:   models.AuditMetadata{"user_id": userID},
+			})
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to revoke api key", logger.F("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	r.logger.Info("API key revoked", logger.F("api_key_id", keyID))
+	return nil
+}
@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// PromptRepository handles prompt template storage and versioning
+type PromptRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewPromptRepository creates a new prompt repository
+func NewPromptRepository(db *gorm.DB, logger logger.Logger) *PromptRepository {
+	return &PromptRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateVersion stores tpl as the next version of its name, activating it if
+// tpl.IsActive is set
+func (r *PromptRepository) CreateVersion(ctx context.Context, tpl *models.PromptTemplate) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			// Lock the existing versions so concurrent creates get distinct numbers
+			var versions []int
+			err := tx.Model(&models.PromptTemplate{}).
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("name = ?", tpl.Name).
+				Pluck("version", &versions).Error
+			if err != nil {
+				return err
+			}
+
+			tpl.Version = 1
+			for _, v := range versions {
+				if v >= tpl.Version {
+					tpl.Version = v + 1
+				}
+			}
+
+			if tpl.IsActive {
+				if err := deactivatePrompts(tx, tpl.Name); err != nil {
+					return err
+				}
+			}
+			if err := tx.Create(tpl).Error; err != nil {
+				return err
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionPromptCreate,
+				TargetType: "prompt_template",
+				TargetID:   tpl.ID,
+				Metadata: models.AuditMetadata{
+					"name":    tpl.Name,
+					"version": tpl.Version,
+					"active":  tpl.IsActive,
+				},
+			})
+		})
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrConflict
+		}
+		r.logger.Error("Failed to create prompt version", logger.F("error", err.Error()))
+		return err
+	}
+	r.logger.Info("Prompt version created",
+		logger.F("name", tpl.Name),
+		logger.F("version", tpl.Version),
+		logger.F("active", tpl.IsActive),
+	)
+	return nil
+}
+
+// Promote makes a version the active one for its name
+func (r *PromptRepository) Promote(ctx context.Context, name string, version int) (*models.PromptTemplate, error) {
+	var tpl models.PromptTemplate
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("name = ? AND version = ?", name, version).First(&tpl).Error; err != nil {
+				return err
+			}
+			if err := deactivatePrompts(tx, name); err != nil {
+				return err
+			}
+			tpl.IsActive = true
+			if err := tx.Model(&tpl).Update("is_active", true).Error; err != nil {
+				return err
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionPromptPromote,
+				TargetType: "prompt_template",
+				TargetID:   tpl.ID,
+				Metadata:   models.AuditMetadata{"name": name, "version": version},
+			})
+		})
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		r.logger.Error("Failed to promote prompt version", logger.F("error", err.Error()))
+		return nil, err
+	}
+	r.logger.Info("Prompt version promoted",
+		logger.F("name", name),
+		logger.F("version", version),
+	)
+	return &tpl, nil
+}
+
+func deactivatePrompts(tx *gorm.DB, name string) error {
+	return tx.Model(&models.PromptTemplate{}).
+		Where("name = ? AND is_active = ?", name, true).
+		Update("is_active", false).Error
+}
+
+// ListTemplates returns every version of every template, or of one name if
+// name is not empty, newest first
+func (r *PromptRepository) ListTemplates(ctx context.Context, name string) ([]models.PromptTemplate, error) {
+	var templates []models.PromptTemplate
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		query := tx.Order("name ASC, version DESC")
+		if name != "" {
+			query = query.Where("name = ?", name)
+		}
+		return query.Find(&templates).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to list prompt templates", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return templates, nil
+}
+
+// GetActive returns the active version of a template
+func (r *PromptRepository) GetActive(ctx context.Context, name string) (*models.PromptTemplate, error) {
+	return r.first(ctx, "name = ? AND is_active = ?", name, true)
+}
+
+// GetByID retrieves a template version by ID
+func (r *PromptRepository) GetByID(ctx context.Context, id string) (*models.PromptTemplate, error) {
+	return r.first(ctx, "id = ?", id)
+}
+
+// GetVersion retrieves a specific version of a template
+func (r *PromptRepository) GetVersion(ctx context.Context, name string, version int) (*models.PromptTemplate, error) {
+	return r.first(ctx, "name = ? AND version = ?", name, version)
+}
+
+func (r *PromptRepository) first(ctx context.Context, query string, args ...interface{}) (*models.PromptTemplate, error) {
+	var tpl models.PromptTemplate
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where(query, args...).First(&tpl).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get prompt template", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return &tpl, nil
+}
@@ -0,0 +1,268 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// PurgeResult counts the rows removed by a retention purge
+type PurgeResult struct {
+	Messages     int64 `json:"messages"`
+	Sessions     int64 `json:"sessions"`
+	CacheEntries int64 `json:"cache_entries"`
+}
+
+// RetentionRepository stores retention policies and applies them
+type RetentionRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewRetentionRepository creates a new retention repository
+func NewRetentionRepository(db *gorm.DB, logger logger.Logger) *RetentionRepository {
+	return &RetentionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ListPolicies returns the organization's retention policies, the
+// organization-wide policy first
+func (r *RetentionRepository) ListPolicies(ctx context.Context) ([]models.RetentionPolicy, error) {
+	var policies []models.RetentionPolicy
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Order("assistant_id").Find(&policies).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to list retention policies", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return policies, nil
+}
+
+// SavePolicy creates the policy for its scope, or replaces the existing one
+func (r *RetentionRepository) SavePolicy(ctx context.Context, policy *models.RetentionPolicy) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			var existing models.RetentionPolicy
+			err := tx.Where("assistant_id = ?", policy.AssistantID).First(&existing).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := tx.Create(policy).Error; err != nil {
+					return err
+				}
+				return appendAudit(ctx, tx, retentionAudit(policy, nil))
+			}
+			if err != nil {
+				return err
+			}
+
+			before := existing
+			existing.MessageDays = policy.MessageDays
+			existing.AnonymizeUserDays = policy.AnonymizeUserDays
+			if err := tx.Model(&existing).Select("message_days", "anonymize_user_days").Updates(&existing).Error; err != nil {
+				return err
+			}
+			*policy = existing
+			return appendAudit(ctx, tx, retentionAudit(policy, &before))
+		})
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrConflict
+		}
+		r.logger.Error("Failed to save retention policy", logger.F("error", err.Error()))
+		return err
+	}
+	r.logger.Info("Retention policy saved",
+		logger.F("policy_id", policy.ID),
+		logger.F("assistant_id", policy.AssistantID),
+	)
+	return nil
+}
+
+// DeletePolicy removes a retention policy
+func (r *RetentionRepository) DeletePolicy(ctx context.Context, id string) error {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			result := tx.Where("id = ?", id).Delete(&models.RetentionPolicy{})
+			rows = result.RowsAffected
+			if result.Error != nil || rows == 0 {
+				return result.Error
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionRetentionDelete,
+				TargetType: "retention_policy",
+				TargetID:   id,
+			})
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to delete retention policy", logger.F("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	r.logger.Info("Retention policy deleted", logger.F("policy_id", id))
+	return nil
+}
+
+// retentionAudit describes a saved policy, with the previous settings if it
+// replaced one
+func retentionAudit(policy, before *models.RetentionPolicy) *models.AuditEvent {
+	fields := func(p *models.RetentionPolicy) map[string]interface{} {
+		if p == nil {
+			return nil
+		}
+		return map[string]interface{}{
+			"message_days":        p.MessageDays,
+			"anonymize_user_days": p.AnonymizeUserDays,
+		}
+	}
+	return &models.AuditEvent{
+		Action:     audit.ActionRetentionSave,
+		TargetType: "retention_policy",
+		TargetID:   policy.ID,
+		Diff:       diffValues(fields(before), fields(policy)),
+		Metadata:   models.AuditMetadata{"assistant_id": policy.AssistantID},
+	}
+}
+
+// governedBy selects the rows a policy governs by their assistant column. An
+// assistant policy covers that assistant's rows; the organization-wide policy
+// covers every row whose assistant has no policy of its own.
+func governedBy(column string) string {
+	return `(CASE WHEN @assistant = '' THEN
+		` + column + ` IS NULL OR ` + column + ` NOT IN (
+			SELECT assistant_id FROM retention_policies
+			WHERE organization_id = @org AND assistant_id <> '')
+	ELSE ` + column + ` = @assistant END)`
+}
+
+// PurgeMessages permanently deletes messages created before cutoff in the
+// sessions the policy governs, batchSize rows at a time, then removes the
+// sessions left empty. Semantic cache entries of the governed assistants
+// hold copies of answers, so those created before cutoff go too.
+func (r *RetentionRepository) PurgeMessages(ctx context.Context, policy models.RetentionPolicy, cutoff time.Time, batchSize int) (PurgeResult, error) {
+	var result PurgeResult
+
+	orgID, _ := auth.OrganizationID(ctx)
+	params := map[string]interface{}{
+		"org":       orgID,
+		"assistant": policy.AssistantID,
+		"cutoff":    cutoff,
+		"limit":     batchSize,
+	}
+
+	messages := `DELETE FROM chat_messages WHERE id IN (
+		SELECT m.id FROM chat_messages m
+		JOIN chat_sessions s ON s.id = m.session_id
+		WHERE m.organization_id = @org AND s.organization_id = @org
+			AND m.created_at < @cutoff AND ` + governedBy("s.assistant_id") + `
+		LIMIT @limit)`
+	sessions := `DELETE FROM chat_sessions WHERE id IN (
+		SELECT s.id FROM chat_sessions s
+		WHERE s.organization_id = @org AND s.updated_at < @cutoff AND ` + governedBy("s.assistant_id") + `
+			AND NOT EXISTS (SELECT 1 FROM chat_messages m WHERE m.session_id = s.id)
+		LIMIT @limit)`
+	cacheEntries := `DELETE FROM semantic_cache_entries WHERE id IN (
+		SELECT e.id FROM semantic_cache_entries e
+		WHERE e.organization_id = @org AND e.created_at < @cutoff AND ` + governedBy("e.assistant_id") + `
+		LIMIT @limit)`
+
+	for _, step := range []struct {
+		sql   string
+		count *int64
+	}{{messages, &result.Messages}, {sessions, &result.Sessions}, {cacheEntries, &result.CacheEntries}} {
+		n, err := r.deleteInBatches(ctx, step.sql, params, batchSize)
+		*step.count += n
+		if err != nil {
+			r.logger.Error("Failed to purge messages",
+				logger.F("policy_id", policy.ID),
+				logger.F("error", err.Error()),
+			)
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// deleteInBatches runs a batched delete until a batch comes back short, so no
+// single statement holds locks on a large part of the table
+func (r *RetentionRepository) deleteInBatches(ctx context.Context, sql string, params map[string]interface{}, batchSize int) (int64, error) {
+	var total int64
+	for {
+		var rows int64
+		err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+			result := tx.Exec(sql, params)
+			rows = result.RowsAffected
+			return result.Error
+		})
+		total += rows
+		if err != nil {
+			return total, err
+		}
+		if rows < int64(batchSize) {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
+
+// AnonymizeUsers scrubs the identifying fields of users deleted before cutoff
+// and removes their API keys, batchSize users at a time. It returns the IDs of
+// the users it anonymized.
+func (r *RetentionRepository) AnonymizeUsers(ctx context.Context, cutoff time.Time, batchSize int) ([]string, error) {
+	orgID, _ := auth.OrganizationID(ctx)
+	params := map[string]interface{}{
+		"org":    orgID,
+		"cutoff": cutoff,
+		"limit":  batchSize,
+		"now":    time.Now(),
+	}
+
+	var anonymized []string
+	for {
+		var ids []string
+		err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+			return tx.Transaction(func(tx *gorm.DB) error {
+				err := tx.Raw(`UPDATE users
+					SET username = 'deleted-' || id, email = id || '@deleted.invalid',
+						display_name = '', anonymized_at = @now
+					WHERE id IN (
+						SELECT id FROM users
+						WHERE organization_id = @org AND deleted_at IS NOT NULL
+							AND deleted_at < @cutoff AND anonymized_at IS NULL
+						LIMIT @limit)
+					RETURNING id`, params).Scan(&ids).Error
+				if err != nil || len(ids) == 0 {
+					return err
+				}
+				return tx.Exec("DELETE FROM api_keys WHERE organization_id = ? AND user_id IN ?", orgID, ids).Error
+			})
+		})
+		if err != nil {
+			r.logger.Error("Failed to anonymize users", logger.F("error", err.Error()))
+			return anonymized, err
+		}
+		anonymized = append(anonymized, ids...)
+		if len(ids) < batchSize {
+			return anonymized, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return anonymized, err
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// recordRaw records the raw statements of a dry-run database, which
+// newDryRunDB leaves out because audited writes add their own
+func recordRaw(t *testing.T, db *gorm.DB) func() []statement {
+	t.Helper()
+
+	var (
+		mu         sync.Mutex
+		statements []statement
+	)
+	record := func(db *gorm.DB) {
+		// Savepoints only nest the transactions of the code under test
+		if strings.Contains(db.Statement.SQL.String(), "SAVEPOINT ") {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		statements = append(statements, statement{
+			SQL:  db.Statement.SQL.String(),
+			Vars: append([]interface{}(nil), db.Statement.Vars...),
+		})
+	}
+	require.NoError(t, db.Callback().Raw().After("gorm:raw").Register("test:record_raw", record))
+	require.NoError(t, db.Callback().Row().After("gorm:row").Register("test:record_raw", record))
+
+	return func() []statement {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]statement(nil), statements...)
+	}
+}
+
+func TestPurgeMessagesScopesEveryDelete(t *testing.T) {
+	db, _ := newDryRunDB(t)
+	statements := recordRaw(t, db)
+	repo := NewRetentionRepository(db, logger.NewLogrusLogger("error", "text"))
+
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Dry runs delete no rows, so each step stops after its first batch
+	result, err := repo.PurgeMessages(orgContext("org-a"), models.RetentionPolicy{ID: "policy-1", AssistantID: "assistant-1"}, cutoff, 500)
+	require.NoError(t, err)
+	assert.Equal(t, PurgeResult{}, result)
+
+	built := statements()
+	require.Len(t, built, 3)
+	for i, table := range []string{"chat_messages", "chat_sessions", "semantic_cache_entries"} {
+		stmt := built[i]
+		assert.True(t, strings.HasPrefix(stmt.SQL, "DELETE FROM "+table+" WHERE id IN ("), stmt.SQL)
+		assert.Contains(t, stmt.SQL, ".organization_id = $", table)
+		assert.Contains(t, stmt.SQL, "LIMIT $", table)
+		assert.Contains(t, stmt.Vars, "org-a", table)
+		assert.Contains(t, stmt.Vars, "assistant-1", table)
+		assert.Contains(t, stmt.Vars, cutoff, table)
+		assert.Contains(t, stmt.Vars, 500, table)
+	}
+	assert.Contains(t, built[0].SQL, "m.created_at < $")
+	assert.Contains(t, built[0].SQL, "s.organization_id = $", "sessions are joined within the organization")
+	assert.Contains(t, built[1].SQL, "s.updated_at < $")
+	assert.Contains(t, built[1].SQL, "NOT EXISTS (SELECT 1 FROM chat_messages m WHERE m.session_id = s.id)",
+		"only sessions left empty are removed")
+	assert.Contains(t, built[2].SQL, "e.created_at < $")
+}
+
+func TestPurgeMessagesOrganizationPolicy(t *testing.T) {
+	db, _ := newDryRunDB(t)
+	statements := recordRaw(t, db)
+	repo := NewRetentionRepository(db, logger.NewLogrusLogger("error", "text"))
+
+	_, err := repo.PurgeMessages(orgContext("org-a"), models.RetentionPolicy{ID: "policy-1"}, time.Now(), 100)
+	require.NoError(t, err)
+
+	// The organization-wide policy leaves assistants with a policy of their
+	// own to that policy
+	built := statements()
+	require.NotEmpty(t, built)
+	assert.Contains(t, built[0].SQL, "SELECT assistant_id FROM retention_policies")
+	assert.Contains(t, built[0].SQL, "WHERE organization_id = $")
+	assert.Contains(t, built[0].Vars, "")
+}
+
+func TestPurgeRequiresOrganization(t *testing.T) {
+	db, _ := newDryRunDB(t)
+	statements := recordRaw(t, db)
+	repo := NewRetentionRepository(db, logger.NewLogrusLogger("error", "text"))
+
+	_, err := repo.PurgeMessages(orgContext(""), models.RetentionPolicy{ID: "policy-1"}, time.Now(), 100)
+	assert.ErrorIs(t, err, ErrNoTenant)
+	_, err = repo.AnonymizeUsers(orgContext(""), time.Now(), 100)
+	assert.ErrorIs(t, err, ErrNoTenant)
+
+	assert.Empty(t, statements(), "nothing may be deleted without a tenant")
+}
+
+func TestAnonymizeUsersScopesUpdate(t *testing.T) {
+	db, _ := newDryRunDB(t)
+	statements := recordRaw(t, db)
+	repo := NewRetentionRepository(db, logger.NewLogrusLogger("error", "text"))
+
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Dry runs cannot scan the IDs the update returns
+	_, err := repo.AnonymizeUsers(orgContext("org-a"), cutoff, 50)
+	require.ErrorIs(t, err, gorm.ErrDryRunModeUnsupported)
+
+	built := statements()
+	require.Len(t, built, 1)
+	sql := built[0].SQL
+	assert.Contains(t, sql, "UPDATE users")
+	assert.Contains(t, sql, "WHERE organization_id = $")
+	assert.Contains(t, sql, "deleted_at IS NOT NULL")
+	assert.Contains(t, sql, "deleted_at < $")
+	assert.Contains(t, sql, "anonymized_at IS NULL")
+	assert.Contains(t, sql, "LIMIT $")
+	assert.Contains(t, built[0].Vars, "org-a")
+	assert.Contains(t, built[0].Vars, cutoff)
+	assert.Contains(t, built[0].Vars, 50)
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+)
+
+const (
+	tenantPluginName = "chat-agent:tenant"
+	tenantColumn     = "organization_id"
+	tenantField      = "OrganizationID"
+
+	// rlsSetting is the Postgres run-time parameter the row-level security
+	// policies compare organization_id against
+	rlsSetting = "app.organization_id"
+)
+
+var (
+	// ErrNoTenant is returned when a tenant-scoped query runs without an organization in its context
+	ErrNoTenant = errors.New("database: no organization in context")
+	// ErrTenantMismatch is returned when a record is written for a different organization than the context
+	ErrTenantMismatch = errors.New("database: record belongs to a different organization")
+)
+
+// TenantPlugin enforces organization scoping on writes and carries the
+// row-level security setting for tenant-scoped repositories
+type TenantPlugin struct {
+	RowLevelSecurity bool
+}
+
+// Name implements gorm.Plugin
+func (p *TenantPlugin) Name() string {
+	return tenantPluginName
+}
+
+// Initialize implements gorm.Plugin
+func (p *TenantPlugin) Initialize(db *gorm.DB) error {
+	return db.Callback().Create().Before("gorm:create").Register(tenantPluginName+":assign", assignTenant)
+}
+
+// assignTenant fills OrganizationID from the context on tenant-scoped models
+// and rejects records that name a different organization
+func assignTenant(db *gorm.DB) {
+	if db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(tenantField)
+	if field == nil {
+		return
+	}
+
+	orgID, hasTenant := auth.OrganizationID(db.Statement.Context)
+
+	assign := func(rv reflect.Value) {
+		value, isZero := field.ValueOf(db.Statement.Context, rv)
+		switch {
+		case isZero && !hasTenant:
+			db.AddError(ErrNoTenant)
+		case isZero:
+			if err := field.Set(db.Statement.Context, rv, orgID); err != nil {
+				db.AddError(err)
+			}
+		case hasTenant && value != orgID:
+			db.AddError(ErrTenantMismatch)
+		}
+	}
+
+	switch db.Statement.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
+			assign(reflect.Indirect(db.Statement.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		assign(db.Statement.ReflectValue)
+	}
+}
+
+// TenantScope restricts a query to rows owned by orgID
+func TenantScope(orgID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: tenantColumn},
+			Value:  orgID,
+		})
+	}
+}
+
+// withTenant runs fn against a handle scoped to the organization in ctx. When
+// row-level security is enabled the work runs in a transaction with the
+// organization set so the Postgres policies apply as well.
+func withTenant(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	orgID, ok := auth.OrganizationID(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	tx := db.WithContext(ctx)
+	if !rowLevelSecurity(db) {
+		return fn(tx.Scopes(TenantScope(orgID)).Session(&gorm.Session{}))
+	}
+
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config(?, ?, true)", rlsSetting, orgID).Error; err != nil {
+			return fmt.Errorf("failed to set tenant for row-level security: %w", err)
+		}
+		return fn(tx.Scopes(TenantScope(orgID)).Session(&gorm.Session{}))
+	})
+}
+
+func rowLevelSecurity(db *gorm.DB) bool {
+	plugin, ok := db.Config.Plugins[tenantPluginName].(*TenantPlugin)
+	return ok && plugin.RowLevelSecurity
+}
+
+// enableRowLevelSecurity installs an isolation policy on every tenant-scoped table
+func (d *Database) enableRowLevelSecurity(tables ...interface{}) error {
+	for _, model := range tables {
+		stmt := &gorm.Statement{DB: d.DB}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		table := stmt.Schema.Table
+
+		statements := []string{
+			fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", table),
+			fmt.Sprintf("ALTER TABLE %s FORCE ROW LEVEL SECURITY", table),
+			fmt.Sprintf("DROP POLICY IF EXISTS tenant_isolation ON %s", table),
+			fmt.Sprintf("CREATE POLICY tenant_isolation ON %[1]s USING (%[2]s = current_setting('%[3]s', true)) WITH CHECK (%[2]s = current_setting('%[3]s', true))",
+				table, tenantColumn, rlsSetting),
+		}
+		for _, sql := range statements {
+			if err := d.DB.Exec(sql).Error; err != nil {
+				return fmt.Errorf("failed to enable row-level security on %s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
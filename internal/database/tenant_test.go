@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// statement is a query built by a dry-run database
+type statement struct {
+	SQL  string
+	Vars []interface{}
+}
+
+// newDryRunDB returns a database that builds statements without running
+// them, together with the statements it has built so far
+func newDryRunDB(t *testing.T) (*gorm.DB, func() []statement) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: &dryRunPool{}}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(&TenantPlugin{}))
+
+	var (
+		mu         sync.Mutex
+		statements []statement
+	)
+	record := func(db *gorm.DB) {
+		mu.Lock()
+		defer mu.Unlock()
+		statements = append(statements, statement{
+			SQL:  db.Statement.SQL.String(),
+			Vars: append([]interface{}(nil), db.Statement.Vars...),
+		})
+	}
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:record", record))
+	require.NoError(t, db.Callback().Create().After("gorm:create").Register("test:record", record))
+	require.NoError(t, db.Callback().Update().After("gorm:update").Register("test:record", record))
+	require.NoError(t, db.Callback().Delete().After("gorm:delete").Register("test:record", record))
+
+	return db, func() []statement {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]statement(nil), statements...)
+	}
+}
+
+// dryRunPool stands in for the connection of a dry-run database. Statements
+// are never sent to it; transactions begin and end without a server.
+type dryRunPool struct{}
+
+var errDryRun = errors.New("dry run: no database")
+
+func (p *dryRunPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errDryRun
+}
+
+func (p *dryRunPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errDryRun
+}
+
+func (p *dryRunPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errDryRun
+}
+
+func (p *dryRunPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (p *dryRunPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return p, nil
+}
+
+func (p *dryRunPool) Commit() error   { return nil }
+func (p *dryRunPool) Rollback() error { return nil }
+
+func orgContext(orgID string) context.Context {
+	return auth.WithIdentity(context.Background(), auth.Identity{UserID: "user-1", OrganizationID: orgID, Role: auth.RoleAdmin})
+}
+
+func TestWithTenantRequiresOrganization(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	repo := NewUserRepository(db, logger.NewLogrusLogger("error", "text"))
+
+	_, err := repo.GetUserByID(context.Background(), "user-1")
+	assert.ErrorIs(t, err, ErrNoTenant)
+
+	_, _, err = repo.ListUsers(context.Background(), 0, 10)
+	assert.ErrorIs(t, err, ErrNoTenant)
+
+	err = repo.CreateUser(context.Background(), &models.User{ID: "user-1", Username: "alice"})
+	assert.ErrorIs(t, err, ErrNoTenant)
+
+	err = repo.UpdateUser(context.Background(), &models.User{ID: "user-1", Username: "alice"})
+	assert.ErrorIs(t, err, ErrNoTenant)
+
+	// An identity without an organization is no tenant either
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: "user-1"})
+	_, err = repo.GetUserByID(ctx, "user-1")
+	assert.ErrorIs(t, err, ErrNoTenant)
+
+	assert.Empty(t, statements(), "no statement may be built without a tenant")
+}
+
+func TestAssignTenantOnCreate(t *testing.T) {
+	db, _ := newDryRunDB(t)
+
+	// Creates outside withTenant are checked by the plugin as well
+	err := db.WithContext(context.Background()).Create(&models.User{ID: "user-1", Username: "alice"}).Error
+	assert.ErrorIs(t, err, ErrNoTenant)
+
+	user := &models.User{ID: "user-1", Username: "alice"}
+	require.NoError(t, db.WithContext(orgContext("org-a")).Create(user).Error)
+	assert.Equal(t, "org-a", user.OrganizationID)
+
+	err = db.WithContext(orgContext("org-a")).Create(&models.User{ID: "user-2", OrganizationID: "org-b"}).Error
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+
+	users := []models.User{{ID: "user-3"}, {ID: "user-4", OrganizationID: "org-b"}}
+	err = db.WithContext(orgContext("org-a")).Create(&users).Error
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+}
+
+func TestTenantScopeRestrictsReads(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	repo := NewUserRepository(db, logger.NewLogrusLogger("error", "text"))
+
+	_, err := repo.GetUserByID(orgContext("org-a"), "user-of-org-b")
+	require.NoError(t, err)
+	_, _, err = repo.ListUsers(orgContext("org-a"), 0, 10)
+	require.NoError(t, err)
+
+	built := statements()
+	require.Len(t, built, 3)
+	for _, stmt := range built {
+		assert.Contains(t, stmt.SQL, `"users"."organization_id" = `)
+		assert.Contains(t, stmt.Vars, "org-a")
+		assert.NotContains(t, stmt.Vars, "org-b")
+	}
+}
+
+func TestTenantScopeRestrictsUpdates(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	repo := NewUserRepository(db, logger.NewLogrusLogger("error", "text"))
+
+	// A row of another organization is not matched, so nothing is updated
+	err := repo.UpdateUser(orgContext("org-a"), &models.User{ID: "user-of-org-b", OrganizationID: "org-b", Username: "mallory"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	built := statements()
+	require.Len(t, built, 1)
+	assert.Contains(t, built[0].SQL, "UPDATE")
+	assert.Contains(t, built[0].SQL, `"users"."organization_id" = `)
+	assert.Contains(t, built[0].Vars, "org-a")
+	assert.NotContains(t, built[0].Vars, "org-b")
+}
+
+func TestUserUniquenessIsPerOrganization(t *testing.T) {
+	s, err := schema.Parse(&models.User{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+
+	for _, name := range []string{"idx_users_org_username", "idx_users_org_email"} {
+		index := s.LookIndex(name)
+		require.NotNil(t, index, name)
+		assert.Equal(t, "UNIQUE", index.Class)
+		assert.Equal(t, "deleted_at IS NULL", index.Where)
+		require.Len(t, index.Fields, 2)
+		assert.Equal(t, "organization_id", index.Fields[0].DBName)
+	}
+	assert.False(t, s.LookUpField("Username").Unique)
+	assert.False(t, s.LookUpField("Email").Unique)
+}
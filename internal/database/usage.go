@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/usage"
+)
+
+// Dimensions usage can be broken down by
+const (
+	UsageByDay   = "day"
+	UsageByMonth = "month"
+	UsageByModel = "model"
+	UsageByUser  = "user"
+)
+
+// usageGroups maps each dimension to the expression it groups by
+var usageGroups = map[string]string{
+	UsageByDay:   "to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')",
+	UsageByMonth: "to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM')",
+	UsageByModel: "model",
+	UsageByUser:  "user_id",
+}
+
+// ValidUsageDimension reports whether usage can be broken down by by
+func ValidUsageDimension(by string) bool {
+	_, ok := usageGroups[by]
+	return ok
+}
+
+// UsageFilter narrows a usage report. Empty fields match everything.
+type UsageFilter struct {
+	UserID string
+	From   *time.Time
+	To     *time.Time
+}
+
+// UsageRepository stores the token usage of provider calls
+type UsageRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewUsageRepository creates a new usage repository
+func NewUsageRepository(db *gorm.DB, logger logger.Logger) *UsageRepository {
+	return &UsageRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// RecordUsage stores the usage of one provider call
+func (r *UsageRepository) RecordUsage(ctx context.Context, record *models.UsageRecord) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Create(record).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to record usage", logger.F("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// UsageTotals implements usage.Store. The key scope is only counted for
+// callers authenticated with an API key.
+func (r *UsageRepository) UsageTotals(ctx context.Context, identity auth.Identity, day, month time.Time) (usage.Totals, error) {
+	var row struct {
+		UserDaily     int64
+		UserMonthly   int64
+		KeyDaily      int64
+		KeyMonthly    int64
+		TenantDaily   int64
+		TenantMonthly int64
+	}
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		orgID, _ := auth.OrganizationID(ctx)
+		sql := `SELECT
+	COALESCE(SUM(total_tokens) FILTER (WHERE user_id = @user AND created_at >= @day), 0) AS user_daily,
+	COALESCE(SUM(total_tokens) FILTER (WHERE user_id = @user), 0) AS user_monthly,
+	COALESCE(SUM(total_tokens) FILTER (WHERE api_key_id = @key AND created_at >= @day), 0) AS key_daily,
+	COALESCE(SUM(total_tokens) FILTER (WHERE api_key_id = @key), 0) AS key_monthly,
+	COALESCE(SUM(total_tokens) FILTER (WHERE created_at >= @day), 0) AS tenant_daily,
+	COALESCE(SUM(total_tokens), 0) AS tenant_monthly
+FROM usage_records
+WHERE organization_id = @org AND created_at >= @month`
+		return tx.Raw(sql, map[string]interface{}{
+			"org":   orgID,
+			"user":  identity.UserID,
+			"key":   identity.APIKeyID,
+			"day":   day,
+			"month": month,
+		}).Scan(&row).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to total usage", logger.F("error", err.Error()))
+		return nil, err
+	}
+
+	totals := usage.Totals{
+		usage.ScopeUser:   {Daily: row.UserDaily, Monthly: row.UserMonthly},
+		usage.ScopeTenant: {Daily: row.TenantDaily, Monthly: row.TenantMonthly},
+	}
+	if identity.APIKeyID != "" {
+		totals[usage.ScopeKey] = usage.Counts{Daily: row.KeyDaily, Monthly: row.KeyMonthly}
+	}
+	return totals, nil
+}
+
+// SummarizeUsage adds up the usage matching filter, and breaks it down by day,
+// month, model or user unless by is empty
+func (r *UsageRepository) SummarizeUsage(ctx context.Context, filter UsageFilter, by string) (*models.UsageSummary, []models.UsageSummary, error) {
+	group, ok := usageGroups[by]
+	if by != "" && !ok {
+		return nil, nil, fmt.Errorf("unknown usage dimension %q", by)
+	}
+
+	var (
+		total     models.UsageSummary
+		breakdown []models.UsageSummary
+	)
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		query := tx.Model(&models.UsageRecord{})
+		if filter.UserID != "" {
+			query = query.Where("user_id = ?", filter.UserID)
+		}
+		if filter.From != nil {
+			query = query.Where("created_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			query = query.Where("created_at < ?", *filter.To)
+		}
+
+		sums := []string{
+			"COUNT(*) AS requests",
+			"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens",
+			"COALESCE(SUM(completion_tokens), 0) AS completion_tokens",
+			"COALESCE(SUM(total_tokens), 0) AS total_tokens",
+			"COALESCE(SUM(cost), 0) AS cost",
+		}
+		if err := query.Session(&gorm.Session{}).Select(sums).Scan(&total).Error; err != nil {
+			return err
+		}
+		if by == "" {
+			return nil
+		}
+		return query.Select(append([]string{group + " AS \"group\""}, sums...)).
+			Group(group).Order("\"group\"").Scan(&breakdown).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to summarize usage", logger.F("error", err.Error()))
+		return nil, nil, err
+	}
+	return &total, breakdown, nil
+}
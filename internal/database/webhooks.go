@@ -0,0 +1,300 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// WebhookRepository stores webhook endpoints and their delivery log. It
+// implements webhooks.Store.
+type WebhookRepository struct {
+	db     *gorm.DB
+	logger logger.Logger
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *gorm.DB, logger logger.Logger) *WebhookRepository {
+	return &WebhookRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateEndpoint registers a webhook endpoint
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(endpoint).Error; err != nil {
+				return err
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionWebhookCreate,
+				TargetType: "webhook",
+				TargetID:   endpoint.ID,
+				Diff:       diffValues(nil, webhookFields(endpoint)),
+			})
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to create webhook endpoint", logger.F("error", err.Error()))
+		return err
+	}
+	r.logger.Info("Webhook endpoint created", logger.F("endpoint_id", endpoint.ID))
+	return nil
+}
+
+// GetEndpoint retrieves a webhook endpoint by ID
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("id = ?", id).First(&endpoint).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get webhook endpoint", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// ListEndpoints returns every webhook endpoint, oldest first
+func (r *WebhookRepository) ListEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Order("created_at ASC").Find(&endpoints).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to list webhook endpoints", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// SubscribedEndpoints implements webhooks.Store
+func (r *WebhookRepository) SubscribedEndpoints(ctx context.Context, event string) ([]models.WebhookEndpoint, error) {
+	filter, err := json.Marshal([]string{event})
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []models.WebhookEndpoint
+	err = withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("active = ? AND events @> ?", true, string(filter)).Find(&endpoints).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to find webhook endpoints", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// UpdateEndpoint persists the editable fields of an existing endpoint
+func (r *WebhookRepository) UpdateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			var before models.WebhookEndpoint
+			if err := tx.Where("id = ?", endpoint.ID).Limit(1).Find(&before).Error; err != nil {
+				return err
+			}
+			result := tx.Model(endpoint).
+				Select("url", "description", "events", "active").
+				Updates(endpoint)
+			rows = result.RowsAffected
+			if result.Error != nil || rows == 0 {
+				return result.Error
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionWebhookUpdate,
+				TargetType: "webhook",
+				TargetID:   endpoint.ID,
+				Diff:       diffValues(webhookFields(&before), webhookFields(endpoint)),
+			})
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to update webhook endpoint", logger.F("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	r.logger.Info("Webhook endpoint updated", logger.F("endpoint_id", endpoint.ID))
+	return nil
+}
+
+// DeleteEndpoint soft-deletes an endpoint. Its pending deliveries fail on
+// their next attempt; the delivery log is kept.
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, id string) error {
+	var rows int64
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			result := tx.Where("id = ?", id).Delete(&models.WebhookEndpoint{})
+			rows = result.RowsAffected
+			if result.Error != nil || rows == 0 {
+				return result.Error
+			}
+			return appendAudit(ctx, tx, &models.AuditEvent{
+				Action:     audit.ActionWebhookDelete,
+				TargetType: "webhook",
+				TargetID:   id,
+			})
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to delete webhook endpoint", logger.F("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	r.logger.Info("Webhook endpoint deleted", logger.F("endpoint_id", id))
+	return nil
+}
+
+// ListDeliveries returns a page of an endpoint's delivery log, newest first,
+// with the total number of deliveries
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpointID string, offset, limit int) ([]models.WebhookDelivery, int64, error) {
+	var (
+		deliveries []models.WebhookDelivery
+		total      int64
+	)
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		q := tx.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+		if err := q.Count(&total).Error; err != nil {
+			return err
+		}
+		return q.Order("created_at DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	})
+	if err != nil {
+		r.logger.Error("Failed to list webhook deliveries", logger.F("error", err.Error()))
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// GetDelivery retrieves a delivery by ID
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Where("id = ?", id).First(&delivery).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get webhook delivery", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// CreateDeliveries implements webhooks.Store. A delivery that redelivers an
+// earlier one is audited.
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	err := withTenant(ctx, r.db, func(tx *gorm.DB) error {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&deliveries).Error; err != nil {
+				return err
+			}
+			for _, d := range deliveries {
+				if d.RedeliveryOf == nil {
+					continue
+				}
+				err := appendAudit(ctx, tx, &models.AuditEvent{
+					Action:     audit.ActionWebhookRedeliver,
+					TargetType: "webhook_delivery",
+					TargetID:   *d.RedeliveryOf,
+					Metadata:   models.AuditMetadata{"delivery_id": d.ID, "endpoint_id": d.EndpointID},
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		r.logger.Error("Failed to create webhook deliveries", logger.F("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// ClaimDeliveries implements webhooks.Store. It runs across organizations;
+// deliveries whose sender died are claimed again once their lease expires.
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	now := time.Now().UTC()
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE webhook_deliveries SET status = ?, attempts = attempts + 1, next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status IN (?, ?) AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.DeliverySending, now.Add(lease), now,
+		models.DeliveryPending, models.DeliverySending, now,
+		limit,
+	).Scan(&deliveries).Error
+	if err != nil {
+		r.logger.Error("Failed to claim webhook deliveries", logger.F("error", err.Error()))
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt implements webhooks.Store
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	err := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", delivery.ID, models.DeliverySending, delivery.Attempts).
+		Updates(map[string]interface{}{
+			"status":          delivery.Status,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"response_status": delivery.ResponseStatus,
+			"response_body":   delivery.ResponseBody,
+			"error":           delivery.Error,
+			"delivered_at":    delivery.DeliveredAt,
+			"updated_at":      time.Now().UTC(),
+		}).Error
+	if err != nil {
+		r.logger.Error("Failed to record webhook delivery", logger.F("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// PruneDeliveries implements webhooks.Store
+func (r *WebhookRepository) PruneDeliveries(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status IN ? AND created_at < ?", []string{models.DeliverySucceeded, models.DeliveryFailed}, cutoff).
+		Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		r.logger.Error("Failed to prune webhook deliveries", logger.F("error", result.Error.Error()))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// webhookFields returns the audited fields of an endpoint; the secret is
+// never recorded
+func webhookFields(e *models.WebhookEndpoint) map[string]interface{} {
+	return map[string]interface{}{
+		"url":         e.URL,
+		"description": e.Description,
+		"events":      []string(e.Events),
+		"active":      e.Active,
+	}
+}
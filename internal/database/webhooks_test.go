@@ -0,0 +1,29 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+func TestRecordAttemptMatchesClaimedAttempt(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	repo := NewWebhookRepository(db, logger.NewLogrusLogger("error", "text"))
+
+	delivery := &models.WebhookDelivery{ID: "delivery-1", Status: models.DeliveryPending, Attempts: 2}
+	require.NoError(t, repo.RecordAttempt(context.Background(), delivery))
+
+	built := statements()
+	require.Len(t, built, 1)
+	// An outcome that arrives after the delivery was claimed again must not
+	// overwrite the newer attempt
+	assert.Contains(t, built[0].SQL, `WHERE id = $8 AND status = $9 AND attempts = $10`)
+	assert.NotContains(t, built[0].SQL, `"attempts"=`)
+	assert.Contains(t, built[0].Vars, models.DeliverySending)
+	assert.Contains(t, built[0].Vars, 2)
+}
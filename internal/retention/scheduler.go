@@ -0,0 +1,167 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+const day = 24 * time.Hour
+
+// Organizations lists the organizations whose policies are applied
+type Organizations interface {
+	ListOrganizations(ctx context.Context) ([]models.Organization, error)
+}
+
+// Store holds the retention policies of the organization in the context and
+// applies them. It is implemented by database.RetentionRepository.
+type Store interface {
+	ListPolicies(ctx context.Context) ([]models.RetentionPolicy, error)
+	PurgeMessages(ctx context.Context, policy models.RetentionPolicy, cutoff time.Time, batchSize int) (database.PurgeResult, error)
+	AnonymizeUsers(ctx context.Context, cutoff time.Time, batchSize int) ([]string, error)
+}
+
+// AuditRecorder appends events to the audit log
+type AuditRecorder interface {
+	Record(ctx context.Context, event *models.AuditEvent) error
+}
+
+// Scheduler periodically applies every organization's retention policies
+type Scheduler struct {
+	orgRepo       Organizations
+	retentionRepo Store
+	auditRepo     AuditRecorder
+	interval      time.Duration
+	batchSize     int
+	logger        logger.Logger
+}
+
+// NewScheduler creates a scheduler that runs every interval and deletes at
+// most batchSize rows per statement
+func NewScheduler(
+	orgRepo Organizations,
+	retentionRepo Store,
+	auditRepo AuditRecorder,
+	interval time.Duration,
+	batchSize int,
+	logger logger.Logger,
+) *Scheduler {
+	return &Scheduler{
+		orgRepo:       orgRepo,
+		retentionRepo: retentionRepo,
+		auditRepo:     auditRepo,
+		interval:      interval,
+		batchSize:     batchSize,
+		logger:        logger,
+	}
+}
+
+// Start runs the scheduler until ctx is cancelled. The first run happens
+// immediately.
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("Retention run failed", logger.F("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce applies the retention policies of every organization. A failure in
+// one organization is logged and does not stop the others; the last error is
+// returned.
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	orgs, err := s.orgRepo.ListOrganizations(ctx)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, org := range orgs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.purgeOrganization(auth.ForOrganization(ctx, org.ID), time.Now()); err != nil {
+			s.logger.Error("Retention failed for organization",
+				logger.F("organization_id", org.ID),
+				logger.F("error", err.Error()),
+			)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// purgeOrganization applies the policies of the organization in ctx
+func (s *Scheduler) purgeOrganization(ctx context.Context, now time.Time) error {
+	policies, err := s.retentionRepo.ListPolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		if policy.MessageDays > 0 {
+			cutoff := now.Add(-time.Duration(policy.MessageDays) * day)
+			result, err := s.retentionRepo.PurgeMessages(ctx, policy, cutoff, s.batchSize)
+			if result.Messages > 0 || result.Sessions > 0 || result.CacheEntries > 0 {
+				s.audit(ctx, audit.ActionPurgeMessages, policy, models.AuditMetadata{
+					"cutoff":        cutoff,
+					"messages":      result.Messages,
+					"sessions":      result.Sessions,
+					"cache_entries": result.CacheEntries,
+				})
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if policy.AnonymizeUserDays > 0 && policy.AssistantID == "" {
+			cutoff := now.Add(-time.Duration(policy.AnonymizeUserDays) * day)
+			ids, err := s.retentionRepo.AnonymizeUsers(ctx, cutoff, s.batchSize)
+			if len(ids) > 0 {
+				s.audit(ctx, audit.ActionAnonymizeUsers, policy, models.AuditMetadata{
+					"cutoff":   cutoff,
+					"users":    len(ids),
+					"user_ids": ids,
+				})
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// audit records a purge. Failing to record it is logged but does not undo the
+// purge, which has already been committed.
+func (s *Scheduler) audit(ctx context.Context, action string, policy models.RetentionPolicy, metadata models.AuditMetadata) {
+	event := &models.AuditEvent{
+		Action:     action,
+		TargetType: "retention_policy",
+		TargetID:   policy.ID,
+		Metadata:   metadata,
+	}
+	if err := s.auditRepo.Record(ctx, event); err != nil {
+		return
+	}
+	s.logger.Info("Retention purge completed",
+		logger.F("action", action),
+		logger.F("policy_id", policy.ID),
+		logger.F("organization_id", policy.OrganizationID),
+	)
+}
@@ -0,0 +1,144 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+type fakeOrganizations []models.Organization
+
+func (f fakeOrganizations) ListOrganizations(ctx context.Context) ([]models.Organization, error) {
+	return f, nil
+}
+
+type purge struct {
+	org       string
+	policy    string
+	cutoff    time.Time
+	batchSize int
+}
+
+// fakeStore holds policies per organization and records what it is asked to
+// purge
+type fakeStore struct {
+	mu         sync.Mutex
+	policies   map[string][]models.RetentionPolicy
+	removed    database.PurgeResult
+	anonymized []string
+	failFor    string
+	purges     []purge
+	anonymize  []purge
+}
+
+func (f *fakeStore) ListPolicies(ctx context.Context) ([]models.RetentionPolicy, error) {
+	orgID, _ := auth.OrganizationID(ctx)
+	if orgID == f.failFor {
+		return nil, errors.New("database is down")
+	}
+	return f.policies[orgID], nil
+}
+
+func (f *fakeStore) PurgeMessages(ctx context.Context, policy models.RetentionPolicy, cutoff time.Time, batchSize int) (database.PurgeResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	orgID, _ := auth.OrganizationID(ctx)
+	f.purges = append(f.purges, purge{org: orgID, policy: policy.ID, cutoff: cutoff, batchSize: batchSize})
+	return f.removed, nil
+}
+
+func (f *fakeStore) AnonymizeUsers(ctx context.Context, cutoff time.Time, batchSize int) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	orgID, _ := auth.OrganizationID(ctx)
+	f.anonymize = append(f.anonymize, purge{org: orgID, cutoff: cutoff, batchSize: batchSize})
+	return f.anonymized, nil
+}
+
+type fakeRecorder struct {
+	events []*models.AuditEvent
+	orgs   []string
+}
+
+func (f *fakeRecorder) Record(ctx context.Context, event *models.AuditEvent) error {
+	orgID, _ := auth.OrganizationID(ctx)
+	f.events = append(f.events, event)
+	f.orgs = append(f.orgs, orgID)
+	return nil
+}
+
+func TestPurgeOrganizationAppliesPolicies(t *testing.T) {
+	store := &fakeStore{
+		policies: map[string][]models.RetentionPolicy{"org-a": {
+			{ID: "org-wide", OrganizationID: "org-a", MessageDays: 30, AnonymizeUserDays: 7},
+			{ID: "assistant", OrganizationID: "org-a", AssistantID: "assistant-1", MessageDays: 1, AnonymizeUserDays: 7},
+			{ID: "keep", OrganizationID: "org-a"},
+		}},
+		removed:    database.PurgeResult{Messages: 3, Sessions: 1},
+		anonymized: []string{"user-1"},
+	}
+	recorder := &fakeRecorder{}
+	s := NewScheduler(fakeOrganizations{}, store, recorder, time.Hour, 250, logger.NewLogrusLogger("error", "text"))
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, s.purgeOrganization(auth.ForOrganization(context.Background(), "org-a"), now))
+
+	assert.Equal(t, []purge{
+		{org: "org-a", policy: "org-wide", cutoff: now.Add(-30 * day), batchSize: 250},
+		{org: "org-a", policy: "assistant", cutoff: now.Add(-day), batchSize: 250},
+	}, store.purges, "policies without a message period purge nothing")
+	assert.Equal(t, []purge{
+		{org: "org-a", cutoff: now.Add(-7 * day), batchSize: 250},
+	}, store.anonymize, "only the organization-wide policy anonymizes users")
+
+	require.Len(t, recorder.events, 3)
+	assert.Equal(t, audit.ActionPurgeMessages, recorder.events[0].Action)
+	assert.Equal(t, "org-wide", recorder.events[0].TargetID)
+	assert.Equal(t, int64(3), recorder.events[0].Metadata["messages"])
+	assert.Equal(t, audit.ActionAnonymizeUsers, recorder.events[1].Action)
+	assert.Equal(t, []string{"user-1"}, recorder.events[1].Metadata["user_ids"])
+	assert.Equal(t, "assistant", recorder.events[2].TargetID)
+	assert.Equal(t, []string{"org-a", "org-a", "org-a"}, recorder.orgs)
+}
+
+func TestPurgeOrganizationSkipsAuditWhenNothingRemoved(t *testing.T) {
+	store := &fakeStore{policies: map[string][]models.RetentionPolicy{"org-a": {
+		{ID: "org-wide", MessageDays: 30, AnonymizeUserDays: 7},
+	}}}
+	recorder := &fakeRecorder{}
+	s := NewScheduler(fakeOrganizations{}, store, recorder, time.Hour, 250, logger.NewLogrusLogger("error", "text"))
+
+	require.NoError(t, s.purgeOrganization(auth.ForOrganization(context.Background(), "org-a"), time.Now()))
+	assert.Len(t, store.purges, 1)
+	assert.Empty(t, recorder.events)
+}
+
+func TestRunOnceContinuesPastFailingOrganization(t *testing.T) {
+	store := &fakeStore{
+		policies: map[string][]models.RetentionPolicy{
+			"org-b": {{ID: "policy-b", MessageDays: 30}},
+			"org-c": {{ID: "policy-c", MessageDays: 30}},
+		},
+		failFor: "org-a",
+	}
+	orgs := fakeOrganizations{{ID: "org-a"}, {ID: "org-b"}, {ID: "org-c"}}
+	s := NewScheduler(orgs, store, &fakeRecorder{}, time.Hour, 250, logger.NewLogrusLogger("error", "text"))
+
+	err := s.RunOnce(context.Background())
+	assert.EqualError(t, err, "database is down")
+
+	require.Len(t, store.purges, 2)
+	assert.Equal(t, "org-b", store.purges[0].org)
+	assert.Equal(t, "org-c", store.purges[1].org, "each organization is purged in its own scope")
+}
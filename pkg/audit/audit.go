@@ -0,0 +1,60 @@
+package audit
+
+import "context"
+
+// Actions recorded in the audit log
+const (
+	ActionRequest = "http.request"
+	ActionCall    = "grpc.call"
+
+	ActionUserDelete     = "user.delete"
+	ActionUserErase      = "user.erase"
+	ActionUserExport     = "user.export"
+	ActionUserRoleChange = "user.role_change"
+
+	ActionAPIKeyCreate = "api_key.create"
+	ActionAPIKeyRevoke = "api_key.revoke"
+
+	ActionMessageDelete  = "message.delete"
+	ActionMessageRestore = "message.restore"
+	ActionMessageReveal  = "message.reveal"
+
+	ActionAssistantCreate = "assistant.create"
+	ActionAssistantUpdate = "assistant.update"
+	ActionAssistantDelete = "assistant.delete"
+
+	ActionKnowledgeBaseChange = "knowledge_base.change"
+
+	ActionWebhookCreate    = "webhook.create"
+	ActionWebhookUpdate    = "webhook.update"
+	ActionWebhookDelete    = "webhook.delete"
+	ActionWebhookRedeliver = "webhook.redeliver"
+
+	ActionPromptCreate  = "prompt.create_version"
+	ActionPromptPromote = "prompt.promote"
+
+	ActionRetentionSave   = "retention_policy.save"
+	ActionRetentionDelete = "retention_policy.delete"
+
+	ActionPurgeMessages  = "retention.purge_messages"
+	ActionAnonymizeUsers = "retention.anonymize_users"
+)
+
+// Request describes the HTTP request an audited action was taken in
+type Request struct {
+	IP        string
+	RequestID string
+}
+
+type requestKey struct{}
+
+// WithRequest returns a copy of ctx carrying details of the current request
+func WithRequest(ctx context.Context, req Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, req)
+}
+
+// RequestFrom returns the request details stored in ctx, if any
+func RequestFrom(ctx context.Context) Request {
+	req, _ := ctx.Value(requestKey{}).(Request)
+	return req
+}
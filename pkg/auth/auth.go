@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// KeyPrefix marks tokens issued by this service
+const KeyPrefix = "ca_"
+
+// ErrInvalidKey is returned when a token is unknown, revoked or expired
+var ErrInvalidKey = errors.New("auth: invalid api key")
+
+// Identity is the authenticated caller resolved from an API key
+type Identity struct {
+	UserID         string `json:"user_id"`
+	OrganizationID string `json:"organization_id"`
+	APIKeyID       string `json:"api_key_id,omitempty"`
+	Role           Role   `json:"role"`
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the given identity
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity stored in ctx, if any
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+// OrganizationID returns the tenant the context is scoped to
+func OrganizationID(ctx context.Context) (string, bool) {
+	id, ok := FromContext(ctx)
+	if !ok || id.OrganizationID == "" {
+		return "", false
+	}
+	return id.OrganizationID, true
+}
+
+// ForOrganization returns a context scoped to orgID without a user, for
+// background work that operates on behalf of a tenant
+func ForOrganization(ctx context.Context, orgID string) context.Context {
+	return WithIdentity(ctx, Identity{OrganizationID: orgID})
+}
+
+// GenerateKey creates a new random API key token and returns it together with
+// the hash that should be persisted
+func GenerateKey() (token string, hash string, err error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	token = KeyPrefix + hex.EncodeToString(b[:])
+	return token, HashKey(token), nil
+}
+
+// HashKey returns the hex encoded SHA-256 hash of a token
+func HashKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// DisplayPrefix returns the non-secret leading part of a token, used to help
+// users tell their keys apart
+func DisplayPrefix(token string) string {
+	if len(token) <= len(KeyPrefix)+8 {
+		return token
+	}
+	return token[:len(KeyPrefix)+8]
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+	_, ok = OrganizationID(context.Background())
+	assert.False(t, ok)
+
+	ctx := WithIdentity(context.Background(), Identity{UserID: "user-1", OrganizationID: "org-1", Role: RoleUser})
+	id, ok := FromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "user-1", id.UserID)
+	orgID, ok := OrganizationID(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "org-1", orgID)
+
+	// An identity without an organization does not scope a tenant
+	_, ok = OrganizationID(WithIdentity(context.Background(), Identity{UserID: "user-1"}))
+	assert.False(t, ok)
+
+	background := ForOrganization(context.Background(), "org-2")
+	orgID, _ = OrganizationID(background)
+	assert.Equal(t, "org-2", orgID)
+	id, _ = FromContext(background)
+	assert.Empty(t, id.UserID)
+	assert.False(t, id.Can(PermChat))
+}
+
+func TestGenerateKey(t *testing.T) {
+	token, hash, err := GenerateKey()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(token, KeyPrefix))
+	assert.Equal(t, HashKey(token), hash)
+	assert.NotContains(t, hash, token)
+	assert.Equal(t, token[:len(KeyPrefix)+8], DisplayPrefix(token))
+
+	other, _, err := GenerateKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestRolePermissions(t *testing.T) {
+	for _, role := range []Role{RoleAdmin, RoleAgentOperator, RoleUser} {
+		assert.True(t, ValidRole(role), role)
+		assert.True(t, role.Has(PermChat), role)
+	}
+	assert.False(t, ValidRole("superuser"))
+	assert.False(t, Role("superuser").Has(PermChat))
+
+	assert.True(t, RoleAdmin.Has(PermManageRoles))
+	assert.True(t, RoleAgentOperator.Has(PermManagePrompts))
+	assert.False(t, RoleAgentOperator.Has(PermManageUsers))
+	assert.False(t, RoleUser.Has(PermReadAnyHistory))
+	assert.False(t, RoleUser.Has(PermRevealPII))
+
+	// Permissions returns a copy callers cannot use to grant themselves more
+	perms := RoleUser.Permissions()
+	perms[0] = PermManageRoles
+	assert.False(t, RoleUser.Has(PermManageRoles))
+}
+
+func TestIsSelfOr(t *testing.T) {
+	user := Identity{UserID: "user-1", Role: RoleUser}
+	admin := Identity{UserID: "admin-1", Role: RoleAdmin}
+
+	assert.True(t, user.IsSelfOr("user-1", PermReadAnyHistory))
+	assert.False(t, user.IsSelfOr("user-2", PermReadAnyHistory))
+	assert.True(t, admin.IsSelfOr("user-2", PermReadAnyHistory))
+}
@@ -0,0 +1,114 @@
+package auth
+
+// Role names a set of permissions granted to a user
+type Role string
+
+// Roles known to the service
+const (
+	RoleAdmin         Role = "admin"
+	RoleAgentOperator Role = "agent-operator"
+	RoleUser          Role = "user"
+)
+
+// Permission names a single capability checked by handlers and middleware
+type Permission string
+
+// Permissions known to the service
+const (
+	// PermChat allows sending messages and reading one's own history
+	PermChat Permission = "chat"
+	// PermReadAnyHistory allows reading other users' chat history
+	PermReadAnyHistory Permission = "history:read_any"
+	// PermDeleteAnyMessage allows deleting other users' messages
+	PermDeleteAnyMessage Permission = "messages:delete_any"
+	// PermManageUsers allows creating, listing, editing and deleting users
+	PermManageUsers Permission = "users:manage"
+	// PermManageRoles allows changing a user's role
+	PermManageRoles Permission = "roles:manage"
+	// PermManageKeys allows issuing and revoking API keys for other users
+	PermManageKeys Permission = "keys:manage"
+	// PermManageTools allows setting the tools an assistant may use
+	PermManageTools Permission = "tools:manage"
+	// PermManagePrompts allows editing and promoting prompts
+	PermManagePrompts Permission = "prompts:manage"
+	// PermManageAssistants allows creating, editing and deleting assistants
+	PermManageAssistants Permission = "assistants:manage"
+	// PermReviewModeration allows reading messages flagged by moderation
+	PermReviewModeration Permission = "moderation:review"
+	// PermRevealPII allows reading the originals of redacted messages
+	PermRevealPII Permission = "pii:reveal"
+	// PermManageRetention allows configuring data retention policies
+	PermManageRetention Permission = "retention:manage"
+	// PermReadAudit allows querying and verifying the audit log
+	PermReadAudit Permission = "audit:read"
+	// PermReadFeedback allows reading aggregated ratings of assistant replies
+	PermReadFeedback Permission = "feedback:read"
+	// PermReadUsage allows reading other users' and the organization's token usage
+	PermReadUsage Permission = "usage:read"
+	// PermManageWebhooks allows managing the organization's webhook endpoints
+	// and their delivery log
+	PermManageWebhooks Permission = "webhooks:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermChat,
+		PermReadAnyHistory,
+		PermDeleteAnyMessage,
+		PermManageUsers,
+		PermManageRoles,
+		PermManageKeys,
+		PermManageTools,
+		PermManagePrompts,
+		PermManageAssistants,
+		PermReviewModeration,
+		PermRevealPII,
+		PermManageRetention,
+		PermReadAudit,
+		PermReadFeedback,
+		PermReadUsage,
+		PermManageWebhooks,
+	},
+	RoleAgentOperator: {
+		PermChat,
+		PermManageTools,
+		PermManagePrompts,
+		PermManageAssistants,
+		PermReadFeedback,
+	},
+	RoleUser: {
+		PermChat,
+	},
+}
+
+// ValidRole reports whether r is a known role
+func ValidRole(r Role) bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Has reports whether the role grants perm
+func (r Role) Has(perm Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions returns the permissions granted to the role
+func (r Role) Permissions() []Permission {
+	return append([]Permission(nil), rolePermissions[r]...)
+}
+
+// Can reports whether the identity's role grants perm
+func (id Identity) Can(perm Permission) bool {
+	return id.Role.Has(perm)
+}
+
+// IsSelfOr reports whether the identity is userID or holds perm, the usual
+// rule for acting on another user's data
+func (id Identity) IsSelfOr(userID string, perm Permission) bool {
+	return id.UserID == userID || id.Can(perm)
+}
@@ -0,0 +1,103 @@
+// Package cache stores generated replies so identical prompts, and for the
+// semantic cache similar questions, are answered without calling a model
+// provider again
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+)
+
+// Store keeps values for a limited time
+type Store interface {
+	// Get returns the value stored under key, reporting false when there is
+	// none or it has expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// ResponseCache answers completion requests from a Store, falling back to a
+// provider on a miss. Entries never cross organizations.
+type ResponseCache struct {
+	store  Store
+	ttl    time.Duration
+	logger logger.Logger
+}
+
+// NewResponseCache creates a response cache whose entries live for ttl
+func NewResponseCache(store Store, ttl time.Duration, logger logger.Logger) *ResponseCache {
+	return &ResponseCache{
+		store:  store,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// Complete returns the cached response to req, or gets one from provider and
+// caches it. It reports whether the response came from the cache. Cache
+// failures are logged and the provider is used instead.
+func (c *ResponseCache) Complete(ctx context.Context, provider llm.Provider, req llm.Request) (*llm.Response, bool, error) {
+	key := Key(ctx, req)
+
+	data, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		c.logger.Warn("Response cache lookup failed", logger.F("error", err.Error()))
+	}
+	if ok {
+		var resp llm.Response
+		if err := json.Unmarshal(data, &resp); err == nil {
+			return &resp, true, nil
+		}
+		c.logger.Warn("Discarding unreadable cache entry", logger.F("key", key))
+	}
+
+	resp, err := provider.Complete(ctx, req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if data, err := json.Marshal(resp); err == nil {
+		if err := c.store.Set(ctx, key, data, c.ttl); err != nil {
+			c.logger.Warn("Response cache update failed", logger.F("error", err.Error()))
+		}
+	}
+	return resp, false, nil
+}
+
+// Key hashes everything that shapes a reply: the organization, the requested
+// provider, model and temperature, and the conversation. Messages are
+// compared case-insensitively with whitespace collapsed, so trivially
+// different phrasings of the same question share an entry.
+func Key(ctx context.Context, req llm.Request) string {
+	orgID, _ := auth.OrganizationID(ctx)
+
+	h := sha256.New()
+	write := func(s string) {
+		h.Write([]byte(strconv.Itoa(len(s))))
+		h.Write([]byte{':'})
+		h.Write([]byte(s))
+	}
+	write(orgID)
+	write(req.Provider)
+	write(req.Model)
+	write(strconv.FormatFloat(req.Temperature, 'g', -1, 64))
+	for _, m := range req.Messages {
+		write(string(m.Role))
+		write(Normalize(m.Content))
+	}
+	return "response:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// Normalize lower-cases text and collapses runs of whitespace
+func Normalize(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
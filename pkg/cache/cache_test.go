@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+)
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2)
+
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, store.Set(ctx, "b", []byte("2"), 0))
+	_, ok, _ := store.Get(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, store.Set(ctx, "c", []byte("3"), 0))
+
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok, "b was least recently used")
+	_, ok, _ = store.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, store.Len())
+}
+
+func TestMemoryStoreExpires(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(10)
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Set(ctx, "k", []byte("v"), time.Minute))
+	value, ok, _ := store.Get(ctx, "k")
+	require.True(t, ok)
+	assert.Equal(t, "v", string(value))
+
+	now = now.Add(time.Minute)
+	_, ok, _ = store.Get(ctx, "k")
+	assert.False(t, ok)
+	assert.Zero(t, store.Len())
+}
+
+func TestKey(t *testing.T) {
+	org1 := auth.ForOrganization(context.Background(), "org1")
+	org2 := auth.ForOrganization(context.Background(), "org2")
+	req := func(model, text string) llm.Request {
+		return llm.Request{Model: model, Temperature: 0.2, Messages: []llm.Message{{Role: llm.RoleUser, Content: text}}}
+	}
+
+	assert.Equal(t, Key(org1, req("m", "What are your  hours?")), Key(org1, req("m", " what are your hours? ")))
+	assert.NotEqual(t, Key(org1, req("m", "hours?")), Key(org2, req("m", "hours?")), "entries are per organization")
+	assert.NotEqual(t, Key(org1, req("m", "hours?")), Key(org1, req("other", "hours?")))
+	assert.NotEqual(t, Key(org1, req("m", "opening hours")), Key(org1, req("m", "closing hours")))
+}
+
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Name() string { return "counting" }
+
+func (p *countingProvider) Complete(ctx context.Context, req llm.Request) (*llm.Response, error) {
+	p.calls++
+	return &llm.Response{Content: "We open at nine.", Model: req.Model, Provider: p.Name()}, nil
+}
+
+func TestResponseCacheComplete(t *testing.T) {
+	ctx := auth.ForOrganization(context.Background(), "org1")
+	provider := &countingProvider{}
+	c := NewResponseCache(NewMemoryStore(10), time.Hour, logger.NewLogrusLogger("error", "text"))
+	req := llm.Request{Model: "m", Messages: []llm.Message{{Role: llm.RoleUser, Content: "When do you open?"}}}
+
+	resp, hit, err := c.Complete(ctx, provider, req)
+	require.NoError(t, err)
+	assert.False(t, hit)
+
+	cached, hit, err := c.Complete(ctx, provider, req)
+	require.NoError(t, err)
+	assert.True(t, hit)
+	assert.Equal(t, resp, cached)
+	assert.Equal(t, 1, provider.calls)
+}
@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/redact"
+)
+
+// SemanticStore keeps answers alongside the embeddings of the questions they
+// answered, within the organization in the context
+type SemanticStore interface {
+	// SemanticCandidates returns the newest unexpired entries of scope
+	SemanticCandidates(ctx context.Context, assistantID, fingerprint string, limit int) ([]models.SemanticCacheEntry, error)
+	SaveSemanticEntry(ctx context.Context, entry *models.SemanticCacheEntry) error
+}
+
+// SemanticOptions configures a SemanticCache. Model is the embedding model;
+// empty uses the embedder's default. Threshold is the cosine similarity a
+// question must reach when the assistant sets none. At most MaxCandidates
+// entries are compared per lookup. Redactor, when set, scrubs personal data
+// from questions and answers before they are stored for other users.
+type SemanticOptions struct {
+	Model         string
+	Threshold     float64
+	TTL           time.Duration
+	MaxCandidates int
+	Redactor      *redact.Redactor
+}
+
+// Scope is the set of entries that may answer a question: those of one
+// assistant generated under the same system prompt while it and its
+// knowledge bases were unchanged
+type Scope struct {
+	AssistantID    string
+	Fingerprint    string
+	KnowledgeBases []string
+}
+
+// NewScope returns the scope of an assistant's answers. promptID is the
+// prompt template version the session uses, if any, system is the system
+// prompt as rendered for the session, and versions holds the current version
+// of each of the assistant's knowledge bases. Editing the assistant or
+// bumping a knowledge base version moves it to a new scope. Templates render
+// the user, session and date into system, so answers given under them are
+// only shared where those values match.
+func NewScope(assistant *models.Assistant, promptID, system string, versions map[string]int) Scope {
+	names := append([]string(nil), assistant.KnowledgeBases...)
+	sort.Strings(names)
+
+	h := sha256.New()
+	write := func(s string) {
+		h.Write([]byte(strconv.Itoa(len(s))))
+		h.Write([]byte{':'})
+		h.Write([]byte(s))
+	}
+	write(assistant.ID)
+	write(strconv.FormatInt(assistant.UpdatedAt.UnixNano(), 10))
+	write(promptID)
+	write(system)
+	for _, name := range names {
+		write(name)
+		write(strconv.Itoa(versions[name]))
+	}
+	return Scope{
+		AssistantID:    assistant.ID,
+		Fingerprint:    hex.EncodeToString(h.Sum(nil)),
+		KnowledgeBases: names,
+	}
+}
+
+// SemanticCache answers questions from earlier answers to similar questions,
+// compared by the cosine similarity of their embeddings
+type SemanticCache struct {
+	embedder llm.Embedder
+	store    SemanticStore
+	opts     SemanticOptions
+	logger   logger.Logger
+}
+
+// NewSemanticCache creates a semantic cache that embeds questions with
+// embedder
+func NewSemanticCache(embedder llm.Embedder, store SemanticStore, opts SemanticOptions, logger logger.Logger) *SemanticCache {
+	return &SemanticCache{
+		embedder: embedder,
+		store:    store,
+		opts:     opts,
+		logger:   logger,
+	}
+}
+
+// Complete answers question from the most similar cached question in scope
+// when its similarity reaches threshold, or the configured default when
+// threshold is zero. Otherwise it gets a response to req from provider and
+// caches it. It reports whether the response came from the cache. Cache
+// failures are logged and the provider is used instead.
+func (c *SemanticCache) Complete(ctx context.Context, provider llm.Provider, req llm.Request, scope Scope, question string, threshold float64) (*llm.Response, bool, error) {
+	if threshold <= 0 {
+		threshold = c.opts.Threshold
+	}
+
+	embedding, err := c.embed(ctx, question)
+	if err != nil {
+		c.logger.Warn("Question embedding failed", logger.F("error", err.Error()))
+	}
+	if embedding != nil {
+		if entry, score := c.lookup(ctx, scope, embedding); entry != nil && score >= threshold {
+			c.logger.Debug("Semantic cache hit",
+				logger.F("assistant_id", scope.AssistantID),
+				logger.F("entry_id", entry.ID),
+				logger.F("score", score),
+			)
+			return &llm.Response{
+				Content:  entry.Answer,
+				Model:    entry.Model,
+				Provider: entry.Provider,
+			}, true, nil
+		}
+	}
+
+	resp, err := provider.Complete(ctx, req)
+	if err != nil {
+		return nil, false, err
+	}
+	if embedding != nil {
+		c.save(ctx, scope, question, embedding, resp)
+	}
+	return resp, false, nil
+}
+
+func (c *SemanticCache) embed(ctx context.Context, question string) ([]float32, error) {
+	resp, err := c.embedder.Embed(ctx, llm.EmbedRequest{
+		Model: c.opts.Model,
+		Input: []string{Normalize(question)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Vectors) != 1 || len(resp.Vectors[0]) == 0 {
+		return nil, nil
+	}
+	return resp.Vectors[0], nil
+}
+
+// lookup returns the entry in scope most similar to embedding, with its score
+func (c *SemanticCache) lookup(ctx context.Context, scope Scope, embedding []float32) (*models.SemanticCacheEntry, float64) {
+	candidates, err := c.store.SemanticCandidates(ctx, scope.AssistantID, scope.Fingerprint, c.opts.MaxCandidates)
+	if err != nil {
+		c.logger.Warn("Semantic cache lookup failed", logger.F("error", err.Error()))
+		return nil, 0
+	}
+
+	var (
+		best      *models.SemanticCacheEntry
+		bestScore float64
+	)
+	for i := range candidates {
+		if score := Cosine(embedding, candidates[i].Embedding); best == nil || score > bestScore {
+			best, bestScore = &candidates[i], score
+		}
+	}
+	return best, bestScore
+}
+
+func (c *SemanticCache) save(ctx context.Context, scope Scope, question string, embedding []float32, resp *llm.Response) {
+	identity, _ := auth.FromContext(ctx)
+	entry := &models.SemanticCacheEntry{
+		AssistantID:    scope.AssistantID,
+		Fingerprint:    scope.Fingerprint,
+		KnowledgeBases: scope.KnowledgeBases,
+		UserID:         identity.UserID,
+		Question:       c.redact(question),
+		Embedding:      embedding,
+		Answer:         c.redact(resp.Content),
+		Provider:       resp.Provider,
+		Model:          resp.Model,
+	}
+	if c.opts.TTL > 0 {
+		expires := time.Now().UTC().Add(c.opts.TTL)
+		entry.ExpiresAt = &expires
+	}
+	if err := c.store.SaveSemanticEntry(ctx, entry); err != nil {
+		c.logger.Warn("Semantic cache update failed", logger.F("error", err.Error()))
+	}
+}
+
+func (c *SemanticCache) redact(text string) string {
+	if c.opts.Redactor == nil {
+		return text
+	}
+	return c.opts.Redactor.Redact(text)
+}
+
+// Cosine returns the cosine similarity of a and b, or 0 when they differ in
+// length or either is zero
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
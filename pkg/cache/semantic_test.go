@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/redact"
+)
+
+type memorySemanticStore struct {
+	entries []models.SemanticCacheEntry
+}
+
+func (s *memorySemanticStore) SemanticCandidates(ctx context.Context, assistantID, fingerprint string, limit int) ([]models.SemanticCacheEntry, error) {
+	var out []models.SemanticCacheEntry
+	for _, e := range s.entries {
+		if e.AssistantID == assistantID && e.Fingerprint == fingerprint {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (s *memorySemanticStore) SaveSemanticEntry(ctx context.Context, entry *models.SemanticCacheEntry) error {
+	s.entries = append(s.entries, *entry)
+	return nil
+}
+
+func TestSemanticCacheComplete(t *testing.T) {
+	ctx := auth.ForOrganization(context.Background(), "org1")
+	provider := &countingProvider{}
+	store := &memorySemanticStore{}
+	c := NewSemanticCache(llm.NewEchoProvider(), store, SemanticOptions{Threshold: 0.8, TTL: time.Hour}, logger.NewLogrusLogger("error", "text"))
+	assistant := &models.Assistant{ID: "a1", KnowledgeBases: models.StringList{"faq"}}
+	scope := NewScope(assistant, "", "", map[string]int{"faq": 1})
+	ask := func(scope Scope, question string) bool {
+		req := llm.Request{Messages: []llm.Message{{Role: llm.RoleUser, Content: question}}}
+		resp, hit, err := c.Complete(ctx, provider, req, scope, question, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "We open at nine.", resp.Content)
+		return hit
+	}
+
+	assert.False(t, ask(scope, "What time do you open on Monday?"))
+	assert.True(t, ask(scope, "what time do you open on monday"), "similar question")
+	assert.False(t, ask(scope, "How do I reset my password?"), "unrelated question")
+	assert.Equal(t, 2, provider.calls)
+
+	changed := NewScope(assistant, "", "", map[string]int{"faq": 2})
+	assert.False(t, ask(changed, "What time do you open on Monday?"), "knowledge base changed")
+	assert.False(t, ask(NewScope(&models.Assistant{ID: "a2"}, "", "", nil), "What time do you open on Monday?"), "other assistant")
+}
+
+func TestSemanticCacheRedactsEntries(t *testing.T) {
+	ctx := auth.ForOrganization(context.Background(), "org1")
+	redactor, err := redact.New(nil)
+	require.NoError(t, err)
+	store := &memorySemanticStore{}
+	c := NewSemanticCache(llm.NewEchoProvider(), store, SemanticOptions{Threshold: 0.8, Redactor: redactor}, logger.NewLogrusLogger("error", "text"))
+
+	question := "Write to jane.doe@example.com"
+	req := llm.Request{Messages: []llm.Message{{Role: llm.RoleUser, Content: question}}}
+	resp, _, err := c.Complete(ctx, llm.NewEchoProvider(), req, NewScope(&models.Assistant{ID: "a1"}, "", "", nil), question, 0)
+	require.NoError(t, err)
+	assert.Contains(t, resp.Content, "jane.doe@example.com", "the asker gets the answer as generated")
+
+	require.Len(t, store.entries, 1)
+	assert.NotContains(t, store.entries[0].Question, "jane.doe")
+	assert.NotContains(t, store.entries[0].Answer, "jane.doe")
+}
+
+func TestNewScope(t *testing.T) {
+	assistant := &models.Assistant{ID: "a1", KnowledgeBases: models.StringList{"faq", "billing"}}
+	versions := map[string]int{"faq": 1, "billing": 3}
+
+	scope := NewScope(assistant, "p1", "Be brief", versions)
+	assert.Equal(t, []string{"billing", "faq"}, scope.KnowledgeBases)
+	assert.Equal(t, scope, NewScope(&models.Assistant{ID: "a1", KnowledgeBases: models.StringList{"billing", "faq"}}, "p1", "Be brief", versions))
+	assert.NotEqual(t, scope.Fingerprint, NewScope(assistant, "p2", "Be brief", versions).Fingerprint, "prompt version")
+	assert.NotEqual(t, scope.Fingerprint, NewScope(assistant, "p1", "You are helping user-2", versions).Fingerprint, "rendered system prompt")
+
+	edited := *assistant
+	edited.UpdatedAt = time.Now()
+	assert.NotEqual(t, scope.Fingerprint, NewScope(&edited, "p1", "Be brief", versions).Fingerprint, "assistant edited")
+}
+
+func TestCosine(t *testing.T) {
+	assert.InDelta(t, 1, Cosine([]float32{1, 2}, []float32{2, 4}), 1e-9)
+	assert.InDelta(t, 0, Cosine([]float32{1, 0}, []float32{0, 1}), 1e-9)
+	assert.Zero(t, Cosine([]float32{1}, []float32{1, 0}))
+	assert.Zero(t, Cosine([]float32{0, 0}, []float32{1, 0}))
+}
@@ -0,0 +1,145 @@
+// Package channels connects the agent to messaging media besides the API,
+// such as Slack, email and SMS. Each channel normalizes what it receives into
+// Messages and delivers Replies back to the conversation they came from.
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidSignature is returned by Webhook.Receive when a request was not
+// signed by the channel's provider
+var ErrInvalidSignature = errors.New("channels: invalid request signature")
+
+// Capabilities describe what a channel can carry
+type Capabilities struct {
+	// Markdown is set when the channel renders Markdown; replies to other
+	// channels are sent as plain text
+	Markdown bool
+	// MaxLength is the most characters one message may hold; longer replies
+	// are split. Zero means unlimited.
+	MaxLength int
+}
+
+// Message is a message received on a channel
+type Message struct {
+	// OrganizationID and AssistantID come from the configuration of the
+	// workspace, mailbox or number the message arrived at
+	OrganizationID string
+	AssistantID    string
+	// Account identifies the sender on the channel and DisplayName names them
+	Account     string
+	DisplayName string
+	// Thread identifies the conversation on the channel, including whatever
+	// the channel needs to reply to it
+	Thread  string
+	Subject string
+	Text    string
+	// Ref is the channel's own ID of the message, such as an email's
+	// Message-ID
+	Ref string
+}
+
+// Reply is an answer to a Message
+type Reply struct {
+	Account string
+	Thread  string
+	Subject string
+	Ref     string
+	Text    string
+}
+
+// ReplyTo returns a reply with text to msg
+func ReplyTo(msg Message, text string) Reply {
+	return Reply{
+		Account: msg.Account,
+		Thread:  msg.Thread,
+		Subject: msg.Subject,
+		Ref:     msg.Ref,
+		Text:    text,
+	}
+}
+
+// Channel is a messaging medium the agent converses on
+type Channel interface {
+	// Name identifies the channel on sessions and linked accounts
+	Name() string
+	Capabilities() Capabilities
+	// Send delivers a reply to the conversation its message came from
+	Send(ctx context.Context, reply Reply) error
+}
+
+// Inbound is what a webhook request carried: the messages to answer, and the
+// acknowledgement the provider expects in response
+type Inbound struct {
+	Messages    []Message
+	ContentType string
+	Response    []byte
+}
+
+// Webhook is a channel whose provider delivers messages as HTTP requests
+type Webhook interface {
+	Channel
+	// Receive verifies and parses a request. It returns ErrInvalidSignature
+	// for requests the provider did not sign.
+	Receive(r *http.Request) (*Inbound, error)
+}
+
+// Poller is a channel whose messages are fetched, such as from a mailbox
+type Poller interface {
+	Channel
+	// Poll returns the messages received since the last poll
+	Poll(ctx context.Context) ([]Message, error)
+}
+
+var (
+	markdownEmphasis = regexp.MustCompile(`(\*\*|__|~~|` + "`" + `)`)
+	markdownHeading  = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	markdownLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+)
+
+// Format prepares a reply's text for a channel: Markdown is flattened for
+// channels that do not render it, and the text is split into messages the
+// channel can carry
+func Format(caps Capabilities, text string) []string {
+	if !caps.Markdown {
+		text = markdownLink.ReplaceAllString(text, "$1 ($2)")
+		text = markdownHeading.ReplaceAllString(text, "")
+		text = markdownEmphasis.ReplaceAllString(text, "")
+	}
+	return Split(text, caps.MaxLength)
+}
+
+// Split breaks text into parts of at most max characters, preferring to
+// break between paragraphs, then lines, then words. A max of zero returns
+// text whole.
+func Split(text string, max int) []string {
+	text = strings.TrimSpace(text)
+	if max <= 0 || utf8.RuneCountInString(text) <= max {
+		return []string{text}
+	}
+
+	var parts []string
+	for utf8.RuneCountInString(text) > max {
+		runes := []rune(text)
+		head := string(runes[:max])
+		cut := len(head)
+		for _, sep := range []string{"\n\n", "\n", " "} {
+			if i := strings.LastIndex(head, sep); i > 0 {
+				cut = i
+				break
+			}
+		}
+		parts = append(parts, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+	return parts
+}
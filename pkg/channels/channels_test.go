@@ -0,0 +1,22 @@
+package channels
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	assert.Equal(t, []string{"short"}, Split(" short ", 10))
+	assert.Equal(t, []string{strings.Repeat("a", 20)}, Split(strings.Repeat("a", 20), 0))
+	assert.Equal(t, []string{"first paragraph", "second one"}, Split("first paragraph\n\nsecond one", 20))
+	assert.Equal(t, []string{"one two", "three"}, Split("one two three", 9))
+	assert.Equal(t, []string{"abcd", "efgh", "ij"}, Split("abcdefghij", 4))
+}
+
+func TestFormat(t *testing.T) {
+	text := "## Hours\n**Open** daily, see [the site](https://example.com)."
+	assert.Equal(t, []string{text}, Format(Capabilities{Markdown: true}, text))
+	assert.Equal(t, []string{"Hours\nOpen daily, see the site (https://example.com)."}, Format(Capabilities{}, text))
+}
@@ -0,0 +1,426 @@
+// Package email implements an email channel: mailboxes are polled over IMAP
+// and answered over SMTP
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// DefaultFolder is the folder polled when a mailbox names none
+const DefaultFolder = "INBOX"
+
+// maxBody bounds the text read from a message
+const maxBody = 1 << 20
+
+// replyHeader matches the line mail clients put above a quoted message
+var replyHeader = regexp.MustCompile(`^On .+ wrote:$`)
+
+// Mailbox connects an email address to an organization. New conversations
+// start with AssistantID; empty uses the default prompt. IMAPAddr and
+// SMTPAddr are host:port; IMAPTLS selects implicit TLS for IMAP, and SMTP
+// upgrades with STARTTLS when the server offers it. Username and Password
+// sign in to both.
+type Mailbox struct {
+	Address        string
+	OrganizationID string
+	AssistantID    string
+	IMAPAddr       string
+	IMAPTLS        bool
+	Folder         string
+	SMTPAddr       string
+	Username       string
+	Password       string
+}
+
+// Channel is a channels.Poller for email. Accounts are the sender's
+// address, and threads "<mailbox address> <root Message-ID>", so a reply to
+// an answer continues the conversation the first message started.
+type Channel struct {
+	mailboxes []Mailbox
+	byAddress map[string]Mailbox
+	timeout   time.Duration
+	now       func() time.Time
+}
+
+// NewChannel creates an email channel for mailboxes. Each IMAP session and
+// SMTP delivery must finish within timeout.
+func NewChannel(mailboxes []Mailbox, timeout time.Duration) *Channel {
+	normalized := make([]Mailbox, 0, len(mailboxes))
+	byAddress := make(map[string]Mailbox, len(mailboxes))
+	for _, mb := range mailboxes {
+		mb.Address = strings.ToLower(mb.Address)
+		if mb.Folder == "" {
+			mb.Folder = DefaultFolder
+		}
+		normalized = append(normalized, mb)
+		byAddress[mb.Address] = mb
+	}
+	return &Channel{
+		mailboxes: normalized,
+		byAddress: byAddress,
+		timeout:   timeout,
+		now:       time.Now,
+	}
+}
+
+// Name implements channels.Channel
+func (c *Channel) Name() string {
+	return models.ChannelEmail
+}
+
+// Capabilities implements channels.Channel
+func (c *Channel) Capabilities() channels.Capabilities {
+	return channels.Capabilities{}
+}
+
+// Poll implements channels.Poller by fetching the unseen messages of every
+// mailbox. Messages are marked seen as they are fetched, so each is answered
+// at most once. A mailbox that cannot be read does not keep the others'
+// messages from being returned along with the error.
+func (c *Channel) Poll(ctx context.Context) ([]channels.Message, error) {
+	var (
+		messages []channels.Message
+		errs     []error
+	)
+	for _, mb := range c.mailboxes {
+		received, err := c.receive(ctx, mb)
+		messages = append(messages, received...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("email: %s: %w", mb.Address, err))
+		}
+	}
+	return messages, errors.Join(errs...)
+}
+
+func (c *Channel) receive(ctx context.Context, mb Mailbox) ([]channels.Message, error) {
+	conn, err := dialIMAP(ctx, mb.IMAPAddr, mb.IMAPTLS, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.logout()
+
+	if err := conn.login(mb.Username, mb.Password); err != nil {
+		return nil, err
+	}
+	if err := conn.selectFolder(mb.Folder); err != nil {
+		return nil, err
+	}
+	uids, err := conn.unseen()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		messages []channels.Message
+		errs     []error
+	)
+	for _, uid := range uids {
+		raw, err := conn.fetch(uid)
+		if err != nil {
+			return messages, err
+		}
+		if err := conn.markSeen(uid); err != nil {
+			return messages, err
+		}
+		msg, ok, err := parse(raw, mb)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("message %d: %w", uid, err))
+			continue
+		}
+		if ok {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, errors.Join(errs...)
+}
+
+// parse normalizes a raw message received at mb. It reports false for
+// messages that must not be answered: the mailbox's own, automatic replies
+// and bulk mail, and messages with no text of their own.
+func parse(raw []byte, mb Mailbox) (channels.Message, bool, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return channels.Message{}, false, err
+	}
+	from, err := m.Header.AddressList("From")
+	if err != nil || len(from) == 0 {
+		return channels.Message{}, false, fmt.Errorf("invalid sender: %v", err)
+	}
+	sender := strings.ToLower(from[0].Address)
+	if sender == mb.Address || automatic(m.Header) {
+		return channels.Message{}, false, nil
+	}
+
+	text, err := textBody(m.Header, m.Body)
+	if err != nil {
+		return channels.Message{}, false, err
+	}
+	if text = stripQuoted(text); text == "" {
+		return channels.Message{}, false, nil
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		subject = m.Header.Get("Subject")
+	}
+	displayName := from[0].Name
+	if displayName == "" {
+		displayName = sender
+	}
+	ref := strings.TrimSpace(m.Header.Get("Message-Id"))
+	root := threadRoot(m.Header)
+	if root == "" {
+		root = "<" + sender + ">"
+	}
+
+	return channels.Message{
+		OrganizationID: mb.OrganizationID,
+		AssistantID:    mb.AssistantID,
+		Account:        sender,
+		DisplayName:    displayName,
+		Thread:         mb.Address + " " + root,
+		Subject:        subject,
+		Text:           text,
+		Ref:            ref,
+	}, true, nil
+}
+
+// automatic reports whether a message was sent by a machine rather than a
+// person, such as an out-of-office reply, which answering could loop with
+func automatic(h mail.Header) bool {
+	if v := strings.ToLower(h.Get("Auto-Submitted")); v != "" && v != "no" {
+		return true
+	}
+	switch strings.ToLower(h.Get("Precedence")) {
+	case "bulk", "junk", "list", "auto_reply":
+		return true
+	}
+	return false
+}
+
+// threadRoot returns the Message-ID of the first message of the
+// conversation a message belongs to
+func threadRoot(h mail.Header) string {
+	for _, name := range []string{"References", "In-Reply-To", "Message-Id"} {
+		if ids := strings.Fields(h.Get(name)); len(ids) > 0 {
+			return ids[0]
+		}
+	}
+	return ""
+}
+
+type header interface {
+	Get(key string) string
+}
+
+// textBody returns the first text/plain part of a message body
+func textBody(h header, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	switch strings.ToLower(h.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &lineSkipper{r: body})
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return "", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := textBody(part.Header, part)
+			if err != nil || text != "" {
+				return text, err
+			}
+		}
+	case mediaType == "text/plain":
+		data, err := io.ReadAll(io.LimitReader(body, maxBody))
+		if err != nil {
+			return "", err
+		}
+		return strings.ToValidUTF8(string(data), "�"), nil
+	}
+	return "", nil
+}
+
+// lineSkipper drops the line breaks base64 bodies are wrapped with
+type lineSkipper struct {
+	r io.Reader
+}
+
+func (l *lineSkipper) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+// stripQuoted removes the quoted message a reply carries below, or between,
+// its own text, and the sender's signature
+func stripQuoted(text string) string {
+	var kept []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if line == "-- " || trimmed == "-----Original Message-----" || replyHeader.MatchString(trimmed) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// Send implements channels.Channel by mailing the reply from the mailbox the
+// conversation is held with, threaded under the message it answers
+func (c *Channel) Send(ctx context.Context, reply channels.Reply) error {
+	address, root, _ := strings.Cut(reply.Thread, " ")
+	mb, ok := c.byAddress[address]
+	if !ok {
+		return fmt.Errorf("email: unknown mailbox %q", address)
+	}
+	msg, err := c.compose(mb, root, reply)
+	if err != nil {
+		return err
+	}
+	return c.deliver(ctx, mb, reply.Account, msg)
+}
+
+// compose renders a reply as a message
+func (c *Channel) compose(mb Mailbox, root string, reply channels.Reply) ([]byte, error) {
+	subject := reply.Subject
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = strings.TrimSpace("Re: " + subject)
+	}
+	references := root
+	if reply.Ref != "" && reply.Ref != root {
+		references = strings.TrimSpace(root + " " + reply.Ref)
+	}
+	id, err := messageID(mb.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	headers := [][2]string{
+		{"From", mb.Address},
+		{"To", reply.Account},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", c.now().Format(time.RFC1123Z)},
+		{"Message-ID", id},
+		{"In-Reply-To", reply.Ref},
+		{"References", references},
+		{"Auto-Submitted", "auto-replied"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, h := range headers {
+		if h[1] != "" {
+			fmt.Fprintf(&buf, "%s: %s\r\n", h[0], h[1])
+		}
+	}
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(reply.Text, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageID returns a new Message-ID in the domain of address
+func messageID(address string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	domain := address
+	if i := strings.LastIndexByte(address, '@'); i >= 0 {
+		domain = address[i+1:]
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">", nil
+}
+
+// deliver sends msg to rcpt through the mailbox's SMTP server
+func (c *Channel) deliver(ctx context.Context, mb Mailbox, rcpt string, msg []byte) error {
+	conn, err := (&net.Dialer{Timeout: c.timeout}).DialContext(ctx, "tcp", mb.SMTPAddr)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(c.timeout))
+
+	host, _, _ := net.SplitHostPort(mb.SMTPAddr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if mb.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", mb.Username, mb.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(mb.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(rcpt); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,259 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels"
+)
+
+// fakeIMAP serves the IMAP commands the channel uses from an in-memory
+// mailbox
+type fakeIMAP struct {
+	mu       sync.Mutex
+	messages map[uint32]string
+	seen     map[uint32]bool
+}
+
+func (f *fakeIMAP) serve(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.session(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func (f *fakeIMAP) session(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK fake IMAP ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, command, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		fields := strings.Fields(command)
+
+		f.mu.Lock()
+		switch {
+		case fields[0] == "LOGIN":
+			if fields[1] != `"agent"` || fields[2] != `"secret"` {
+				fmt.Fprintf(conn, "%s NO invalid credentials\r\n", tag)
+				f.mu.Unlock()
+				continue
+			}
+		case command == "UID SEARCH UNSEEN":
+			var uids []string
+			for uid := range f.messages {
+				if !f.seen[uid] {
+					uids = append(uids, fmt.Sprint(uid))
+				}
+			}
+			fmt.Fprintf(conn, "* SEARCH %s\r\n", strings.Join(uids, " "))
+		case strings.HasPrefix(command, "UID FETCH"):
+			var uid uint32
+			fmt.Sscanf(fields[2], "%d", &uid)
+			msg := f.messages[uid]
+			fmt.Fprintf(conn, "* 1 FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", uid, len(msg), msg)
+		case strings.HasPrefix(command, "UID STORE"):
+			var uid uint32
+			fmt.Sscanf(fields[2], "%d", &uid)
+			f.seen[uid] = true
+		case command == "LOGOUT":
+			fmt.Fprintf(conn, "* BYE\r\n%s OK done\r\n", tag)
+			f.mu.Unlock()
+			return
+		}
+		f.mu.Unlock()
+		fmt.Fprintf(conn, "%s OK done\r\n", tag)
+	}
+}
+
+// fakeSMTP accepts messages and records them
+type fakeSMTP struct {
+	mu   sync.Mutex
+	rcpt []string
+	data []string
+}
+
+func (f *fakeSMTP) serve(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.session(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func (f *fakeSMTP) session(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "220 fake SMTP ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimRight(line, "\r\n"))
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			fmt.Fprint(conn, "250-fake\r\n250 AUTH PLAIN\r\n")
+		case strings.HasPrefix(command, "AUTH PLAIN"):
+			fmt.Fprint(conn, "235 authenticated\r\n")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			f.mu.Lock()
+			f.rcpt = append(f.rcpt, strings.Trim(strings.TrimRight(line, "\r\n")[len("RCPT TO:"):], "<>"))
+			f.mu.Unlock()
+			fmt.Fprint(conn, "250 OK\r\n")
+		case command == "DATA":
+			fmt.Fprint(conn, "354 go ahead\r\n")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			f.mu.Lock()
+			f.data = append(f.data, data.String())
+			f.mu.Unlock()
+			fmt.Fprint(conn, "250 queued\r\n")
+		case command == "QUIT":
+			fmt.Fprint(conn, "221 bye\r\n")
+			return
+		default:
+			fmt.Fprint(conn, "250 OK\r\n")
+		}
+	}
+}
+
+const question = "From: Ada <Ada@example.com>\r\n" +
+	"To: agent@example.org\r\n" +
+	"Subject: =?utf-8?q?Opening_hours?=\r\n" +
+	"Message-ID: <q1@example.com>\r\n" +
+	"Content-Type: multipart/alternative; boundary=b\r\n" +
+	"\r\n" +
+	"--b\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"When are you open?\r\n" +
+	"\r\n" +
+	"On Mon, Jan 1, 2024 Agent wrote:\r\n" +
+	"> an earlier answer\r\n" +
+	"--b\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<p>When are you open?</p>\r\n" +
+	"--b--\r\n"
+
+const outOfOffice = "From: bob@example.com\r\n" +
+	"Subject: Out of office\r\n" +
+	"Auto-Submitted: auto-replied\r\n" +
+	"\r\n" +
+	"I am away.\r\n"
+
+func TestPollAndSend(t *testing.T) {
+	imap := &fakeIMAP{
+		messages: map[uint32]string{1: question, 2: outOfOffice},
+		seen:     map[uint32]bool{},
+	}
+	smtp := &fakeSMTP{}
+	ch := NewChannel([]Mailbox{{
+		Address:        "Agent@example.org",
+		OrganizationID: "org-1",
+		AssistantID:    "asst-1",
+		IMAPAddr:       imap.serve(t),
+		SMTPAddr:       smtp.serve(t),
+		Username:       "agent",
+		Password:       "secret",
+	}}, 5*time.Second)
+
+	messages, err := ch.Poll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	msg := messages[0]
+	assert.Equal(t, "org-1", msg.OrganizationID)
+	assert.Equal(t, "asst-1", msg.AssistantID)
+	assert.Equal(t, "ada@example.com", msg.Account)
+	assert.Equal(t, "Ada", msg.DisplayName)
+	assert.Equal(t, "agent@example.org <q1@example.com>", msg.Thread)
+	assert.Equal(t, "Opening hours", msg.Subject)
+	assert.Equal(t, "When are you open?", msg.Text)
+	assert.True(t, imap.seen[1])
+	assert.True(t, imap.seen[2])
+
+	messages, err = ch.Poll(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	require.NoError(t, ch.Send(context.Background(), channels.ReplyTo(msg, "We open at nine.")))
+	require.Len(t, smtp.data, 1)
+	assert.Equal(t, []string{"ada@example.com"}, smtp.rcpt)
+	sent := smtp.data[0]
+	assert.Contains(t, sent, "Subject: Re: Opening hours\r\n")
+	assert.Contains(t, sent, "In-Reply-To: <q1@example.com>\r\n")
+	assert.Contains(t, sent, "References: <q1@example.com>\r\n")
+	assert.Contains(t, sent, "Auto-Submitted: auto-replied\r\n")
+	assert.Contains(t, sent, "We open at nine.")
+
+	assert.Error(t, ch.Send(context.Background(), channels.Reply{Thread: "other@example.org <x>", Account: "a@b"}))
+}
+
+func TestPollLoginFailure(t *testing.T) {
+	imap := &fakeIMAP{messages: map[uint32]string{}, seen: map[uint32]bool{}}
+	ch := NewChannel([]Mailbox{{
+		Address:  "agent@example.org",
+		IMAPAddr: imap.serve(t),
+		Username: "agent",
+		Password: "wrong",
+	}}, 5*time.Second)
+
+	_, err := ch.Poll(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid credentials")
+}
+
+func TestThreadRoot(t *testing.T) {
+	reply := "From: ada@example.com\r\n" +
+		"Message-ID: <q2@example.com>\r\n" +
+		"In-Reply-To: <a1@example.org>\r\n" +
+		"References: <q1@example.com> <a1@example.org>\r\n" +
+		"Subject: Re: Opening hours\r\n" +
+		"\r\n" +
+		"And on Sundays?\r\n"
+	msg, ok, err := parse([]byte(reply), Mailbox{Address: "agent@example.org"})
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "agent@example.org <q1@example.com>", msg.Thread)
+	assert.Equal(t, "<q2@example.com>", msg.Ref)
+}
@@ -0,0 +1,193 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLiteral bounds the size of a message fetched over IMAP
+const maxLiteral = 10 << 20
+
+// imapConn is a minimal IMAP4rev1 client, enough to fetch unseen messages
+// from a mailbox and mark them seen
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// imapResponse is an untagged response line, with the literal it carried
+// if any
+type imapResponse struct {
+	text    string
+	literal []byte
+}
+
+// dialIMAP connects to addr, with implicit TLS when useTLS is set, and
+// reads the server's greeting. The whole session must finish within
+// timeout.
+func dialIMAP(ctx context.Context, addr string, useTLS bool, timeout time.Duration) (*imapConn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	c := &imapConn{conn: conn, r: bufio.NewReader(conn)}
+	greeting, err := c.readLine()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		conn.Close()
+		return nil, fmt.Errorf("imap: unexpected greeting %q", greeting)
+	}
+	return c, nil
+}
+
+func (c *imapConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// cmd sends a command and returns its untagged responses, or an error when
+// the command does not complete with OK
+func (c *imapConn) cmd(format string, args ...interface{}) ([]imapResponse, error) {
+	c.tag++
+	tag := "a" + strconv.Itoa(c.tag)
+	if _, err := fmt.Fprintf(c.conn, tag+" "+format+"\r\n", args...); err != nil {
+		return nil, err
+	}
+
+	var responses []imapResponse
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if status, ok := strings.CutPrefix(line, tag+" "); ok {
+			if !strings.HasPrefix(status, "OK") {
+				return nil, fmt.Errorf("imap: %s", status)
+			}
+			return responses, nil
+		}
+
+		resp := imapResponse{text: line}
+		for {
+			n, ok := literalSize(line)
+			if !ok {
+				break
+			}
+			if n > maxLiteral {
+				return nil, fmt.Errorf("imap: literal of %d bytes is too large", n)
+			}
+			resp.literal = make([]byte, n)
+			if _, err := io.ReadFull(c.r, resp.literal); err != nil {
+				return nil, err
+			}
+			if line, err = c.readLine(); err != nil {
+				return nil, err
+			}
+			resp.text += line
+		}
+		responses = append(responses, resp)
+	}
+}
+
+// literalSize parses the "{n}" a line announcing a literal ends with
+func literalSize(line string) (int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	i := strings.LastIndexByte(line, '{')
+	if i < 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(line[i+1 : len(line)-1])
+	return n, err == nil && n >= 0
+}
+
+// quote returns s as an IMAP quoted string
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func (c *imapConn) login(username, password string) error {
+	_, err := c.cmd("LOGIN %s %s", quote(username), quote(password))
+	return err
+}
+
+func (c *imapConn) selectFolder(folder string) error {
+	_, err := c.cmd("SELECT %s", quote(folder))
+	return err
+}
+
+// unseen returns the UIDs of the messages not yet seen
+func (c *imapConn) unseen() ([]uint32, error) {
+	responses, err := c.cmd("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, err
+	}
+	var uids []uint32
+	for _, resp := range responses {
+		fields, ok := strings.CutPrefix(resp.text, "* SEARCH")
+		if !ok {
+			continue
+		}
+		for _, f := range strings.Fields(fields) {
+			uid, err := strconv.ParseUint(f, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("imap: invalid UID %q", f)
+			}
+			uids = append(uids, uint32(uid))
+		}
+	}
+	return uids, nil
+}
+
+// fetch returns a message's raw contents without marking it seen
+func (c *imapConn) fetch(uid uint32) ([]byte, error) {
+	responses, err := c.cmd("UID FETCH %d (BODY.PEEK[])", uid)
+	if err != nil {
+		return nil, err
+	}
+	for _, resp := range responses {
+		if resp.literal != nil && strings.Contains(resp.text, "FETCH") {
+			return resp.literal, nil
+		}
+	}
+	return nil, fmt.Errorf("imap: message %d not found", uid)
+}
+
+func (c *imapConn) markSeen(uid uint32) error {
+	_, err := c.cmd(`UID STORE %d +FLAGS.SILENT (\Seen)`, uid)
+	return err
+}
+
+// logout ends the session and closes the connection
+func (c *imapConn) logout() {
+	c.cmd("LOGOUT")
+	c.conn.Close()
+}
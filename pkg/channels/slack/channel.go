@@ -0,0 +1,129 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// maxEventBody bounds the size of an Events API request
+const maxEventBody = 1 << 20
+
+// maxMessageLength is the longest message Slack recommends posting
+const maxMessageLength = 4000
+
+// Workspace connects a Slack workspace to an organization. New
+// conversations start with AssistantID; empty uses the default prompt.
+type Workspace struct {
+	TeamID         string
+	OrganizationID string
+	BotToken       string
+	AssistantID    string
+}
+
+// Channel is a channels.Webhook for a Slack app. It answers mentions in
+// channels and direct messages, in the message's thread. Accounts are
+// "<team>:<user>" and threads "<team>:<channel>:<thread ts>".
+type Channel struct {
+	signingSecret string
+	client        *Client
+	workspaces    map[string]Workspace
+	now           func() time.Time
+}
+
+// NewChannel creates the channel for a Slack app's signing secret and the
+// workspaces it is installed in
+func NewChannel(signingSecret string, client *Client, workspaces []Workspace) *Channel {
+	byTeam := make(map[string]Workspace, len(workspaces))
+	for _, ws := range workspaces {
+		byTeam[ws.TeamID] = ws
+	}
+	return &Channel{
+		signingSecret: signingSecret,
+		client:        client,
+		workspaces:    byTeam,
+		now:           time.Now,
+	}
+}
+
+// Name implements channels.Channel
+func (c *Channel) Name() string {
+	return models.ChannelSlack
+}
+
+// Capabilities implements channels.Channel
+func (c *Channel) Capabilities() channels.Capabilities {
+	return channels.Capabilities{Markdown: true, MaxLength: maxMessageLength}
+}
+
+// Receive implements channels.Webhook. URL verification requests are
+// answered with their challenge. Slack retries events it did not see
+// acknowledged within three seconds; since events are acknowledged before
+// they are answered, retries are ignored.
+func (c *Channel) Receive(r *http.Request) (*channels.Inbound, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxEventBody))
+	if err != nil {
+		return nil, err
+	}
+	if err := Verify(c.signingSecret, r.Header, body, c.now()); err != nil {
+		return nil, err
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("slack: invalid event: %w", err)
+	}
+
+	inbound := &channels.Inbound{}
+	if envelope.Type == TypeURLVerification {
+		inbound.ContentType = "application/json"
+		inbound.Response, err = json.Marshal(map[string]string{"challenge": envelope.Challenge})
+		return inbound, err
+	}
+
+	event := envelope.Event
+	ws, known := c.workspaces[envelope.TeamID]
+	if envelope.Type != TypeEventCallback || !event.Addressed() || !known || r.Header.Get(HeaderRetryNum) != "" {
+		return inbound, nil
+	}
+	text := StripMentions(event.Text)
+	if text == "" {
+		return inbound, nil
+	}
+
+	inbound.Messages = []channels.Message{{
+		OrganizationID: ws.OrganizationID,
+		AssistantID:    ws.AssistantID,
+		Account:        ws.TeamID + ":" + event.User,
+		DisplayName:    "Slack user " + event.User,
+		Thread:         ws.TeamID + ":" + event.Channel + ":" + event.Thread(),
+		Text:           text,
+		Ref:            event.TS,
+	}}
+	return inbound, nil
+}
+
+// Send implements channels.Channel by posting the reply in its thread with
+// the workspace's bot token
+func (c *Channel) Send(ctx context.Context, reply channels.Reply) error {
+	parts := strings.SplitN(reply.Thread, ":", 3)
+	if len(parts) != 3 {
+		return fmt.Errorf("slack: invalid thread %q", reply.Thread)
+	}
+	ws, ok := c.workspaces[parts[0]]
+	if !ok {
+		return fmt.Errorf("slack: unknown workspace %q", parts[0])
+	}
+	return c.client.PostMessage(ctx, ws.BotToken, Message{
+		Channel:  parts[1],
+		ThreadTS: parts[2],
+		Text:     reply.Text,
+	})
+}
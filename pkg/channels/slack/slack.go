@@ -0,0 +1,193 @@
+// Package slack implements the parts of Slack's Events API and Web API used
+// to hold conversations with the agent in Slack
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels"
+)
+
+// DefaultAPIBaseURL is the base URL of Slack's Web API
+const DefaultAPIBaseURL = "https://slack.com/api"
+
+// Headers Slack sets on event requests
+const (
+	HeaderSignature = "X-Slack-Signature"
+	HeaderTimestamp = "X-Slack-Request-Timestamp"
+	HeaderRetryNum  = "X-Slack-Retry-Num"
+)
+
+// MaxSkew is how old a signed request may be before it is rejected as a
+// replay
+const MaxSkew = 5 * time.Minute
+
+// Envelope and event types
+const (
+	TypeURLVerification = "url_verification"
+	TypeEventCallback   = "event_callback"
+	EventMessage        = "message"
+	EventAppMention     = "app_mention"
+)
+
+// ErrInvalidSignature is returned by Verify when a request is not signed
+// with the signing secret, or was signed too long ago
+var ErrInvalidSignature = channels.ErrInvalidSignature
+
+var mention = regexp.MustCompile(`<@[A-Z0-9]+(\|[^>]*)?>`)
+
+// Sign returns the signature Slack sends for body at ts: "v0=" and the hex
+// HMAC-SHA256, keyed with the signing secret, of "v0:<ts>:<body>"
+func Sign(secret string, ts time.Time, body []byte) string {
+	return "v0=" + hex.EncodeToString(mac(secret, strconv.FormatInt(ts.Unix(), 10), body))
+}
+
+// Verify checks the signature headers of an event request
+func Verify(secret string, header http.Header, body []byte, now time.Time) error {
+	t := header.Get(HeaderTimestamp)
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if skew := now.Sub(time.Unix(unix, 0)); skew > MaxSkew || skew < -MaxSkew {
+		return ErrInvalidSignature
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(header.Get(HeaderSignature), "v0="))
+	if err != nil || !hmac.Equal(sig, mac(secret, t, body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func mac(secret, t string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(h, "v0:%s:", t)
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// Envelope is the body of an Events API request
+type Envelope struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge,omitempty"`
+	TeamID    string `json:"team_id,omitempty"`
+	EventID   string `json:"event_id,omitempty"`
+	Event     Event  `json:"event"`
+}
+
+// Event is a message or app_mention event
+type Event struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype,omitempty"`
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channel_type,omitempty"`
+	User        string `json:"user"`
+	BotID       string `json:"bot_id,omitempty"`
+	Text        string `json:"text"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts,omitempty"`
+}
+
+// Addressed reports whether the event is a message a person sent to the
+// bot: a mention in a channel, or a direct message. Bot messages, including
+// the bot's own replies, and edits or joins are not.
+func (e Event) Addressed() bool {
+	if e.BotID != "" || e.Subtype != "" || e.User == "" {
+		return false
+	}
+	switch e.Type {
+	case EventAppMention:
+		return true
+	case EventMessage:
+		return e.ChannelType == "im"
+	}
+	return false
+}
+
+// Thread returns the timestamp of the thread the event belongs to. A message
+// outside a thread starts one.
+func (e Event) Thread() string {
+	if e.ThreadTS != "" {
+		return e.ThreadTS
+	}
+	return e.TS
+}
+
+// StripMentions removes user mentions, such as the bot's own, from text
+func StripMentions(text string) string {
+	return strings.Join(strings.Fields(mention.ReplaceAllString(text, " ")), " ")
+}
+
+// Message is a message posted with chat.postMessage
+type Message struct {
+	Channel  string `json:"channel"`
+	ThreadTS string `json:"thread_ts,omitempty"`
+	Text     string `json:"text"`
+}
+
+// Client calls Slack's Web API
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewClient creates a client for the Web API at baseURL, normally
+// DefaultAPIBaseURL
+func NewClient(baseURL string, client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  client,
+	}
+}
+
+// PostMessage posts msg with a workspace's bot token
+func (c *Client) PostMessage(ctx context.Context, token string, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat.postMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack: chat.postMessage returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("slack: invalid chat.postMessage response: %w", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("slack: chat.postMessage failed: %s", result.Error)
+	}
+	return nil
+}
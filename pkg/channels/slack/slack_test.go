@@ -0,0 +1,103 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels"
+)
+
+func signedHeader(secret string, ts time.Time, body []byte) http.Header {
+	header := http.Header{}
+	header.Set(HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+	header.Set(HeaderSignature, Sign(secret, ts, body))
+	return header
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"type":"event_callback"}`)
+
+	assert.NoError(t, Verify("secret", signedHeader("secret", now, body), body, now.Add(time.Minute)))
+	assert.ErrorIs(t, Verify("other", signedHeader("secret", now, body), body, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", signedHeader("secret", now, body), []byte(`{}`), now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", signedHeader("secret", now, body), body, now.Add(10*time.Minute)), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", http.Header{}, body, now), ErrInvalidSignature)
+}
+
+func TestEventAddressed(t *testing.T) {
+	assert.True(t, Event{Type: EventAppMention, User: "U1", Text: "<@UBOT> hi"}.Addressed())
+	assert.True(t, Event{Type: EventMessage, ChannelType: "im", User: "U1"}.Addressed())
+	assert.False(t, Event{Type: EventMessage, ChannelType: "channel", User: "U1"}.Addressed(), "channel messages need a mention")
+	assert.False(t, Event{Type: EventMessage, ChannelType: "im", BotID: "B1"}.Addressed(), "the bot's own replies")
+	assert.False(t, Event{Type: EventMessage, ChannelType: "im", User: "U1", Subtype: "message_changed"}.Addressed())
+}
+
+func TestEventThreadAndMentions(t *testing.T) {
+	assert.Equal(t, "1.1", Event{TS: "1.1"}.Thread())
+	assert.Equal(t, "1.1", Event{TS: "2.2", ThreadTS: "1.1"}.Thread())
+	assert.Equal(t, "how do I reset my password?", StripMentions("<@U0BOT>  how do I reset my password? <@U123|ana>"))
+}
+
+func TestClientPostMessage(t *testing.T) {
+	var received Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat.postMessage", r.URL.Path)
+		assert.Equal(t, "Bearer xoxb-test", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if received.Channel == "C404" {
+			w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/api/", server.Client())
+	msg := Message{Channel: "C1", ThreadTS: "1.1", Text: "Hello"}
+	require.NoError(t, client.PostMessage(context.Background(), "xoxb-test", msg))
+	assert.Equal(t, msg, received)
+
+	err := client.PostMessage(context.Background(), "xoxb-test", Message{Channel: "C404", Text: "Hello"})
+	assert.ErrorContains(t, err, "channel_not_found")
+}
+
+func TestChannelReceive(t *testing.T) {
+	ch := NewChannel("secret", NewClient(DefaultAPIBaseURL, nil), []Workspace{{TeamID: "T1", OrganizationID: "org-1", BotToken: "xoxb", AssistantID: "asst-1"}})
+	request := func(secret, body string) *http.Request {
+		now := time.Now()
+		req := httptest.NewRequest("POST", "/channels/slack", strings.NewReader(body))
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(HeaderSignature, Sign(secret, now, []byte(body)))
+		return req
+	}
+
+	inbound, err := ch.Receive(request("secret", `{"type":"url_verification","challenge":"abc"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"challenge":"abc"}`, string(inbound.Response))
+
+	_, err = ch.Receive(request("wrong", `{"type":"url_verification","challenge":"abc"}`))
+	assert.ErrorIs(t, err, channels.ErrInvalidSignature)
+
+	inbound, err = ch.Receive(request("secret", `{"type":"event_callback","team_id":"T2","event":{"type":"app_mention","user":"U1","text":"hi","channel":"C1","ts":"1.1"}}`))
+	require.NoError(t, err)
+	assert.Empty(t, inbound.Messages)
+
+	inbound, err = ch.Receive(request("secret", `{"type":"event_callback","team_id":"T1","event":{"type":"app_mention","user":"U1","text":"<@UBOT> hi","channel":"C1","ts":"1.1"}}`))
+	require.NoError(t, err)
+	require.Len(t, inbound.Messages, 1)
+	msg := inbound.Messages[0]
+	assert.Equal(t, "org-1", msg.OrganizationID)
+	assert.Equal(t, "T1:U1", msg.Account)
+	assert.Equal(t, "T1:C1:1.1", msg.Thread)
+	assert.Equal(t, "hi", msg.Text)
+}
@@ -0,0 +1,180 @@
+// Package sms implements an SMS channel for Twilio's messaging webhooks and
+// REST API, or any provider compatible with them
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// DefaultAPIBaseURL is the base URL of Twilio's REST API
+const DefaultAPIBaseURL = "https://api.twilio.com"
+
+// HeaderSignature is the header Twilio signs webhook requests with
+const HeaderSignature = "X-Twilio-Signature"
+
+const (
+	// maxWebhookBody bounds the size of a webhook request
+	maxWebhookBody = 64 << 10
+	// maxMessageLength is the longest message Twilio accepts
+	maxMessageLength = 1600
+)
+
+// emptyResponse acknowledges a message without replying to it inline;
+// replies are sent through the REST API once generated
+var emptyResponse = []byte(`<?xml version="1.0" encoding="UTF-8"?><Response></Response>`)
+
+// Number connects a phone number to an organization. New conversations
+// start with AssistantID; empty uses the default prompt.
+type Number struct {
+	Number         string
+	OrganizationID string
+	AssistantID    string
+}
+
+// Options configures a Channel. WebhookURL is the public URL the provider
+// calls, which requests are signed for.
+type Options struct {
+	AccountSID string
+	AuthToken  string
+	APIBaseURL string
+	WebhookURL string
+	Numbers    []Number
+}
+
+// Channel is a channels.Webhook for SMS. Accounts are the sender's phone
+// number and threads "<our number>:<sender's number>", so each sender holds
+// one conversation with each number.
+type Channel struct {
+	opts    Options
+	numbers map[string]Number
+	client  *http.Client
+}
+
+// NewChannel creates an SMS channel
+func NewChannel(opts Options, client *http.Client) *Channel {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	opts.APIBaseURL = strings.TrimRight(opts.APIBaseURL, "/")
+	numbers := make(map[string]Number, len(opts.Numbers))
+	for _, n := range opts.Numbers {
+		numbers[n.Number] = n
+	}
+	return &Channel{
+		opts:    opts,
+		numbers: numbers,
+		client:  client,
+	}
+}
+
+// Name implements channels.Channel
+func (c *Channel) Name() string {
+	return models.ChannelSMS
+}
+
+// Capabilities implements channels.Channel
+func (c *Channel) Capabilities() channels.Capabilities {
+	return channels.Capabilities{MaxLength: maxMessageLength}
+}
+
+// Sign returns the signature of a webhook request to rawURL with the given
+// form parameters: the base64 HMAC-SHA1, keyed with the auth token, of the
+// URL followed by each parameter's name and value in name order
+func Sign(authToken, rawURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := hmac.New(sha1.New, []byte(authToken))
+	h.Write([]byte(rawURL))
+	for _, k := range keys {
+		for _, v := range params[k] {
+			h.Write([]byte(k + v))
+		}
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Receive implements channels.Webhook. Messages to numbers that are not
+// configured are acknowledged and ignored.
+func (c *Channel) Receive(r *http.Request) (*channels.Inbound, error) {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxWebhookBody)
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("sms: invalid request: %w", err)
+	}
+	want := Sign(c.opts.AuthToken, c.opts.WebhookURL, r.PostForm)
+	if !hmac.Equal([]byte(want), []byte(r.Header.Get(HeaderSignature))) {
+		return nil, channels.ErrInvalidSignature
+	}
+
+	inbound := &channels.Inbound{ContentType: "text/xml", Response: emptyResponse}
+	from, to, text := r.PostForm.Get("From"), r.PostForm.Get("To"), strings.TrimSpace(r.PostForm.Get("Body"))
+	number, ok := c.numbers[to]
+	if !ok || from == "" || text == "" {
+		return inbound, nil
+	}
+
+	inbound.Messages = []channels.Message{{
+		OrganizationID: number.OrganizationID,
+		AssistantID:    number.AssistantID,
+		Account:        from,
+		DisplayName:    from,
+		Thread:         to + ":" + from,
+		Text:           text,
+		Ref:            r.PostForm.Get("MessageSid"),
+	}}
+	return inbound, nil
+}
+
+// Send implements channels.Channel by sending the reply from the number the
+// conversation is held with
+func (c *Channel) Send(ctx context.Context, reply channels.Reply) error {
+	from, to, ok := strings.Cut(reply.Thread, ":")
+	if !ok {
+		return fmt.Errorf("sms: invalid thread %q", reply.Thread)
+	}
+
+	form := url.Values{
+		"From": {from},
+		"To":   {to},
+		"Body": {reply.Text},
+	}
+	endpoint := c.opts.APIBaseURL + "/2010-04-01/Accounts/" + url.PathEscape(c.opts.AccountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.opts.AccountSID, c.opts.AuthToken)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = json.Unmarshal(body, &apiErr)
+		return fmt.Errorf("sms: sending failed with status %d: %s", resp.StatusCode, apiErr.Message)
+	}
+	return nil
+}
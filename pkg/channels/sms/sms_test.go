@@ -0,0 +1,106 @@
+package sms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels"
+)
+
+const webhookURL = "https://agent.example.com/channels/sms"
+
+func testChannel(apiBaseURL string) *Channel {
+	return NewChannel(Options{
+		AccountSID: "AC1",
+		AuthToken:  "token",
+		APIBaseURL: apiBaseURL,
+		WebhookURL: webhookURL,
+		Numbers:    []Number{{Number: "+15550001", OrganizationID: "org-1", AssistantID: "asst-1"}},
+	}, nil)
+}
+
+func webhookRequest(t *testing.T, token string, form url.Values) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest("POST", "/channels/sms", strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(HeaderSignature, Sign(token, webhookURL, form))
+	return req
+}
+
+func TestReceive(t *testing.T) {
+	ch := testChannel(DefaultAPIBaseURL)
+	form := url.Values{
+		"From":       {"+15559999"},
+		"To":         {"+15550001"},
+		"Body":       {" hello "},
+		"MessageSid": {"SM1"},
+	}
+
+	t.Run("message", func(t *testing.T) {
+		inbound, err := ch.Receive(webhookRequest(t, "token", form))
+		require.NoError(t, err)
+		assert.Equal(t, "text/xml", inbound.ContentType)
+		require.Len(t, inbound.Messages, 1)
+		msg := inbound.Messages[0]
+		assert.Equal(t, "org-1", msg.OrganizationID)
+		assert.Equal(t, "asst-1", msg.AssistantID)
+		assert.Equal(t, "+15559999", msg.Account)
+		assert.Equal(t, "+15550001:+15559999", msg.Thread)
+		assert.Equal(t, "hello", msg.Text)
+		assert.Equal(t, "SM1", msg.Ref)
+	})
+
+	t.Run("bad signature", func(t *testing.T) {
+		_, err := ch.Receive(webhookRequest(t, "wrong", form))
+		assert.ErrorIs(t, err, channels.ErrInvalidSignature)
+	})
+
+	t.Run("unknown number", func(t *testing.T) {
+		other := url.Values{"From": {"+15559999"}, "To": {"+15550002"}, "Body": {"hello"}}
+		inbound, err := ch.Receive(webhookRequest(t, "token", other))
+		require.NoError(t, err)
+		assert.Empty(t, inbound.Messages)
+		assert.NotEmpty(t, inbound.Response)
+	})
+}
+
+func TestSend(t *testing.T) {
+	var got url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC1/Messages.json", r.URL.Path)
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC1", user)
+		assert.Equal(t, "token", pass)
+		require.NoError(t, r.ParseForm())
+		got = r.PostForm
+		if got.Get("To") == "+15550000" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"invalid number"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid":"SM2"}`))
+	}))
+	defer srv.Close()
+
+	ch := testChannel(srv.URL)
+	require.NoError(t, ch.Send(context.Background(), channels.Reply{Thread: "+15550001:+15559999", Text: "hi"}))
+	assert.Equal(t, "+15550001", got.Get("From"))
+	assert.Equal(t, "+15559999", got.Get("To"))
+	assert.Equal(t, "hi", got.Get("Body"))
+
+	err := ch.Send(context.Background(), channels.Reply{Thread: "+15550001:+15550000", Text: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid number")
+
+	assert.Error(t, ch.Send(context.Background(), channels.Reply{Thread: "bad", Text: "hi"}))
+}
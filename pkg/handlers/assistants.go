@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultTemperature = 0.7
+
+// AssistantHandler handles assistant profile endpoints
+type AssistantHandler struct {
+	assistantRepo *database.AssistantRepository
+	providers     []string
+	logger        logger.Logger
+}
+
+// NewAssistantHandler creates a new assistant handler. Assistants may only
+// name the given model providers.
+func NewAssistantHandler(assistantRepo *database.AssistantRepository, providers []string, logger logger.Logger) *AssistantHandler {
+	return &AssistantHandler{
+		assistantRepo: assistantRepo,
+		providers:     providers,
+		logger:        logger,
+	}
+}
+
+// CreateAssistant creates an assistant
+func (h *AssistantHandler) CreateAssistant(c *gin.Context) {
+	var req models.CreateAssistantRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+	if req.PromptName != "" && !promptNamePattern.MatchString(req.PromptName) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid prompt_name",
+		})
+		return
+	}
+	if !h.validRoute(c, req.Provider, req.Fallbacks) {
+		return
+	}
+	if len(req.AllowedTools) > 0 && !mayConfigureTools(c) {
+		return
+	}
+
+	assistant := &models.Assistant{
+		Name:              req.Name,
+		Description:       req.Description,
+		SystemPrompt:      req.SystemPrompt,
+		PromptName:        req.PromptName,
+		Provider:          req.Provider,
+		Model:             req.Model,
+		Fallbacks:         req.Fallbacks,
+		Temperature:       defaultTemperature,
+		AllowedTools:      req.AllowedTools,
+		KnowledgeBases:    req.KnowledgeBases,
+		CacheResponses:    req.CacheResponses,
+		SemanticCache:     req.SemanticCache,
+		SemanticThreshold: req.SemanticThreshold,
+	}
+	if req.Temperature != nil {
+		assistant.Temperature = *req.Temperature
+	}
+
+	if err := h.assistantRepo.CreateAssistant(c.Request.Context(), assistant); err != nil {
+		respondAssistantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, assistant)
+}
+
+// ListAssistants lists the organization's assistants
+func (h *AssistantHandler) ListAssistants(c *gin.Context) {
+	assistants, err := h.assistantRepo.ListAssistants(c.Request.Context())
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"assistants": assistants,
+		"total":      len(assistants),
+	})
+}
+
+// GetAssistant returns a single assistant
+func (h *AssistantHandler) GetAssistant(c *gin.Context) {
+	assistant, err := h.assistantRepo.GetAssistantByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	if assistant == nil {
+		respondAssistantError(c, database.ErrNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, assistant)
+}
+
+// UpdateAssistant applies a partial update to an assistant
+func (h *AssistantHandler) UpdateAssistant(c *gin.Context) {
+	var req models.UpdateAssistantRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+	if req.PromptName != nil && *req.PromptName != "" && !promptNamePattern.MatchString(*req.PromptName) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid prompt_name",
+		})
+		return
+	}
+	var provider string
+	var fallbacks models.ModelTargets
+	if req.Provider != nil {
+		provider = *req.Provider
+	}
+	if req.Fallbacks != nil {
+		fallbacks = *req.Fallbacks
+	}
+	if !h.validRoute(c, provider, fallbacks) {
+		return
+	}
+	if req.AllowedTools != nil && !mayConfigureTools(c) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	assistant, err := h.assistantRepo.GetAssistantByID(ctx, c.Param("id"))
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	if assistant == nil {
+		respondAssistantError(c, database.ErrNotFound)
+		return
+	}
+
+	if req.Name != nil {
+		assistant.Name = *req.Name
+	}
+	if req.Description != nil {
+		assistant.Description = *req.Description
+	}
+	if req.SystemPrompt != nil {
+		assistant.SystemPrompt = *req.SystemPrompt
+	}
+	if req.PromptName != nil {
+		assistant.PromptName = *req.PromptName
+	}
+	if req.Provider != nil {
+		assistant.Provider = *req.Provider
+	}
+	if req.Model != nil {
+		assistant.Model = *req.Model
+	}
+	if req.Fallbacks != nil {
+		assistant.Fallbacks = *req.Fallbacks
+	}
+	if req.Temperature != nil {
+		assistant.Temperature = *req.Temperature
+	}
+	if req.AllowedTools != nil {
+		assistant.AllowedTools = *req.AllowedTools
+	}
+	if req.KnowledgeBases != nil {
+		assistant.KnowledgeBases = *req.KnowledgeBases
+	}
+	if req.CacheResponses != nil {
+		assistant.CacheResponses = *req.CacheResponses
+	}
+	if req.SemanticCache != nil {
+		assistant.SemanticCache = *req.SemanticCache
+	}
+	if req.SemanticThreshold != nil {
+		assistant.SemanticThreshold = *req.SemanticThreshold
+	}
+
+	if err := h.assistantRepo.UpdateAssistant(ctx, assistant); err != nil {
+		respondAssistantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, assistant)
+}
+
+// DeleteAssistant soft-deletes an assistant
+func (h *AssistantHandler) DeleteAssistant(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.assistantRepo.DeleteAssistant(c.Request.Context(), id); err != nil {
+		respondAssistantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Assistant deleted successfully",
+		"assistant_id": id,
+	})
+}
+
+// validRoute checks that an assistant only names configured providers. It
+// writes a 400 response and reports false otherwise.
+func (h *AssistantHandler) validRoute(c *gin.Context, provider string, fallbacks models.ModelTargets) bool {
+	names := []string{provider}
+	for _, f := range fallbacks {
+		if f.Provider == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Every fallback needs a provider",
+			})
+			return false
+		}
+		names = append(names, f.Provider)
+	}
+	for _, name := range names {
+		if name != "" && !models.StringList(h.providers).Contains(name) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "Unknown provider " + name,
+				"providers": h.providers,
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// mayConfigureTools refuses to set the tools an assistant may use unless the
+// caller may manage tools
+func mayConfigureTools(c *gin.Context) bool {
+	identity, _ := auth.FromContext(c.Request.Context())
+	if identity.Can(auth.PermManageTools) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":      "Forbidden",
+		"permission": auth.PermManageTools,
+	})
+	return false
+}
+
+func respondAssistantError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Assistant not found",
+		})
+	case errors.Is(err, database.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "An assistant with this name already exists",
+		})
+	default:
+		respondInternalError(c)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+)
+
+func TestAllowedToolsRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewAssistantHandler(nil, nil, logger.NewLogrusLogger("error", "text"))
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		identity := auth.Identity{UserID: "user-1", OrganizationID: "org-1", Role: auth.RoleUser}
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+	})
+	router.POST("/assistants", h.CreateAssistant)
+	router.PATCH("/assistants/:id", h.UpdateAssistant)
+
+	for name, r := range map[string]struct{ method, path, body string }{
+		"create": {"POST", "/assistants", `{"name":"support","allowed_tools":["search"]}`},
+		"update": {"PATCH", "/assistants/a1", `{"allowed_tools":[]}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(r.method, r.path, bytes.NewBufferString(r.body))
+			require.NoError(t, err)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Contains(t, w.Body.String(), string(auth.PermManageTools))
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler exposes the audit log to admins
+type AuditHandler struct {
+	auditRepo *database.AuditRepository
+	logger    logger.Logger
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditRepo *database.AuditRepository, logger logger.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditRepo: auditRepo,
+		logger:    logger,
+	}
+}
+
+// ListEvents lists audit events, newest first, filtered by actor_id, action,
+// target_type, target_id and an RFC 3339 from/to time range
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	filter := database.AuditFilter{
+		ActorID:    c.Query("actor_id"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+	}
+	if filter.From, filter.To, ok = parseTimeRange(c); !ok {
+		return
+	}
+
+	events, total, err := h.auditRepo.ListEvents(c.Request.Context(), filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":    events,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// VerifyChain checks the organization's audit log for tampering
+func (h *AuditHandler) VerifyChain(c *gin.Context) {
+	result, err := h.auditRepo.VerifyChain(c.Request.Context())
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parseTimeRange reads the optional RFC 3339 from and to query parameters. It
+// writes a 400 response and reports false when either is invalid.
+func parseTimeRange(c *gin.Context) (from, to *time.Time, ok bool) {
+	for _, bound := range []struct {
+		param string
+		dst   **time.Time
+	}{{"from", &from}, {"to", &to}} {
+		value := c.Query(bound.param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": bound.param + " must be an RFC 3339 timestamp",
+			})
+			return nil, nil, false
+		}
+		*bound.dst = &t
+	}
+	return from, to, true
+}
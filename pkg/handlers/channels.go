@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// ChannelHandler answers messages received on messaging channels. Each
+// channel account chats as a user of the organization the message arrived
+// for, created the first time the account writes, and each thread on a
+// channel holds one session per account.
+type ChannelHandler struct {
+	chat        *ChatHandler
+	channelRepo *database.ChannelRepository
+	channels    map[string]channels.Channel
+	timeout     time.Duration
+	logger      logger.Logger
+}
+
+// NewChannelHandler creates a new channel handler for the enabled channels.
+// Each reply must be generated and sent within timeout.
+func NewChannelHandler(chat *ChatHandler, channelRepo *database.ChannelRepository, enabled []channels.Channel, timeout time.Duration, logger logger.Logger) *ChannelHandler {
+	byName := make(map[string]channels.Channel, len(enabled))
+	for _, channel := range enabled {
+		byName[channel.Name()] = channel
+	}
+	return &ChannelHandler{
+		chat:        chat,
+		channelRepo: channelRepo,
+		channels:    byName,
+		timeout:     timeout,
+		logger:      logger,
+	}
+}
+
+// Webhook handles the requests a webhook channel's provider sends. Providers
+// expect a prompt answer, so messages are acknowledged at once and answered
+// in the background.
+func (h *ChannelHandler) Webhook(channel channels.Webhook) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inbound, err := channel.Receive(c.Request)
+		if errors.Is(err, channels.ErrInvalidSignature) {
+			h.logger.Warn("Rejected channel request",
+				logger.F("channel", channel.Name()),
+				logger.F("error", err.Error()),
+			)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid signature",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request body",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		for _, msg := range inbound.Messages {
+			go h.answer(channel.Name(), msg)
+		}
+		if inbound.Response == nil {
+			c.Status(http.StatusOK)
+			return
+		}
+		c.Data(http.StatusOK, inbound.ContentType, inbound.Response)
+	}
+}
+
+// Poll answers the messages a polled channel receives, checking every
+// interval until ctx is cancelled. Messages are answered one at a time, in
+// the order received.
+func (h *ChannelHandler) Poll(ctx context.Context, channel channels.Poller, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		messages, err := channel.Poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			h.logger.Error("Failed to poll messaging channel",
+				logger.F("channel", channel.Name()),
+				logger.F("error", err.Error()),
+			)
+		}
+		for _, msg := range messages {
+			h.answer(channel.Name(), msg)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// answer replies to a message as the user its account is linked to
+func (h *ChannelHandler) answer(name string, msg channels.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	ctx = auth.ForOrganization(ctx, msg.OrganizationID)
+
+	user, err := h.channelRepo.LinkedUser(ctx, name, msg.Account, newChannelUser(name, msg))
+	if err != nil {
+		h.send(ctx, name, msg, channelErrorText(err))
+		return
+	}
+	if user == nil {
+		h.logger.Info("Ignored message from a deleted user's channel account", logger.F("channel", name))
+		return
+	}
+
+	identity := auth.Identity{
+		UserID:         user.ID,
+		OrganizationID: msg.OrganizationID,
+		Role:           user.Role,
+	}
+	ctx = auth.WithIdentity(ctx, identity)
+
+	title := msg.Subject
+	if title == "" {
+		title = msg.Text
+	}
+	session, reply, err := h.chat.channelReply(ctx, identity, &models.ChatSession{
+		UserID:        user.ID,
+		Title:         truncate(title, sessionTitleLength),
+		Channel:       name,
+		ChannelThread: msg.Thread,
+	}, msg.AssistantID, msg.Text)
+	if err != nil {
+		h.send(ctx, name, msg, channelErrorText(err))
+		return
+	}
+	h.send(ctx, session.Channel, msg, reply.Message)
+}
+
+// send delivers text in reply to msg through the named channel, split into
+// as many messages as the channel needs
+func (h *ChannelHandler) send(ctx context.Context, name string, msg channels.Message, text string) {
+	channel, ok := h.channels[name]
+	if !ok {
+		h.logger.Error("Reply to a channel that is not enabled", logger.F("channel", name))
+		return
+	}
+	for _, part := range channels.Format(channel.Capabilities(), text) {
+		if err := channel.Send(ctx, channels.ReplyTo(msg, part)); err != nil {
+			h.logger.Error("Failed to send channel reply",
+				logger.F("channel", name),
+				logger.F("error", err.Error()),
+			)
+			return
+		}
+	}
+}
+
+// newChannelUser returns the user a channel account chats as when it is
+// first seen. Accounts such as phone numbers and email addresses may write
+// to several organizations, so the username is derived from both.
+func newChannelUser(name string, msg channels.Message) *models.User {
+	sum := sha256.Sum256([]byte(msg.OrganizationID + "\x00" + msg.Account))
+	username := name + "-" + hex.EncodeToString(sum[:12])
+	return &models.User{
+		Username:    username,
+		Email:       username + "@channels.invalid",
+		DisplayName: msg.DisplayName,
+		Role:        auth.RoleUser,
+	}
+}
+
+// channelReply answers a message received on a channel through the same
+// checks, storage and generation as SendMessage. The message continues the
+// user's open session in the thread, or starts thread as a new session with
+// the given assistant. It returns the session the reply belongs to.
+func (h *ChatHandler) channelReply(ctx context.Context, identity auth.Identity, thread *models.ChatSession, assistantID, text string) (*models.ChatSession, *models.ChatMessage, error) {
+	session, err := h.chatRepo.GetSessionByThread(ctx, thread.Channel, thread.ChannelThread, identity.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session != nil && !session.IsActive {
+		// A closed session ends the conversation; the thread starts afresh
+		session = nil
+	}
+
+	sessionID := ""
+	if session != nil {
+		sessionID = session.ID
+	}
+	if err := h.admit(ctx, identity, sessionID, text); err != nil {
+		return nil, nil, err
+	}
+
+	if session == nil {
+		if session, err = h.newSession(ctx, thread, assistantID); err != nil {
+			return nil, nil, err
+		}
+	}
+	path, _, err := h.postMessage(ctx, identity, session, text)
+	if err != nil {
+		return nil, nil, err
+	}
+	reply, err := h.reply(ctx, session, path, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, reply, nil
+}
+
+// channelErrorText explains to a channel user why their message was not
+// answered
+func channelErrorText(err error) string {
+	var (
+		refused  *requestError
+		exceeded *usage.QuotaError
+	)
+	switch {
+	case errors.As(err, &refused):
+		return refused.Error()
+	case errors.As(err, &exceeded):
+		return "You have used up your token quota. Please try again after " + exceeded.ResetAt.UTC().Format(time.RFC1123) + "."
+	default:
+		return "Sorry, I could not answer that right now. Please try again later."
+	}
+}
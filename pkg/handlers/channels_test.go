@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/channels"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/usage"
+)
+
+// fakeWebhook answers Receive with a fixed result
+type fakeWebhook struct {
+	inbound *channels.Inbound
+	err     error
+}
+
+func (f *fakeWebhook) Name() string                                     { return "fake" }
+func (f *fakeWebhook) Capabilities() channels.Capabilities              { return channels.Capabilities{} }
+func (f *fakeWebhook) Send(ctx context.Context, _ channels.Reply) error { return nil }
+func (f *fakeWebhook) Receive(r *http.Request) (*channels.Inbound, error) {
+	return f.inbound, f.err
+}
+
+func TestChannelWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewChannelHandler(nil, nil, nil, time.Second, logger.NewLogrusLogger("error", "text"))
+
+	serve := func(channel channels.Webhook) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.POST("/channels/fake", h.Webhook(channel))
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/channels/fake", nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("bad signature", func(t *testing.T) {
+		w := serve(&fakeWebhook{err: channels.ErrInvalidSignature})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		w := serve(&fakeWebhook{err: errors.New("malformed")})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("acknowledgement", func(t *testing.T) {
+		w := serve(&fakeWebhook{inbound: &channels.Inbound{ContentType: "text/xml", Response: []byte("<Response></Response>")}})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/xml", w.Header().Get("Content-Type"))
+		assert.Equal(t, "<Response></Response>", w.Body.String())
+	})
+
+	t.Run("empty acknowledgement", func(t *testing.T) {
+		w := serve(&fakeWebhook{inbound: &channels.Inbound{}})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+}
+
+func TestNewChannelUser(t *testing.T) {
+	a := newChannelUser("sms", channels.Message{OrganizationID: "org-1", Account: "+15550001", DisplayName: "+15550001"})
+	b := newChannelUser("sms", channels.Message{OrganizationID: "org-2", Account: "+15550001"})
+	assert.NotEqual(t, a.Username, b.Username)
+	assert.LessOrEqual(t, len(a.Username), 50)
+	assert.Equal(t, a.Username+"@channels.invalid", a.Email)
+	assert.Equal(t, "+15550001", a.DisplayName)
+}
+
+func TestChannelErrorText(t *testing.T) {
+	assert.Equal(t, "Session is closed", channelErrorText(refuse(http.StatusConflict, gin.H{"error": "Session is closed"})))
+	assert.Contains(t, channelErrorText(&usage.QuotaError{ResetAt: time.Now()}), "token quota")
+	assert.Contains(t, channelErrorText(assert.AnError), "try again later")
+}
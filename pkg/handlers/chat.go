@@ -1,26 +1,122 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
 
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/config"
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/cache"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/jobs"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
 	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
 	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/moderation"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/prompts"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/redact"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/usage"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/webhooks"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 500
+	sessionTitleLength  = 50
+)
+
+// ChatDependencies groups the collaborators of a ChatHandler
+type ChatDependencies struct {
+	ChatRepo       *database.ChatRepository
+	UserRepo       *database.UserRepository
+	PromptRepo     *database.PromptRepository
+	AssistantRepo  *database.AssistantRepository
+	ModerationRepo *database.ModerationRepository
+	AuditRepo      *database.AuditRepository
+	FeedbackRepo   *database.FeedbackRepository
+	KnowledgeRepo  *database.KnowledgeBaseRepository
+	Provider       llm.Provider
+	// Moderator screens user messages before they are stored or sent to the
+	// provider; nil disables moderation
+	Moderator *moderation.Chain
+	// Redactor removes personal data from messages before they are stored;
+	// nil stores messages as sent
+	Redactor *redact.Redactor
+	// Vault keeps encrypted originals of redacted messages; nil discards them
+	Vault *redact.Vault
+	// Quota rejects requests from callers that have used up their tokens;
+	// nil disables quotas
+	Quota *usage.Enforcer
+	// Cache answers repeated prompts for assistants that opt in; nil
+	// disables caching
+	Cache *cache.ResponseCache
+	// SemanticCache answers opening questions from answers to similar ones
+	// for assistants that opt in; nil disables it
+	SemanticCache *cache.SemanticCache
+	// Jobs queues replies requested with async=true
+	Jobs *jobs.Pool
+	// Events notifies tenants' webhook endpoints of chat events; nil
+	// disables webhooks
+	Events *webhooks.Dispatcher
+}
+
 // ChatHandler handles chat-related endpoints
 type ChatHandler struct {
-	logger logger.Logger
+	chatRepo       *database.ChatRepository
+	userRepo       *database.UserRepository
+	promptRepo     *database.PromptRepository
+	assistantRepo  *database.AssistantRepository
+	moderationRepo *database.ModerationRepository
+	auditRepo      *database.AuditRepository
+	feedbackRepo   *database.FeedbackRepository
+	knowledgeRepo  *database.KnowledgeBaseRepository
+	quota          *usage.Enforcer
+	cache          *cache.ResponseCache
+	semantic       *cache.SemanticCache
+	jobs           *jobs.Pool
+	events         *webhooks.Dispatcher
+	provider       llm.Provider
+	moderator      *moderation.Chain
+	redactor       *redact.Redactor
+	vault          *redact.Vault
+	config         *config.ChatConfig
+	logger         logger.Logger
 }
 
 // NewChatHandler creates a new chat handler
-func NewChatHandler(logger logger.Logger) *ChatHandler {
+func NewChatHandler(deps ChatDependencies, cfg *config.ChatConfig, logger logger.Logger) *ChatHandler {
 	return &ChatHandler{
-		logger: logger,
+		chatRepo:       deps.ChatRepo,
+		userRepo:       deps.UserRepo,
+		promptRepo:     deps.PromptRepo,
+		assistantRepo:  deps.AssistantRepo,
+		moderationRepo: deps.ModerationRepo,
+		auditRepo:      deps.AuditRepo,
+		feedbackRepo:   deps.FeedbackRepo,
+		knowledgeRepo:  deps.KnowledgeRepo,
+		quota:          deps.Quota,
+		cache:          deps.Cache,
+		semantic:       deps.SemanticCache,
+		jobs:           deps.Jobs,
+		events:         deps.Events,
+		provider:       deps.Provider,
+		moderator:      deps.Moderator,
+		redactor:       deps.Redactor,
+		vault:          deps.Vault,
+		config:         cfg,
+		logger:         logger,
 	}
 }
 
-// SendMessage handles sending a chat message
+// SendMessage handles sending a chat message. With async=true the message is
+// stored and the reply is generated in the background: the response is 202
+// with a job to poll at /jobs/:jobID, or to await on the request's webhook.
 func (h *ChatHandler) SendMessage(c *gin.Context) {
 	var req models.ChatMessageRequest
 
@@ -33,22 +129,625 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
-	// TODO: Implement actual chat logic here
-	response := models.ChatMessageResponse{
-		ID:        "msg_" + generateID(),
-		Message:   "This is a response to: " + req.Message,
-		Timestamp: getCurrentTimestamp(),
-		Status:    "sent",
+	async, err := strconv.ParseBool(c.DefaultQuery("async", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "async must be true or false",
+		})
+		return
+	}
+	if async && h.jobs == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Asynchronous processing is not enabled",
+		})
+		return
+	}
+	if req.WebhookURL != "" && (!async || !validWebhookURL(req.WebhookURL)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "webhook_url must be an http or https URL and requires async=true",
+		})
+		return
 	}
 
+	ctx := c.Request.Context()
+	identity, _ := auth.FromContext(ctx)
+
+	session, path, userMessage, err := h.acceptMessage(ctx, identity, req)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	if async {
+		h.enqueueReply(c, identity, userMessage, req.WebhookURL)
+		return
+	}
+
+	reply, err := h.reply(ctx, session, path, true)
+	if err != nil {
+		respondGenerationError(c, err)
+		return
+	}
+
+	response := newChatMessageResponse(reply, userMessage.ID)
+
 	h.logger.Info("Message sent",
 		logger.F("message_id", response.ID),
-		logger.F("user_message", req.Message),
+		logger.F("session_id", session.ID),
+		logger.F("message_length", len(req.Message)),
 	)
 
 	c.JSON(http.StatusOK, response)
 }
 
+// EditMessage replaces one of the caller's messages with new text. The
+// original is kept: the edit is stored as a sibling branch, answered, and
+// becomes the session's active branch.
+func (h *ChatHandler) EditMessage(c *gin.Context) {
+	var req models.EditMessageRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	identity, _ := auth.FromContext(ctx)
+
+	if err := h.withinQuota(ctx, identity); err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	original, ok := h.ownedMessage(c, identity, c.Param("messageID"))
+	if !ok {
+		return
+	}
+	if original.IsBot {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Only user messages can be edited; regenerate assistant messages instead",
+		})
+		return
+	}
+
+	if err := h.moderate(ctx, identity, original.SessionID, req.Message); err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	session, ok := h.messageSession(c, original)
+	if !ok {
+		return
+	}
+	if err := openSession(session); err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	var path []models.ChatMessage
+	if original.ParentID != nil {
+		var err error
+		if path, err = h.chatRepo.GetPathTo(ctx, *original.ParentID); err != nil {
+			respondInternalError(c)
+			return
+		}
+	}
+
+	edited := &models.ChatMessage{
+		SessionID: original.SessionID,
+		ParentID:  original.ParentID,
+		UserID:    identity.UserID,
+		Message:   req.Message,
+		Timestamp: getCurrentTimestamp(),
+	}
+	if err := h.protect(ctx, edited); err != nil {
+		respondInternalError(c)
+		return
+	}
+	if err := h.chatRepo.CreateMessage(ctx, edited); err != nil {
+		respondInternalError(c)
+		return
+	}
+	h.messageCreated(ctx, edited)
+
+	reply, err := h.reply(ctx, session, append(path, *edited), true)
+	if err != nil {
+		respondGenerationError(c, err)
+		return
+	}
+
+	h.logger.Info("Message edited",
+		logger.F("original_message_id", original.ID),
+		logger.F("message_id", edited.ID),
+		logger.F("session_id", original.SessionID),
+	)
+
+	c.JSON(http.StatusOK, newChatMessageResponse(reply, edited.ID))
+}
+
+// RegenerateMessage produces a new answer to the prompt an assistant message
+// replied to. The new answer is a sibling of the original and becomes the
+// session's active branch.
+func (h *ChatHandler) RegenerateMessage(c *gin.Context) {
+	ctx := c.Request.Context()
+	identity, _ := auth.FromContext(ctx)
+
+	if err := h.withinQuota(ctx, identity); err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	original, ok := h.ownedMessage(c, identity, c.Param("messageID"))
+	if !ok {
+		return
+	}
+	if !original.IsBot || original.ParentID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Only assistant replies can be regenerated",
+		})
+		return
+	}
+
+	session, ok := h.messageSession(c, original)
+	if !ok {
+		return
+	}
+	if err := openSession(session); err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	path, err := h.chatRepo.GetPathTo(ctx, *original.ParentID)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	// A regenerated answer must not come from the cache
+	reply, err := h.reply(ctx, session, path, false)
+	if err != nil {
+		respondGenerationError(c, err)
+		return
+	}
+
+	h.logger.Info("Message regenerated",
+		logger.F("original_message_id", original.ID),
+		logger.F("message_id", reply.ID),
+		logger.F("session_id", original.SessionID),
+	)
+
+	c.JSON(http.StatusOK, newChatMessageResponse(reply, ""))
+}
+
+// acceptMessage admits a message sent to the chat API and stores it in the
+// session it names, or a new one. It returns the session and the
+// conversation up to and including the stored message.
+func (h *ChatHandler) acceptMessage(ctx context.Context, identity auth.Identity, req models.ChatMessageRequest) (*models.ChatSession, []models.ChatMessage, *models.ChatMessage, error) {
+	session, err := h.resolveSession(ctx, identity, req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Moderation flags name the session only once it is known to be the caller's
+	sessionID := ""
+	if session != nil {
+		sessionID = session.ID
+	}
+	if err := h.admit(ctx, identity, sessionID, req.Message); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if session == nil {
+		session, err = h.newSession(ctx, &models.ChatSession{
+			UserID:  identity.UserID,
+			Title:   truncate(req.Message, sessionTitleLength),
+			Channel: models.ChannelAPI,
+		}, req.AssistantID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	path, userMessage, err := h.postMessage(ctx, identity, session, req.Message)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return session, path, userMessage, nil
+}
+
+// admit checks that the caller is within quota and that a new user message
+// passes moderation
+func (h *ChatHandler) admit(ctx context.Context, identity auth.Identity, sessionID, text string) error {
+	if err := h.withinQuota(ctx, identity); err != nil {
+		return err
+	}
+	return h.moderate(ctx, identity, sessionID, text)
+}
+
+// moderate runs a user message through the moderation chain. Rejected
+// messages are recorded and refused with 422 and the reasons. Messages over
+// models.MaxMessageLength are refused with 400 whether moderation is
+// enabled or not.
+func (h *ChatHandler) moderate(ctx context.Context, identity auth.Identity, sessionID, text string) error {
+	if utf8.RuneCountInString(text) > models.MaxMessageLength {
+		return refuse(http.StatusBadRequest, gin.H{
+			"error": "Message must be at most " + strconv.Itoa(models.MaxMessageLength) + " characters",
+		})
+	}
+	if h.moderator == nil {
+		return nil
+	}
+
+	result, err := h.moderator.Check(ctx, text)
+	if err != nil {
+		h.logger.Error("Moderation failed", logger.F("error", err.Error()))
+		return refuse(http.StatusServiceUnavailable, gin.H{
+			"error": "Message moderation is unavailable, please retry later",
+		})
+	}
+	if len(result.Skipped) > 0 {
+		h.logger.Warn("Moderators skipped", logger.F("moderators", result.Skipped))
+	}
+	if result.Allowed {
+		return nil
+	}
+
+	flag := &models.ModerationFlag{
+		UserID:    identity.UserID,
+		SessionID: sessionID,
+		Content:   text,
+		Reasons:   result.Reasons,
+	}
+	if h.redactor != nil {
+		flag.Content = h.redactor.Redact(text)
+	}
+	if err := h.moderationRepo.RecordFlag(ctx, flag); err != nil {
+		return err
+	}
+	h.publish(ctx, webhooks.EventModerationFlagged, flag.UserID, flag)
+
+	return refuse(http.StatusUnprocessableEntity, gin.H{
+		"error":   "Message rejected by moderation",
+		"flag_id": flag.ID,
+		"reasons": result.Reasons,
+	})
+}
+
+// withinQuota checks that the caller's user, key and tenant have tokens left.
+// When a quota is used up it returns the *usage.QuotaError.
+func (h *ChatHandler) withinQuota(ctx context.Context, identity auth.Identity) error {
+	if h.quota == nil {
+		return nil
+	}
+
+	err := h.quota.Allow(ctx, identity)
+	var exceeded *usage.QuotaError
+	if err != nil && !errors.As(err, &exceeded) {
+		h.logger.Error("Failed to check usage quota", logger.F("error", err.Error()))
+	}
+	return err
+}
+
+// postMessage stores a user message at the end of the session's active
+// branch and returns the conversation up to and including it
+func (h *ChatHandler) postMessage(ctx context.Context, identity auth.Identity, session *models.ChatSession, text string) ([]models.ChatMessage, *models.ChatMessage, error) {
+	path, err := h.chatRepo.GetActivePath(ctx, session.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userMessage := &models.ChatMessage{
+		SessionID: session.ID,
+		ParentID:  session.ActiveMessageID,
+		UserID:    identity.UserID,
+		Message:   text,
+		Timestamp: getCurrentTimestamp(),
+	}
+	if err := h.protect(ctx, userMessage); err != nil {
+		return nil, nil, err
+	}
+	if err := h.chatRepo.CreateMessage(ctx, userMessage); err != nil {
+		return nil, nil, err
+	}
+	h.messageCreated(ctx, userMessage)
+	return append(path, *userMessage), userMessage, nil
+}
+
+// protect redacts personal data from a message before it is stored. In vault
+// mode the original is sealed for the message's ID and organization and
+// stored alongside it, so the ID is assigned here rather than on create.
+func (h *ChatHandler) protect(ctx context.Context, message *models.ChatMessage) error {
+	if message.ID == "" {
+		message.ID = models.NewID()
+	}
+	orgID, _ := auth.OrganizationID(ctx)
+	redacted, sealed, err := redact.Protect(h.redactor, h.vault, message.Message, message.ID, orgID)
+	if err != nil {
+		h.logger.Error("Failed to seal message original", logger.F("error", err.Error()))
+		return err
+	}
+	if sealed != "" {
+		message.Vault = &models.MessageVault{Ciphertext: sealed}
+	}
+	message.Message = redacted
+	return nil
+}
+
+// reply generates an answer to the conversation in path, stores it as a child
+// of the last message and makes it the session's active leaf. With cacheable
+// set, assistants that opted in may answer from the response cache, and the
+// opening question of a session from the semantic cache. Later questions
+// depend on the conversation before them, so they are never matched by
+// similarity alone.
+func (h *ChatHandler) reply(ctx context.Context, session *models.ChatSession, path []models.ChatMessage, cacheable bool) (*models.ChatMessage, error) {
+	return h.generate(ctx, session, path, cacheable, nil)
+}
+
+// streamReply is reply, passing the answer to onDelta while it is generated.
+// A reply that is cached or redacted is only known once it is complete, so
+// for those onDelta is never called; the caller sends the stored reply.
+func (h *ChatHandler) streamReply(ctx context.Context, session *models.ChatSession, path []models.ChatMessage, onDelta func(string) error) (*models.ChatMessage, error) {
+	return h.generate(ctx, session, path, true, onDelta)
+}
+
+// generate implements reply and streamReply
+func (h *ChatHandler) generate(ctx context.Context, session *models.ChatSession, path []models.ChatMessage, cacheable bool, onDelta func(string) error) (*models.ChatMessage, error) {
+	if len(path) == 0 {
+		return nil, llm.ErrEmptyConversation
+	}
+	parent := path[len(path)-1]
+
+	assistant, err := h.sessionAssistant(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := toLLMMessages(path)
+	system, err := h.systemPrompt(ctx, session, assistant)
+	if err != nil {
+		return nil, err
+	}
+	if system != "" {
+		messages = append([]llm.Message{{Role: llm.RoleSystem, Content: system}}, messages...)
+	}
+
+	req := llm.Request{
+		Messages:    messages,
+		Temperature: defaultTemperature,
+	}
+	if assistant != nil {
+		req.Provider = assistant.Provider
+		req.Model = assistant.Model
+		req.Fallbacks = assistant.Fallbacks
+		req.Temperature = assistant.Temperature
+	}
+
+	var (
+		resp   *llm.Response
+		cached bool
+	)
+	switch {
+	case cacheable && h.semantic != nil && assistant != nil && assistant.SemanticCache && len(path) == 1:
+		resp, cached, err = h.semanticComplete(ctx, session, assistant, req, parent.Message)
+	case cacheable && h.cache != nil && assistant != nil && assistant.CacheResponses:
+		resp, cached, err = h.cache.Complete(ctx, h.provider, req)
+	case onDelta != nil && h.redactor == nil:
+		resp, err = llm.Stream(ctx, h.provider, req, onDelta)
+	default:
+		resp, err = h.provider.Complete(ctx, req)
+	}
+	if err != nil {
+		h.logger.Error("Failed to generate reply",
+			logger.F("session_id", session.ID),
+			logger.F("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	reply := &models.ChatMessage{
+		SessionID: session.ID,
+		ParentID:  &parent.ID,
+		UserID:    parent.UserID,
+		Message:   resp.Content,
+		Timestamp: getCurrentTimestamp(),
+		IsBot:     true,
+		Model:     resp.Model,
+		Cached:    cached,
+	}
+	if err := h.protect(ctx, reply); err != nil {
+		return nil, err
+	}
+	if err := h.chatRepo.CreateMessage(ctx, reply); err != nil {
+		return nil, err
+	}
+	if err := h.chatRepo.SetActiveMessage(ctx, session.ID, reply.ID); err != nil {
+		return nil, err
+	}
+	h.messageCreated(ctx, reply)
+	return reply, nil
+}
+
+// semanticComplete answers question through the semantic cache, scoped to
+// the assistant's configuration, the rendered system prompt and the current
+// versions of its knowledge bases. If those versions cannot be read the provider is used directly.
+func (h *ChatHandler) semanticComplete(ctx context.Context, session *models.ChatSession, assistant *models.Assistant, req llm.Request, question string) (*llm.Response, bool, error) {
+	versions, err := h.knowledgeRepo.KnowledgeBaseVersions(ctx, assistant.KnowledgeBases)
+	if err != nil {
+		resp, err := h.provider.Complete(ctx, req)
+		return resp, false, err
+	}
+
+	var promptID, system string
+	if session.PromptID != nil {
+		promptID = *session.PromptID
+	}
+	for _, m := range req.Messages {
+		if m.Role == llm.RoleSystem {
+			system += m.Content
+		}
+	}
+	scope := cache.NewScope(assistant, promptID, system, versions)
+	return h.semantic.Complete(ctx, h.provider, req, scope, question, assistant.SemanticThreshold)
+}
+
+// sessionAssistant loads the assistant a session was started with, if any.
+// A deleted assistant is treated as none.
+func (h *ChatHandler) sessionAssistant(ctx context.Context, session *models.ChatSession) (*models.Assistant, error) {
+	if session.AssistantID == nil {
+		return nil, nil
+	}
+	return h.assistantRepo.GetAssistantByID(ctx, *session.AssistantID)
+}
+
+// systemPrompt renders the prompt template version recorded on the session,
+// so a session keeps the prompt it started with after newer versions are
+// promoted. Sessions without a template use their assistant's system prompt.
+func (h *ChatHandler) systemPrompt(ctx context.Context, session *models.ChatSession, assistant *models.Assistant) (string, error) {
+	if session.PromptID == nil {
+		if assistant != nil {
+			return assistant.SystemPrompt, nil
+		}
+		return "", nil
+	}
+
+	tpl, err := h.promptRepo.GetByID(ctx, *session.PromptID)
+	if err != nil {
+		return "", err
+	}
+	if tpl == nil {
+		h.logger.Warn("Session prompt template no longer exists",
+			logger.F("session_id", session.ID),
+			logger.F("prompt_id", *session.PromptID),
+		)
+		return "", nil
+	}
+
+	rendered, err := prompts.Render(tpl, map[string]string{
+		"user_id":    session.UserID,
+		"session_id": session.ID,
+		"date":       time.Now().UTC().Format("2006-01-02"),
+	})
+	if err != nil {
+		h.logger.Error("Failed to render system prompt",
+			logger.F("session_id", session.ID),
+			logger.F("error", err.Error()),
+		)
+		return "", err
+	}
+	return rendered, nil
+}
+
+// messageSession loads the session a message belongs to. It writes an error
+// response and reports false if it cannot.
+func (h *ChatHandler) messageSession(c *gin.Context, message *models.ChatMessage) (*models.ChatSession, bool) {
+	session, err := h.chatRepo.GetSessionByID(c.Request.Context(), message.SessionID)
+	if err != nil {
+		respondInternalError(c)
+		return nil, false
+	}
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Session not found",
+		})
+		return nil, false
+	}
+	return session, true
+}
+
+// ownedMessage loads a message sent in one of the caller's sessions. It
+// writes a 404 response and reports false if there is no such message.
+func (h *ChatHandler) ownedMessage(c *gin.Context, identity auth.Identity, messageID string) (*models.ChatMessage, bool) {
+	message, err := h.chatRepo.GetMessageByID(c.Request.Context(), messageID)
+	if err != nil {
+		respondInternalError(c)
+		return nil, false
+	}
+	if message == nil || message.UserID != identity.UserID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Message not found",
+		})
+		return nil, false
+	}
+	return message, true
+}
+
+// resolveSession loads the session named in the request and checks that the
+// caller may continue it. Without a session ID it returns nil: the message
+// starts a new session once it has been admitted.
+func (h *ChatHandler) resolveSession(ctx context.Context, identity auth.Identity, req models.ChatMessageRequest) (*models.ChatSession, error) {
+	if req.SessionID == "" {
+		return nil, nil
+	}
+
+	session, err := h.chatRepo.GetSessionByID(ctx, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserID != identity.UserID {
+		return nil, refuse(http.StatusNotFound, gin.H{
+			"error": "Session not found",
+		})
+	}
+	if req.AssistantID != "" && (session.AssistantID == nil || *session.AssistantID != req.AssistantID) {
+		return nil, refuse(http.StatusBadRequest, gin.H{
+			"error": "The assistant of an existing session cannot be changed",
+		})
+	}
+	if err := openSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// newSession starts session with the given assistant, or none, recording the
+// prompt version it starts with
+func (h *ChatHandler) newSession(ctx context.Context, session *models.ChatSession, assistantID string) (*models.ChatSession, error) {
+	session.IsActive = true
+
+	promptName := h.config.SystemPrompt
+	if assistantID != "" {
+		assistant, err := h.assistantRepo.GetAssistantByID(ctx, assistantID)
+		if err != nil {
+			return nil, err
+		}
+		if assistant == nil {
+			return nil, refuse(http.StatusNotFound, gin.H{
+				"error": "Assistant not found",
+			})
+		}
+		session.AssistantID = &assistant.ID
+
+		// An assistant's own prompt replaces the deployment default
+		switch {
+		case assistant.PromptName != "":
+			promptName = assistant.PromptName
+		case assistant.SystemPrompt != "":
+			promptName = ""
+		}
+	}
+
+	// Record the prompt version the session starts with
+	if promptName != "" {
+		tpl, err := h.promptRepo.GetActive(ctx, promptName)
+		if err != nil {
+			return nil, err
+		}
+		if tpl != nil {
+			session.PromptName = tpl.Name
+			session.PromptID = &tpl.ID
+			session.PromptVersion = tpl.Version
+		}
+	}
+
+	if err := h.chatRepo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
 // GetChatHistory retrieves chat history
 func (h *ChatHandler) GetChatHistory(c *gin.Context) {
 	userID := c.Param("userID")
@@ -60,22 +759,22 @@ func (h *ChatHandler) GetChatHistory(c *gin.Context) {
 		return
 	}
 
-	// TODO: Implement actual database query
-	history := []models.ChatMessage{
-		{
-			ID:        "msg_1",
-			UserID:    userID,
-			Message:   "Hello, how can I help you?",
-			Timestamp: getCurrentTimestamp(),
-			IsBot:     true,
-		},
-		{
-			ID:        "msg_2",
-			UserID:    userID,
-			Message:   "I need help with my account",
-			Timestamp: getCurrentTimestamp(),
-			IsBot:     false,
-		},
+	if !authorizeSelfOr(c, userID, auth.PermReadAnyHistory) {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultHistoryLimit)))
+	if err != nil || limit < 1 || limit > maxHistoryLimit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "limit must be between 1 and " + strconv.Itoa(maxHistoryLimit),
+		})
+		return
+	}
+
+	history, err := h.chatRepo.GetMessagesByUserID(c.Request.Context(), userID, limit)
+	if err != nil {
+		respondInternalError(c)
+		return
 	}
 
 	h.logger.Info("Chat history retrieved",
@@ -90,7 +789,61 @@ func (h *ChatHandler) GetChatHistory(c *gin.Context) {
 	})
 }
 
-// DeleteMessage deletes a specific message
+// RevealMessage returns the original text of a message that was stored with
+// personal data redacted
+func (h *ChatHandler) RevealMessage(c *gin.Context) {
+	if h.vault == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Redaction vault is not enabled",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	identity, _ := auth.FromContext(ctx)
+	messageID := c.Param("messageID")
+
+	entry, err := h.chatRepo.GetMessageVault(ctx, messageID)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	if entry == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No original stored for this message",
+		})
+		return
+	}
+
+	original, err := h.vault.Open(entry.Ciphertext, entry.MessageID, entry.OrganizationID)
+	if err != nil {
+		h.logger.Error("Failed to open message original",
+			logger.F("message_id", messageID),
+			logger.F("error", err.Error()),
+		)
+		respondInternalError(c)
+		return
+	}
+
+	h.logger.Info("Redacted message revealed",
+		logger.F("message_id", messageID),
+		logger.F("revealed_by", identity.UserID),
+	)
+	// Failures are logged by the repository; the original has been read either way
+	_ = h.auditRepo.Record(ctx, &models.AuditEvent{
+		Action:     audit.ActionMessageReveal,
+		TargetType: "message",
+		TargetID:   messageID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message_id": messageID,
+		"message":    original,
+	})
+}
+
+// DeleteMessage soft-deletes a specific message. Messages that are missing or
+// belong to someone else are reported as not found.
 func (h *ChatHandler) DeleteMessage(c *gin.Context) {
 	messageID := c.Param("messageID")
 
@@ -101,23 +854,243 @@ func (h *ChatHandler) DeleteMessage(c *gin.Context) {
 		return
 	}
 
-	// TODO: Implement actual deletion logic
-	h.logger.Info("Message deleted", logger.F("message_id", messageID))
+	ctx := c.Request.Context()
+	identity, _ := auth.FromContext(ctx)
+
+	if err := h.chatRepo.DeleteMessage(ctx, messageID, ownerFilter(identity, auth.PermDeleteAnyMessage)); err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	h.logger.Info("Message deleted",
+		logger.F("message_id", messageID),
+		logger.F("deleted_by", identity.UserID),
+	)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "Message deleted successfully",
+		"message":          "Message deleted successfully",
+		"message_id":       messageID,
+		"restorable_until": time.Now().UTC().Add(h.restoreWindow()).Format(time.RFC3339),
+	})
+}
+
+// RestoreMessage undoes a deletion within the configured restore window
+func (h *ChatHandler) RestoreMessage(c *gin.Context) {
+	messageID := c.Param("messageID")
+
+	ctx := c.Request.Context()
+	identity, _ := auth.FromContext(ctx)
+
+	err := h.chatRepo.RestoreMessage(ctx, messageID, ownerFilter(identity, auth.PermDeleteAnyMessage), h.restoreWindow())
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	h.logger.Info("Message restored",
+		logger.F("message_id", messageID),
+		logger.F("restored_by", identity.UserID),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Message restored successfully",
 		"message_id": messageID,
 	})
 }
 
+func (h *ChatHandler) restoreWindow() time.Duration {
+	return time.Duration(h.config.RestoreWindow) * time.Second
+}
+
+// GetSessionHistory returns the active branch of a session, oldest first,
+// with the sibling branches available at each turn
+func (h *ChatHandler) GetSessionHistory(c *gin.Context) {
+	session, ok := h.sessionFor(c, auth.PermReadAnyHistory)
+	if !ok {
+		return
+	}
+
+	history, err := h.chatRepo.GetSessionHistory(c.Request.Context(), session.ID)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id":        session.ID,
+		"active_message_id": session.ActiveMessageID,
+		"messages":          history,
+		"total":             len(history),
+	})
+}
+
+// SelectBranch switches a session to the branch containing the given message
+func (h *ChatHandler) SelectBranch(c *gin.Context) {
+	var req models.SelectBranchRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	session, ok := h.sessionFor(c, "")
+	if !ok {
+		return
+	}
+
+	leaf, err := h.chatRepo.SelectBranch(c.Request.Context(), session.ID, req.MessageID)
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id":        session.ID,
+		"active_message_id": leaf,
+	})
+}
+
+// sessionFor loads the session named in the path. The caller must own it or
+// hold perm; an empty perm requires ownership. It writes a 404 response and
+// reports false otherwise.
+func (h *ChatHandler) sessionFor(c *gin.Context, perm auth.Permission) (*models.ChatSession, bool) {
+	ctx := c.Request.Context()
+	identity, _ := auth.FromContext(ctx)
+
+	session, err := h.findSession(ctx, identity, c.Param("sessionID"), perm)
+	if err != nil {
+		respondChatError(c, err)
+		return nil, false
+	}
+	return session, true
+}
+
+// findSession loads a session the caller owns or, when perm is not empty,
+// may access through perm. Other sessions are refused as not found.
+func (h *ChatHandler) findSession(ctx context.Context, identity auth.Identity, sessionID string, perm auth.Permission) (*models.ChatSession, error) {
+	session, err := h.chatRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || !(session.UserID == identity.UserID || (perm != "" && identity.Can(perm))) {
+		return nil, refuse(http.StatusNotFound, gin.H{
+			"error": "Session not found",
+		})
+	}
+	return session, nil
+}
+
 // Helper functions
-func generateID() string {
-	// TODO: Implement proper ID generation (UUID, etc.)
-	return "12345"
+func newChatMessageResponse(reply *models.ChatMessage, userMessageID string) models.ChatMessageResponse {
+	response := models.ChatMessageResponse{
+		ID:            reply.ID,
+		SessionID:     reply.SessionID,
+		UserMessageID: userMessageID,
+		Message:       reply.Message,
+		Timestamp:     reply.Timestamp,
+		Status:        "sent",
+		Cached:        reply.Cached,
+	}
+	if reply.ParentID != nil {
+		response.ParentID = *reply.ParentID
+	}
+	return response
+}
+
+func toLLMMessages(path []models.ChatMessage) []llm.Message {
+	messages := make([]llm.Message, len(path))
+	for i, m := range path {
+		role := llm.RoleUser
+		if m.IsBot {
+			role = llm.RoleAssistant
+		}
+		messages[i] = llm.Message{Role: role, Content: m.Message}
+	}
+	return messages
+}
+
+// requestError is a chat request refused for a reason the caller can act
+// on. status and body are what the REST API responds with; channels relay
+// the message in body["error"].
+type requestError struct {
+	status int
+	body   gin.H
+}
+
+func refuse(status int, body gin.H) *requestError {
+	return &requestError{status: status, body: body}
+}
+
+func (e *requestError) Error() string {
+	message, _ := e.body["error"].(string)
+	return message
+}
+
+// respondChatError answers a refused request with its status and body, a
+// used-up quota with 429, and anything else as an internal error
+func respondChatError(c *gin.Context, err error) {
+	var (
+		refused  *requestError
+		exceeded *usage.QuotaError
+	)
+	switch {
+	case errors.As(err, &refused):
+		c.JSON(refused.status, refused.body)
+	case errors.As(err, &exceeded):
+		respondQuotaExceeded(c, exceeded)
+	default:
+		respondInternalError(c)
+	}
+}
+
+func respondGenerationError(c *gin.Context, err error) {
+	if errors.Is(err, llm.ErrEmptyConversation) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "There is no message to respond to",
+		})
+		return
+	}
+	c.JSON(http.StatusBadGateway, gin.H{
+		"error": "Failed to generate a response",
+	})
 }
 
 func getCurrentTimestamp() string {
-	// TODO: Use proper timestamp format
-	return "2024-01-01T12:00:00Z"
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// ownerFilter returns the owner a repository operation should be restricted
+// to: the caller, or nobody when the caller holds perm
+func ownerFilter(identity auth.Identity, perm auth.Permission) string {
+	if identity.Can(perm) {
+		return ""
+	}
+	return identity.UserID
 }
 
+func respondMessageError(c *gin.Context, err error) {
+	if errors.Is(err, database.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Message not found",
+		})
+		return
+	}
+	respondInternalError(c)
+}
+
+func respondInternalError(c *gin.Context) {
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": "Internal server error",
+	})
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
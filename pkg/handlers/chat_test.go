@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+func TestOwnerFilter(t *testing.T) {
+	user := auth.Identity{UserID: "user-1", Role: auth.RoleUser}
+	admin := auth.Identity{UserID: "admin-1", Role: auth.RoleAdmin}
+
+	// Users may only delete and restore their own messages
+	assert.Equal(t, "user-1", ownerFilter(user, auth.PermDeleteAnyMessage))
+	assert.Equal(t, "", ownerFilter(admin, auth.PermDeleteAnyMessage))
+}
+
+func TestModerateLimitsLengthWithoutModerator(t *testing.T) {
+	h := &ChatHandler{}
+	identity := auth.Identity{UserID: "user-1", OrganizationID: "org-1", Role: auth.RoleUser}
+
+	assert.NoError(t, h.moderate(context.Background(), identity, "", strings.Repeat("a", models.MaxMessageLength)))
+
+	err := h.moderate(context.Background(), identity, "", strings.Repeat("a", models.MaxMessageLength+1))
+	var refused *requestError
+	require.True(t, errors.As(err, &refused))
+	assert.Equal(t, http.StatusBadRequest, refused.status)
+}
+
+func TestResolveSessionWithoutID(t *testing.T) {
+	// A new session is only started once the message has been admitted
+	session, err := (&ChatHandler{}).resolveSession(context.Background(), auth.Identity{UserID: "user-1"}, models.ChatMessageRequest{Message: "Hi"})
+	assert.NoError(t, err)
+	assert.Nil(t, session)
+}
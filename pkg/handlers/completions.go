@@ -0,0 +1,460 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/transcript"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/usage"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// streamChunkSize is roughly how many bytes each streamed part carries
+	streamChunkSize = 32
+	// streamKeepAlive is how often a stream waiting for its reply sends a
+	// comment, so proxies do not close it as idle
+	streamKeepAlive = 10 * time.Second
+)
+
+// ChatCompletions answers an OpenAI-compatible chat completions request,
+// so existing SDKs and tools can talk to an assistant. The last message is
+// sent as with SendMessage, through the same quota, moderation, storage and
+// generation. OpenAI's API is stateless: without a session_id a request
+// continues the user's session whose conversation matches the earlier
+// messages, or else they seed a new session. System messages are ignored in
+// favour of the assistant's own prompt.
+//
+// With stream set the reply is sent as server-sent events while it is
+// generated, unless it is redacted or answered through a cache.
+func (h *ChatHandler) ChatCompletions(c *gin.Context) {
+	var req models.ChatCompletionRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, openAIError("Invalid request body: "+err.Error(), "invalid_request_error"))
+		return
+	}
+	turns, err := completionTurns(req.Messages)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, openAIError(err.Error(), "invalid_request_error"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	identity, _ := auth.FromContext(ctx)
+
+	session, path, err := h.openCompletion(ctx, identity, req.Model, req.SessionID, turns)
+	if err != nil {
+		respondCompletionError(c, err)
+		return
+	}
+	c.Header("X-Session-ID", session.ID)
+
+	if req.Stream {
+		h.streamCompletion(c, req.Model, session, path, turns)
+		return
+	}
+
+	reply, err := h.reply(ctx, session, path, true)
+	if err != nil {
+		c.JSON(generationFailure(err))
+		return
+	}
+	h.recordConversation(ctx, session, turns, reply)
+
+	stop := "stop"
+	c.JSON(http.StatusOK, models.ChatCompletion{
+		ID:      "chatcmpl-" + reply.ID,
+		Object:  models.ObjectChatCompletion,
+		Created: reply.CreatedAt.Unix(),
+		Model:   req.Model,
+		Choices: []models.ChatCompletionChoice{{
+			Message:      &models.ChatCompletionDelta{Role: string(llm.RoleAssistant), Content: reply.Message},
+			FinishReason: &stop,
+		}},
+		SessionID: session.ID,
+	})
+}
+
+// ListModels lists the organization's assistants as the models of the
+// OpenAI-compatible API
+func (h *ChatHandler) ListModels(c *gin.Context) {
+	assistants, err := h.assistantRepo.ListAssistants(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, openAIError("Internal server error", "server_error"))
+		return
+	}
+
+	data := make([]models.CompletionModel, 0, len(assistants))
+	for _, a := range assistants {
+		data = append(data, models.CompletionModel{
+			ID:      a.Name,
+			Object:  models.ObjectModel,
+			Created: a.CreatedAt.Unix(),
+			OwnedBy: a.OrganizationID,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"object": models.ObjectList,
+		"data":   data,
+	})
+}
+
+// completionTurns reads the conversation of a request. System and developer
+// messages are dropped; the last message must be the user's.
+func completionTurns(messages []models.ChatCompletionMessage) ([]llm.Message, error) {
+	var turns []llm.Message
+	for i, m := range messages {
+		text, err := transcript.ContentText(m.Content)
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+		switch llm.Role(m.Role) {
+		case llm.RoleUser, llm.RoleAssistant:
+			turns = append(turns, llm.Message{Role: llm.Role(m.Role), Content: text})
+		case llm.RoleSystem, "developer":
+		default:
+			return nil, fmt.Errorf("messages[%d]: role %q is not supported", i, m.Role)
+		}
+	}
+	if len(turns) == 0 || turns[len(turns)-1].Role != llm.RoleUser {
+		return nil, errors.New("the last message must be from the user")
+	}
+	if strings.TrimSpace(turns[len(turns)-1].Content) == "" {
+		return nil, errors.New("the last message has no text")
+	}
+	return turns, nil
+}
+
+// openCompletion admits the last turn of a conversation and stores it in the
+// session it continues, or in a new session with the given model's
+// assistant seeded with the turns before it. Without a session ID the
+// session is the user's open one whose conversation digest matches those
+// turns, if any. It returns the session and the conversation to answer.
+func (h *ChatHandler) openCompletion(ctx context.Context, identity auth.Identity, model, sessionID string, turns []llm.Message) (*models.ChatSession, []models.ChatMessage, error) {
+	assistant, err := h.modelAssistant(ctx, model)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	text := turns[len(turns)-1].Content
+	history := turns[:len(turns)-1]
+
+	if sessionID == "" && len(history) > 0 {
+		known, err := h.chatRepo.GetSessionByConversation(ctx, identity.UserID, assistant.ID, conversationDigest(history))
+		if err != nil {
+			return nil, nil, err
+		}
+		if known != nil {
+			sessionID = known.ID
+		}
+	}
+
+	// The session holds the conversation; the turns sent with it are not
+	// stored again
+	session, err := h.resolveSession(ctx, identity, models.ChatMessageRequest{
+		SessionID:   sessionID,
+		AssistantID: assistant.ID,
+		Message:     text,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if session != nil {
+		sessionID = session.ID
+	}
+	if err := h.admit(ctx, identity, sessionID, text); err != nil {
+		return nil, nil, err
+	}
+
+	if session == nil {
+		if session, err = h.seedSession(ctx, identity, assistant.ID, history, text); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	path, _, err := h.postMessage(ctx, identity, session, text)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, path, nil
+}
+
+// modelAssistant loads the assistant a model names, by ID or name
+func (h *ChatHandler) modelAssistant(ctx context.Context, model string) (*models.Assistant, error) {
+	assistant, err := h.assistantRepo.GetAssistantByID(ctx, model)
+	if err == nil && assistant == nil {
+		assistant, err = h.assistantRepo.GetAssistantByName(ctx, model)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if assistant == nil {
+		return nil, refuse(http.StatusNotFound, gin.H{
+			"error": "The model `" + model + "` does not exist",
+		})
+	}
+	return assistant, nil
+}
+
+// seedSession starts a session with the assistant and stores the turns of
+// the conversation before text in it. The user's earlier turns pass
+// moderation like the new one.
+func (h *ChatHandler) seedSession(ctx context.Context, identity auth.Identity, assistantID string, history []llm.Message, text string) (*models.ChatSession, error) {
+	title := text
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role != llm.RoleUser {
+			continue
+		}
+		if err := h.moderate(ctx, identity, "", history[i].Content); err != nil {
+			return nil, err
+		}
+		title = history[i].Content
+	}
+
+	session, err := h.newSession(ctx, &models.ChatSession{
+		UserID:  identity.UserID,
+		Title:   truncate(title, sessionTitleLength),
+		Channel: models.ChannelAPI,
+	}, assistantID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, turn := range history {
+		message := &models.ChatMessage{
+			SessionID: session.ID,
+			ParentID:  session.ActiveMessageID,
+			UserID:    identity.UserID,
+			Message:   turn.Content,
+			Timestamp: getCurrentTimestamp(),
+			IsBot:     turn.Role == llm.RoleAssistant,
+		}
+		if err := h.protect(ctx, message); err != nil {
+			return nil, err
+		}
+		if err := h.chatRepo.CreateMessage(ctx, message); err != nil {
+			return nil, err
+		}
+		session.ActiveMessageID = &message.ID
+	}
+	if session.ActiveMessageID != nil {
+		if err := h.chatRepo.SetActiveMessage(ctx, session.ID, *session.ActiveMessageID); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// recordConversation stores the digest of turns followed by reply on the
+// session, so a client that sends the conversation back without a session
+// ID continues it. Failing to store it only costs that continuity.
+func (h *ChatHandler) recordConversation(ctx context.Context, session *models.ChatSession, turns []llm.Message, reply *models.ChatMessage) {
+	conversation := append(turns[:len(turns):len(turns)], llm.Message{Role: llm.RoleAssistant, Content: reply.Message})
+	if err := h.chatRepo.SetConversationDigest(ctx, session.ID, conversationDigest(conversation)); err != nil {
+		h.logger.Warn("Failed to record conversation digest",
+			logger.F("session_id", session.ID),
+			logger.F("error", err.Error()),
+		)
+	}
+}
+
+// conversationDigest hashes the roles and text of a conversation. Each field
+// is length-prefixed so that no two conversations run together alike.
+func conversationDigest(turns []llm.Message) string {
+	hash := sha256.New()
+	for _, turn := range turns {
+		fmt.Fprintf(hash, "%d:%s%d:%s", len(turn.Role), turn.Role, len(turn.Content), turn.Content)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// streamCompletion answers the conversation in path with server-sent
+// events: the reply in parts as it is generated, then [DONE], with a comment
+// every streamKeepAlive while waiting. Replies that are redacted or answered
+// through a cache are only known once complete, so those are sent in parts
+// afterwards. An error after the stream has started is sent as an event.
+func (h *ChatHandler) streamCompletion(c *gin.Context, model string, session *models.ChatSession, path []models.ChatMessage, turns []llm.Message) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	var (
+		reply  *models.ChatMessage
+		err    error
+		done   = make(chan struct{})
+		deltas = make(chan string)
+	)
+	go func() {
+		defer close(done)
+		reply, err = h.streamReply(ctx, session, path, func(delta string) error {
+			select {
+			case deltas <- delta:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	// The reply's ID is only known once it is stored
+	id := "chatcmpl-" + models.NewID()
+	created := time.Now().Unix()
+	chunk := func(delta models.ChatCompletionDelta, finish *string) models.ChatCompletion {
+		return models.ChatCompletion{
+			ID:        id,
+			Object:    models.ObjectChatCompletionChunk,
+			Created:   created,
+			Model:     model,
+			Choices:   []models.ChatCompletionChoice{{Delta: &delta, FinishReason: finish}},
+			SessionID: session.ID,
+		}
+	}
+	var started bool
+	begin := func() {
+		if !started {
+			started = true
+			writeEvent(c, chunk(models.ChatCompletionDelta{Role: string(llm.RoleAssistant)}, nil))
+		}
+	}
+	send := func(part string) {
+		begin()
+		writeEvent(c, chunk(models.ChatCompletionDelta{Content: part}, nil))
+	}
+
+	ticker := time.NewTicker(streamKeepAlive)
+	defer ticker.Stop()
+	for waiting := true; waiting; {
+		select {
+		case delta := <-deltas:
+			send(delta)
+		case <-done:
+			waiting = false
+		case <-ticker.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+			c.Writer.Flush()
+		}
+	}
+
+	if err != nil {
+		_, body := generationFailure(err)
+		writeEvent(c, body)
+		return
+	}
+	h.recordConversation(ctx, session, turns, reply)
+
+	streamed := started
+	if !streamed {
+		for _, part := range streamChunks(reply.Message) {
+			send(part)
+		}
+	}
+	begin()
+	stop := "stop"
+	writeEvent(c, chunk(models.ChatCompletionDelta{}, &stop))
+	fmt.Fprint(c.Writer, "data: [DONE]\n\n")
+	c.Writer.Flush()
+
+	h.logger.Info("Completion streamed",
+		logger.F("message_id", reply.ID),
+		logger.F("session_id", session.ID),
+		logger.F("streamed", streamed),
+	)
+}
+
+// writeEvent sends v as a server-sent event
+func writeEvent(c *gin.Context, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(c.Writer, "data: %s\n\n", data)
+	c.Writer.Flush()
+}
+
+// streamChunks splits a reply into the parts it is streamed in. Parts break
+// after whitespace, so they join to the reply exactly.
+func streamChunks(text string) []string {
+	var chunks []string
+	for len(text) > streamChunkSize {
+		i := strings.IndexAny(text[streamChunkSize:], " \t\n")
+		if i < 0 {
+			break
+		}
+		cut := streamChunkSize + i + 1
+		chunks = append(chunks, text[:cut])
+		text = text[cut:]
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+// respondCompletionError answers in the error format of OpenAI's API, which
+// SDKs raise as their own error types
+func respondCompletionError(c *gin.Context, err error) {
+	var exceeded *usage.QuotaError
+	if errors.As(err, &exceeded) {
+		c.Header("Retry-After", strconv.Itoa(int(time.Until(exceeded.ResetAt).Seconds())+1))
+	}
+	c.JSON(completionError(err))
+}
+
+// completionError returns the status and OpenAI-style body for err: refused
+// requests keep their status, a used-up quota is 429, and anything else is
+// an internal error
+func completionError(err error) (int, gin.H) {
+	var (
+		refused  *requestError
+		exceeded *usage.QuotaError
+	)
+	switch {
+	case errors.As(err, &refused):
+		errType := "invalid_request_error"
+		if refused.status >= http.StatusInternalServerError {
+			errType = "server_error"
+		}
+		return refused.status, openAIError(refused.Error(), errType)
+	case errors.As(err, &exceeded):
+		return http.StatusTooManyRequests, openAIError("Token quota exceeded", "insufficient_quota")
+	default:
+		return http.StatusInternalServerError, openAIError("Internal server error", "server_error")
+	}
+}
+
+// generationFailure returns the status and OpenAI-style body for a reply
+// that could not be generated, as respondGenerationError does for the REST
+// API
+func generationFailure(err error) (int, gin.H) {
+	if errors.Is(err, llm.ErrEmptyConversation) {
+		return http.StatusBadRequest, openAIError("There is no message to respond to", "invalid_request_error")
+	}
+	return http.StatusBadGateway, openAIError("Failed to generate a response", "server_error")
+}
+
+func openAIError(message, errType string) gin.H {
+	return gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    errType,
+			"param":   nil,
+			"code":    nil,
+		},
+	}
+}
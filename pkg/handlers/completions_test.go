@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/config"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/usage"
+)
+
+func TestCompletionTurns(t *testing.T) {
+	turns, err := completionTurns([]models.ChatCompletionMessage{
+		{Role: "system", Content: json.RawMessage(`"You are a pirate"`)},
+		{Role: "user", Content: json.RawMessage(`"Hello"`)},
+		{Role: "assistant", Content: json.RawMessage(`"Hi there"`)},
+		{Role: "user", Content: json.RawMessage(`[{"type":"text","text":"What time is it?"}]`)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []llm.Message{
+		{Role: llm.RoleUser, Content: "Hello"},
+		{Role: llm.RoleAssistant, Content: "Hi there"},
+		{Role: llm.RoleUser, Content: "What time is it?"},
+	}, turns)
+
+	_, err = completionTurns([]models.ChatCompletionMessage{{Role: "tool", Content: json.RawMessage(`"42"`)}})
+	assert.Error(t, err)
+	_, err = completionTurns([]models.ChatCompletionMessage{
+		{Role: "user", Content: json.RawMessage(`"Hello"`)},
+		{Role: "assistant", Content: json.RawMessage(`"Hi"`)},
+	})
+	assert.Error(t, err)
+	_, err = completionTurns([]models.ChatCompletionMessage{{Role: "user", Content: json.RawMessage(`" "`)}})
+	assert.Error(t, err)
+}
+
+func TestChatCompletionsValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewChatHandler(ChatDependencies{}, &config.ChatConfig{}, logger.NewLogrusLogger("error", "text"))
+	router := gin.New()
+	router.POST("/v1/chat/completions", h.ChatCompletions)
+
+	for name, body := range map[string]string{
+		"missing model":   `{"messages":[{"role":"user","content":"hi"}]}`,
+		"no messages":     `{"model":"support","messages":[]}`,
+		"assistant last":  `{"model":"support","messages":[{"role":"assistant","content":"hi"}]}`,
+		"invalid content": `{"model":"support","messages":[{"role":"user","content":42}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(body))
+			require.NoError(t, err)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var resp struct {
+				Error struct {
+					Message string `json:"message"`
+					Type    string `json:"type"`
+				} `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, "invalid_request_error", resp.Error.Type)
+			assert.NotEmpty(t, resp.Error.Message)
+		})
+	}
+}
+
+func TestConversationDigest(t *testing.T) {
+	history := []llm.Message{
+		{Role: llm.RoleUser, Content: "Hello"},
+		{Role: llm.RoleAssistant, Content: "Hi there"},
+	}
+	digest := conversationDigest(history)
+	assert.Len(t, digest, 64)
+	assert.Equal(t, digest, conversationDigest([]llm.Message{
+		{Role: llm.RoleUser, Content: "Hello"},
+		{Role: llm.RoleAssistant, Content: "Hi there"},
+	}), "the same conversation sent again matches")
+
+	for name, other := range map[string][]llm.Message{
+		"reordered": {history[1], history[0]},
+		"prefix":    history[:1],
+		"run together": {
+			{Role: llm.RoleUser, Content: "HelloassistantHi there"},
+		},
+		"other role": {
+			{Role: llm.RoleAssistant, Content: "Hello"},
+			{Role: llm.RoleAssistant, Content: "Hi there"},
+		},
+	} {
+		assert.NotEqual(t, digest, conversationDigest(other), name)
+	}
+}
+
+func TestStreamChunks(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 5) + "Ünïcödé"
+	chunks := streamChunks(text)
+	assert.Greater(t, len(chunks), 1)
+	assert.Equal(t, text, strings.Join(chunks, ""))
+	assert.Equal(t, []string{"short"}, streamChunks("short"))
+	assert.Empty(t, streamChunks(""))
+}
+
+func TestCompletionError(t *testing.T) {
+	status, body := completionError(refuse(http.StatusNotFound, gin.H{"error": "The model `x` does not exist"}))
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "The model `x` does not exist", body["error"].(gin.H)["message"])
+
+	status, body = completionError(&usage.QuotaError{ResetAt: time.Now()})
+	assert.Equal(t, http.StatusTooManyRequests, status)
+	assert.Equal(t, "insufficient_quota", body["error"].(gin.H)["type"])
+
+	status, _ = completionError(assert.AnError)
+	assert.Equal(t, http.StatusInternalServerError, status)
+
+	status, _ = generationFailure(assert.AnError)
+	assert.Equal(t, http.StatusBadGateway, status)
+}
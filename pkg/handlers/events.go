@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/webhooks"
+	"github.com/gin-gonic/gin"
+)
+
+// publish notifies the organization's webhook endpoints of an event
+// concerning userID. It never fails the request.
+func (h *ChatHandler) publish(ctx context.Context, event, userID string, data interface{}) {
+	if h.events == nil {
+		return
+	}
+	h.events.Publish(ctx, event, userID, data)
+}
+
+// messageCreated publishes a stored message
+func (h *ChatHandler) messageCreated(ctx context.Context, message *models.ChatMessage) {
+	h.publish(ctx, webhooks.EventMessageCreated, message.UserID, message)
+}
+
+// CloseSession closes one of the caller's sessions. A closed session keeps
+// its history but accepts no more messages. Closing a closed session is a
+// no-op.
+func (h *ChatHandler) CloseSession(c *gin.Context) {
+	session, ok := h.sessionFor(c, "")
+	if !ok {
+		return
+	}
+
+	if err := h.closeSession(c.Request.Context(), session); err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// closeSession closes session, announcing it the first time it is closed
+func (h *ChatHandler) closeSession(ctx context.Context, session *models.ChatSession) error {
+	closed, err := h.chatRepo.CloseSession(ctx, session.ID)
+	if err != nil {
+		return err
+	}
+	session.IsActive = false
+	if closed {
+		h.publish(ctx, webhooks.EventSessionClosed, session.UserID, session)
+		h.logger.Info("Session closed", logger.F("session_id", session.ID))
+	}
+	return nil
+}
+
+// openSession refuses with 409 a session that has been closed
+func openSession(session *models.ChatSession) error {
+	if session.IsActive {
+		return nil
+	}
+	return refuse(http.StatusConflict, gin.H{
+		"error": "Session is closed",
+	})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/transcript"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportSession renders a session as Markdown or HTML, or as lossless JSON
+// that can be imported elsewhere
+func (h *ChatHandler) ExportSession(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "md" && format != "html" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "format must be md, json or html",
+		})
+		return
+	}
+
+	session, ok := h.sessionFor(c, auth.PermReadAnyHistory)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	messages, err := h.chatRepo.GetSessionMessages(ctx, session.ID)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	assistant, err := h.sessionAssistant(ctx, session)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	export := transcript.NewExport(session, assistant, messages)
+	filename := fmt.Sprintf("session-%s.%s", session.ID, format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	if format == "json" {
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "text/markdown; charset=utf-8"
+	render := transcript.Markdown
+	if format == "html" {
+		contentType = "text/html; charset=utf-8"
+		render = transcript.HTML
+	}
+	if err := render(&buf, export); err != nil {
+		h.logger.Error("Failed to render session export",
+			logger.F("session_id", session.ID),
+			logger.F("error", err.Error()),
+		)
+		respondInternalError(c)
+		return
+	}
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
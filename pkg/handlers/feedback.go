@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/webhooks"
+	"github.com/gin-gonic/gin"
+)
+
+// SubmitFeedback rates an assistant reply in one of the caller's sessions with
+// thumbs up or down and an optional reason and comment
+func (h *ChatHandler) SubmitFeedback(c *gin.Context) {
+	var req models.SubmitFeedbackRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request body", logger.F("error", err.Error()))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	identity, _ := auth.FromContext(ctx)
+
+	message, ok := h.ownedMessage(c, identity, c.Param("messageID"))
+	if !ok {
+		return
+	}
+	if !message.IsBot {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Only assistant replies can be rated",
+		})
+		return
+	}
+
+	session, ok := h.messageSession(c, message)
+	if !ok {
+		return
+	}
+
+	feedback := &models.MessageFeedback{
+		MessageID:     message.ID,
+		UserID:        identity.UserID,
+		SessionID:     session.ID,
+		Rating:        req.Rating,
+		Reason:        req.Reason,
+		Comment:       req.Comment,
+		AssistantID:   session.AssistantID,
+		PromptName:    session.PromptName,
+		PromptVersion: session.PromptVersion,
+		Model:         message.Model,
+	}
+	if err := h.feedbackRepo.SaveFeedback(ctx, feedback); err != nil {
+		respondInternalError(c)
+		return
+	}
+	h.publish(ctx, webhooks.EventFeedbackSubmitted, identity.UserID, feedback)
+
+	c.JSON(http.StatusOK, feedback)
+}
+
+// FeedbackHandler reports aggregated message ratings
+type FeedbackHandler struct {
+	feedbackRepo *database.FeedbackRepository
+	logger       logger.Logger
+}
+
+// NewFeedbackHandler creates a new feedback handler
+func NewFeedbackHandler(feedbackRepo *database.FeedbackRepository, logger logger.Logger) *FeedbackHandler {
+	return &FeedbackHandler{
+		feedbackRepo: feedbackRepo,
+		logger:       logger,
+	}
+}
+
+// Summary counts ratings by assistant, prompt version or model, optionally
+// filtered by assistant_id, prompt_name and an RFC 3339 from/to time range
+func (h *FeedbackHandler) Summary(c *gin.Context) {
+	by := c.DefaultQuery("by", database.FeedbackByAssistant)
+	if !database.ValidFeedbackDimension(by) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "by must be assistant, prompt or model",
+		})
+		return
+	}
+
+	filter := database.FeedbackFilter{
+		AssistantID: c.Query("assistant_id"),
+		PromptName:  c.Query("prompt_name"),
+	}
+	var ok bool
+	if filter.From, filter.To, ok = parseTimeRange(c); !ok {
+		return
+	}
+
+	aggregates, err := h.feedbackRepo.AggregateFeedback(c.Request.Context(), by, filter)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"by":      by,
+		"results": aggregates,
+	})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/config"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+)
+
+func TestSubmitFeedbackValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewChatHandler(ChatDependencies{}, &config.ChatConfig{}, logger.NewLogrusLogger("error", "text"))
+	router := gin.New()
+	router.POST("/messages/:messageID/feedback", h.SubmitFeedback)
+
+	for name, body := range map[string]string{
+		"missing rating": `{"reason":"wrong"}`,
+		"unknown rating": `{"rating":"meh"}`,
+		"long reason":    `{"rating":"down","reason":"` + string(bytes.Repeat([]byte("x"), 101)) + `"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest("POST", "/messages/msg-1/feedback", bytes.NewBufferString(body))
+			require.NoError(t, err)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestFeedbackSummaryValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewFeedbackHandler(nil, logger.NewLogrusLogger("error", "text"))
+	router := gin.New()
+	router.GET("/feedback/summary", h.Summary)
+
+	for _, query := range []string{"by=user", "from=yesterday", "to=2024-13-01"} {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/feedback/summary?"+query, nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	chatv1 "github.com/Ai-chat-agent/Chat-Agent.git/api/chat/v1"
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/usage"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ChatService serves the chat API over gRPC. It goes through the same checks,
+// storage and generation as the REST handlers; the caller's identity is put
+// in the context by middleware.UnaryAuthInterceptor and
+// middleware.StreamAuthInterceptor.
+type ChatService struct {
+	chatv1.UnimplementedChatServiceServer
+	chat *ChatHandler
+}
+
+// NewChatService creates a new gRPC chat service backed by chat
+func NewChatService(chat *ChatHandler) *ChatService {
+	return &ChatService{chat: chat}
+}
+
+// SendMessage posts a message and returns the assistant's reply
+func (s *ChatService) SendMessage(ctx context.Context, req *chatv1.SendMessageRequest) (*chatv1.SendMessageResponse, error) {
+	identity, _ := auth.FromContext(ctx)
+
+	session, path, userMessage, err := s.accept(ctx, identity, req)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	reply, err := s.chat.reply(ctx, session, path, true)
+	if err != nil {
+		return nil, generationStatus(err)
+	}
+
+	s.chat.logger.Info("Message sent",
+		logger.F("message_id", reply.ID),
+		logger.F("session_id", session.ID),
+		logger.F("transport", "grpc"),
+	)
+
+	return &chatv1.SendMessageResponse{
+		Reply:         toProtoMessage(reply),
+		UserMessageId: userMessage.ID,
+	}, nil
+}
+
+// StreamMessage posts a message and streams the reply as it is generated.
+// Replies that are redacted or answered through a cache are only known once
+// complete, so their deltas follow once generation has finished.
+func (s *ChatService) StreamMessage(req *chatv1.SendMessageRequest, stream grpc.ServerStreamingServer[chatv1.StreamMessageResponse]) error {
+	ctx := stream.Context()
+	identity, _ := auth.FromContext(ctx)
+
+	session, path, userMessage, err := s.accept(ctx, identity, req)
+	if err != nil {
+		return rpcError(err)
+	}
+
+	err = stream.Send(&chatv1.StreamMessageResponse{
+		Event: &chatv1.StreamMessageResponse_Accepted{Accepted: &chatv1.MessageAccepted{
+			SessionId:     session.ID,
+			UserMessageId: userMessage.ID,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	var streamed bool
+	var sendErr error
+	sendDelta := func(part string) error {
+		streamed = true
+		sendErr = stream.Send(&chatv1.StreamMessageResponse{
+			Event: &chatv1.StreamMessageResponse_Delta{Delta: part},
+		})
+		return sendErr
+	}
+
+	reply, err := s.chat.streamReply(ctx, session, path, sendDelta)
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return generationStatus(err)
+	}
+
+	if !streamed {
+		for _, part := range streamChunks(reply.Message) {
+			if err := sendDelta(part); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.chat.logger.Info("Message sent",
+		logger.F("message_id", reply.ID),
+		logger.F("session_id", session.ID),
+		logger.F("transport", "grpc"),
+	)
+
+	return stream.Send(&chatv1.StreamMessageResponse{
+		Event: &chatv1.StreamMessageResponse_Reply{Reply: toProtoMessage(reply)},
+	})
+}
+
+// GetSessionHistory returns the active branch of a session, oldest first,
+// with the sibling branches available at each turn
+func (s *ChatService) GetSessionHistory(ctx context.Context, req *chatv1.GetSessionHistoryRequest) (*chatv1.GetSessionHistoryResponse, error) {
+	identity, _ := auth.FromContext(ctx)
+
+	session, err := s.chat.findSession(ctx, identity, req.SessionId, auth.PermReadAnyHistory)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	history, err := s.chat.chatRepo.GetSessionHistory(ctx, session.ID)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	messages := make([]*chatv1.HistoryMessage, len(history))
+	for i := range history {
+		messages[i] = &chatv1.HistoryMessage{
+			Message:    toProtoMessage(&history[i].ChatMessage),
+			SiblingIds: history[i].SiblingIDs,
+		}
+	}
+	return &chatv1.GetSessionHistoryResponse{
+		SessionId:       session.ID,
+		ActiveMessageId: deref(session.ActiveMessageID),
+		Messages:        messages,
+	}, nil
+}
+
+// SelectBranch switches a session to the branch containing the given message
+func (s *ChatService) SelectBranch(ctx context.Context, req *chatv1.SelectBranchRequest) (*chatv1.SelectBranchResponse, error) {
+	if req.MessageId == "" {
+		return nil, status.Error(codes.InvalidArgument, "message_id is required")
+	}
+	identity, _ := auth.FromContext(ctx)
+
+	session, err := s.chat.findSession(ctx, identity, req.SessionId, "")
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	leaf, err := s.chat.chatRepo.SelectBranch(ctx, session.ID, req.MessageId)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, "Message not found")
+	}
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	return &chatv1.SelectBranchResponse{
+		SessionId:       session.ID,
+		ActiveMessageId: leaf,
+	}, nil
+}
+
+// CloseSession closes one of the caller's sessions. Closing a closed session
+// is a no-op.
+func (s *ChatService) CloseSession(ctx context.Context, req *chatv1.CloseSessionRequest) (*chatv1.Session, error) {
+	identity, _ := auth.FromContext(ctx)
+
+	session, err := s.chat.findSession(ctx, identity, req.SessionId, "")
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	if err := s.chat.closeSession(ctx, session); err != nil {
+		return nil, rpcError(err)
+	}
+	return toProtoSession(session), nil
+}
+
+// GetChatHistory returns a user's most recent messages. Callers may read
+// their own history, or anyone's with the read-any-history permission.
+func (s *ChatService) GetChatHistory(ctx context.Context, req *chatv1.GetChatHistoryRequest) (*chatv1.GetChatHistoryResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	identity, _ := auth.FromContext(ctx)
+	if !identity.IsSelfOr(req.UserId, auth.PermReadAnyHistory) {
+		return nil, status.Error(codes.PermissionDenied, "Forbidden")
+	}
+
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit < 1 || limit > maxHistoryLimit {
+		return nil, status.Error(codes.InvalidArgument, "limit must be between 1 and "+strconv.Itoa(maxHistoryLimit))
+	}
+
+	history, err := s.chat.chatRepo.GetMessagesByUserID(ctx, req.UserId, limit)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	messages := make([]*chatv1.ChatMessage, len(history))
+	for i := range history {
+		messages[i] = toProtoMessage(&history[i])
+	}
+	return &chatv1.GetChatHistoryResponse{
+		UserId:   req.UserId,
+		Messages: messages,
+	}, nil
+}
+
+// accept validates a message request and stores the message as SendMessage
+// does for the REST API
+func (s *ChatService) accept(ctx context.Context, identity auth.Identity, req *chatv1.SendMessageRequest) (*models.ChatSession, []models.ChatMessage, *models.ChatMessage, error) {
+	if req.Message == "" {
+		return nil, nil, nil, refuse(http.StatusBadRequest, gin.H{
+			"error": "message is required",
+		})
+	}
+	return s.chat.acceptMessage(ctx, identity, models.ChatMessageRequest{
+		SessionID:   req.SessionId,
+		AssistantID: req.AssistantId,
+		Message:     req.Message,
+	})
+}
+
+// rpcError converts an error from the shared chat logic to the status the
+// REST API's response corresponds to. A used-up quota carries when to retry.
+func rpcError(err error) error {
+	var (
+		refused  *requestError
+		exceeded *usage.QuotaError
+	)
+	switch {
+	case errors.As(err, &refused):
+		return status.Error(rpcCode(refused.status), refused.Error())
+	case errors.As(err, &exceeded):
+		st, detailErr := status.New(codes.ResourceExhausted, "Token quota exceeded").WithDetails(&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(time.Until(exceeded.ResetAt).Truncate(time.Second) + time.Second),
+		})
+		if detailErr != nil {
+			return status.Error(codes.ResourceExhausted, "Token quota exceeded")
+		}
+		return st.Err()
+	default:
+		return status.Error(codes.Internal, "Internal server error")
+	}
+}
+
+// rpcCode maps the HTTP status of a refused request to a gRPC code
+func rpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// generationStatus is the gRPC counterpart of respondGenerationError
+func generationStatus(err error) error {
+	if errors.Is(err, llm.ErrEmptyConversation) {
+		return status.Error(codes.FailedPrecondition, "There is no message to respond to")
+	}
+	return status.Error(codes.Unavailable, "Failed to generate a response")
+}
+
+func toProtoMessage(m *models.ChatMessage) *chatv1.ChatMessage {
+	return &chatv1.ChatMessage{
+		Id:        m.ID,
+		SessionId: m.SessionID,
+		ParentId:  deref(m.ParentID),
+		UserId:    m.UserID,
+		Message:   m.Message,
+		IsBot:     m.IsBot,
+		Model:     m.Model,
+		Cached:    m.Cached,
+		CreatedAt: timestamppb.New(m.CreatedAt),
+	}
+}
+
+func toProtoSession(s *models.ChatSession) *chatv1.Session {
+	return &chatv1.Session{
+		Id:              s.ID,
+		UserId:          s.UserID,
+		Title:           s.Title,
+		ActiveMessageId: deref(s.ActiveMessageID),
+		AssistantId:     deref(s.AssistantID),
+		PromptName:      s.PromptName,
+		PromptVersion:   int32(s.PromptVersion),
+		IsActive:        s.IsActive,
+		Channel:         s.Channel,
+		CreatedAt:       timestamppb.New(s.CreatedAt),
+		UpdatedAt:       timestamppb.New(s.UpdatedAt),
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	chatv1 "github.com/Ai-chat-agent/Chat-Agent.git/api/chat/v1"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/usage"
+)
+
+func TestRPCError(t *testing.T) {
+	st := status.Convert(rpcError(refuse(http.StatusConflict, gin.H{"error": "Session is closed"})))
+	assert.Equal(t, codes.FailedPrecondition, st.Code())
+	assert.Equal(t, "Session is closed", st.Message())
+
+	st = status.Convert(rpcError(refuse(http.StatusUnprocessableEntity, gin.H{"error": "Message rejected by moderation"})))
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+
+	st = status.Convert(rpcError(&usage.QuotaError{ResetAt: time.Now().Add(time.Minute)}))
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	retry, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.InDelta(t, time.Minute.Seconds(), retry.RetryDelay.AsDuration().Seconds(), 2)
+
+	assert.Equal(t, codes.Internal, status.Code(rpcError(assert.AnError)))
+
+	assert.Equal(t, codes.FailedPrecondition, status.Code(generationStatus(llm.ErrEmptyConversation)))
+	assert.Equal(t, codes.Unavailable, status.Code(generationStatus(assert.AnError)))
+}
+
+func TestChatServiceSendMessageValidation(t *testing.T) {
+	service := NewChatService(nil)
+
+	_, err := service.SendMessage(context.Background(), &chatv1.SendMessageRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = service.GetChatHistory(context.Background(), &chatv1.GetChatHistoryRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestToProtoMessage(t *testing.T) {
+	parent := "msg-1"
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	message := toProtoMessage(&models.ChatMessage{
+		ID:        "msg-2",
+		SessionID: "session-1",
+		ParentID:  &parent,
+		Message:   "Hello",
+		IsBot:     true,
+		CreatedAt: created,
+	})
+
+	assert.Equal(t, "msg-2", message.Id)
+	assert.Equal(t, "msg-1", message.ParentId)
+	assert.True(t, message.IsBot)
+	assert.Equal(t, created, message.CreatedAt.AsTime())
+	assert.Empty(t, toProtoMessage(&models.ChatMessage{}).ParentId)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/transcript"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportBytes bounds the size of an import request body
+const maxImportBytes = 20 << 20
+
+// ImportSessions creates sessions from OpenAI-style transcripts or session
+// exports. Sessions belong to the caller unless user_id names another user,
+// which requires the users:manage permission.
+func (h *ChatHandler) ImportSessions(c *gin.Context) {
+	ctx := c.Request.Context()
+	identity, _ := auth.FromContext(ctx)
+
+	userID := c.DefaultQuery("user_id", identity.UserID)
+	if !authorizeSelfOr(c, userID, auth.PermManageUsers) {
+		return
+	}
+	if userID != identity.UserID {
+		user, err := h.userRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			respondInternalError(c)
+			return
+		}
+		if user == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "Import is too large",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	exports, err := transcript.Parse(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid transcript",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	importer := &transcript.Importer{
+		Store:      h.chatRepo,
+		Assistants: h.assistantRepo,
+		Redactor:   h.redactor,
+		Vault:      h.vault,
+	}
+	imported, err := importer.Import(ctx, userID, exports)
+	if err != nil {
+		h.logger.Error("Failed to import sessions",
+			logger.F("user_id", userID),
+			logger.F("imported", len(imported)),
+			logger.F("error", err.Error()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":    "Internal server error",
+			"sessions": imported,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"sessions": imported,
+	})
+}
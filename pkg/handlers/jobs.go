@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// errJobFailed is recorded on jobs that failed for reasons already logged,
+// which are not shown to the caller
+var errJobFailed = errors.New("failed to generate a response")
+
+// enqueueReply queues the generation of the reply to a stored user message
+// and responds 202 with the job, which the caller can poll or await on its
+// webhook
+func (h *ChatHandler) enqueueReply(c *gin.Context, identity auth.Identity, userMessage *models.ChatMessage, webhookURL string) {
+	job := &models.Job{
+		UserID:     identity.UserID,
+		APIKeyID:   identity.APIKeyID,
+		Role:       string(identity.Role),
+		Type:       models.JobTypeChatReply,
+		SessionID:  userMessage.SessionID,
+		MessageID:  userMessage.ID,
+		Cacheable:  true,
+		WebhookURL: webhookURL,
+	}
+	if err := h.jobs.Enqueue(c.Request.Context(), job); err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	h.logger.Info("Reply queued",
+		logger.F("job_id", job.ID),
+		logger.F("session_id", job.SessionID),
+	)
+
+	c.Header("Location", "/api/v1/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, models.JobResponse{
+		ID:        job.ID,
+		Status:    job.Status,
+		SessionID: job.SessionID,
+		MessageID: job.MessageID,
+		CreatedAt: job.CreatedAt,
+	})
+}
+
+// validWebhookURL reports whether a job webhook can be called
+func validWebhookURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// ProcessJob implements jobs.Processor by generating the reply a job was
+// queued for
+func (h *ChatHandler) ProcessJob(ctx context.Context, job *models.Job) error {
+	if job.Type != models.JobTypeChatReply {
+		return fmt.Errorf("unknown job type %q", job.Type)
+	}
+
+	session, err := h.chatRepo.GetSessionByID(ctx, job.SessionID)
+	if err != nil {
+		return errJobFailed
+	}
+	if session == nil {
+		return errors.New("the session no longer exists")
+	}
+	path, err := h.chatRepo.GetPathTo(ctx, job.MessageID)
+	if err != nil {
+		return errJobFailed
+	}
+
+	reply, err := h.reply(ctx, session, path, job.Cacheable)
+	if err != nil {
+		if errors.Is(err, llm.ErrEmptyConversation) {
+			return errors.New("there is no message to respond to")
+		}
+		return errJobFailed
+	}
+	job.ReplyID = &reply.ID
+	return nil
+}
+
+// JobReport implements jobs.Processor
+func (h *ChatHandler) JobReport(ctx context.Context, job *models.Job) (interface{}, error) {
+	return h.jobResponse(ctx, job)
+}
+
+// GetJob returns the state of one of the caller's jobs, with the reply once
+// it has been generated
+func (h *ChatHandler) GetJob(c *gin.Context) {
+	ctx := c.Request.Context()
+	identity, _ := auth.FromContext(ctx)
+
+	job, err := h.jobs.GetJob(ctx, c.Param("jobID"))
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	if job == nil || !identity.IsSelfOr(job.UserID, auth.PermReadAnyHistory) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job not found",
+		})
+		return
+	}
+
+	response, err := h.jobResponse(ctx, job)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ChatHandler) jobResponse(ctx context.Context, job *models.Job) (*models.JobResponse, error) {
+	response := &models.JobResponse{
+		ID:         job.ID,
+		Status:     job.Status,
+		SessionID:  job.SessionID,
+		MessageID:  job.MessageID,
+		Attempts:   job.Attempts,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+	if job.ReplyID == nil {
+		return response, nil
+	}
+
+	reply, err := h.chatRepo.GetMessageByID(ctx, *job.ReplyID)
+	if err != nil {
+		return nil, err
+	}
+	if reply != nil {
+		r := newChatMessageResponse(reply, job.MessageID)
+		response.Reply = &r
+	}
+	return response, nil
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/webhooks"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler handles API key issuance and revocation
+type APIKeyHandler struct {
+	userRepo *database.UserRepository
+	keyRepo  *database.APIKeyRepository
+	logger   logger.Logger
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(userRepo *database.UserRepository, keyRepo *database.APIKeyRepository, logger logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		userRepo: userRepo,
+		keyRepo:  keyRepo,
+		logger:   logger,
+	}
+}
+
+// CreateAPIKey issues a new key for a user. The token is only returned in this response.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID := c.Param("id")
+	if !authorizeSelfOr(c, userID, auth.PermManageKeys) {
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "expires_at must be in the future",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	token, hash, err := auth.GenerateKey()
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	key := models.APIKey{
+		UserID:        user.ID,
+		Name:          req.Name,
+		Prefix:        auth.DisplayPrefix(token),
+		KeyHash:       hash,
+		WebhookSecret: secret,
+		ExpiresAt:     req.ExpiresAt,
+	}
+	if err := h.keyRepo.CreateAPIKey(ctx, &key); err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreateAPIKeyResponse{
+		APIKey:        key,
+		Token:         token,
+		WebhookSecret: secret,
+	})
+}
+
+// ListAPIKeys lists the keys issued to a user
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID := c.Param("id")
+	if !authorizeSelfOr(c, userID, auth.PermManageKeys) {
+		return
+	}
+
+	keys, err := h.keyRepo.ListAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys":  keys,
+		"total": len(keys),
+	})
+}
+
+// RevokeAPIKey revokes one of a user's keys
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID := c.Param("id")
+	if !authorizeSelfOr(c, userID, auth.PermManageKeys) {
+		return
+	}
+
+	keyID := c.Param("keyID")
+	if err := h.keyRepo.RevokeAPIKey(c.Request.Context(), userID, keyID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "API key not found",
+			})
+			return
+		}
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "API key revoked successfully",
+		"api_key_id": keyID,
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const maxKnowledgeBaseName = 100
+
+// KnowledgeBaseHandler tracks changes to the knowledge bases assistants use,
+// so answers cached from old content stop being served
+type KnowledgeBaseHandler struct {
+	knowledgeRepo *database.KnowledgeBaseRepository
+	logger        logger.Logger
+}
+
+// NewKnowledgeBaseHandler creates a new knowledge base handler
+func NewKnowledgeBaseHandler(knowledgeRepo *database.KnowledgeBaseRepository, logger logger.Logger) *KnowledgeBaseHandler {
+	return &KnowledgeBaseHandler{
+		knowledgeRepo: knowledgeRepo,
+		logger:        logger,
+	}
+}
+
+// ListKnowledgeBases lists knowledge bases with their current versions
+func (h *KnowledgeBaseHandler) ListKnowledgeBases(c *gin.Context) {
+	bases, err := h.knowledgeRepo.ListKnowledgeBases(c.Request.Context())
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"knowledge_bases": bases,
+		"total":           len(bases),
+	})
+}
+
+// RecordChange bumps a knowledge base's version after its content changed,
+// invalidating the semantic cache entries generated from it
+func (h *KnowledgeBaseHandler) RecordChange(c *gin.Context) {
+	name := c.Param("name")
+	if strings.TrimSpace(name) == "" || len(name) > maxKnowledgeBaseName {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Knowledge base names are 1 to 100 characters",
+		})
+		return
+	}
+
+	base, err := h.knowledgeRepo.RecordChange(c.Request.Context(), name)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, base)
+}
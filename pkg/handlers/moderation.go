@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// ModerationHandler exposes messages rejected by moderation for review
+type ModerationHandler struct {
+	moderationRepo *database.ModerationRepository
+	logger         logger.Logger
+}
+
+// NewModerationHandler creates a new moderation handler
+func NewModerationHandler(moderationRepo *database.ModerationRepository, logger logger.Logger) *ModerationHandler {
+	return &ModerationHandler{
+		moderationRepo: moderationRepo,
+		logger:         logger,
+	}
+}
+
+// ListFlags lists flagged messages, newest first
+func (h *ModerationHandler) ListFlags(c *gin.Context) {
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	flags, total, err := h.moderationRepo.ListFlags(c.Request.Context(), (page-1)*pageSize, pageSize)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"flags":     flags,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/prompts"
+	"github.com/gin-gonic/gin"
+)
+
+var promptNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,99}$`)
+
+// PromptHandler handles prompt template registry endpoints
+type PromptHandler struct {
+	promptRepo *database.PromptRepository
+	logger     logger.Logger
+}
+
+// NewPromptHandler creates a new prompt handler
+func NewPromptHandler(promptRepo *database.PromptRepository, logger logger.Logger) *PromptHandler {
+	return &PromptHandler{
+		promptRepo: promptRepo,
+		logger:     logger,
+	}
+}
+
+// ListTemplates lists every template version
+func (h *PromptHandler) ListTemplates(c *gin.Context) {
+	h.respondWithVersions(c, "")
+}
+
+// ListVersions lists the versions of one template, newest first
+func (h *PromptHandler) ListVersions(c *gin.Context) {
+	name, ok := promptName(c)
+	if !ok {
+		return
+	}
+	h.respondWithVersions(c, name)
+}
+
+func (h *PromptHandler) respondWithVersions(c *gin.Context, name string) {
+	templates, err := h.promptRepo.ListTemplates(c.Request.Context(), name)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+		"total":     len(templates),
+	})
+}
+
+// CreateVersion adds a new version of a template
+func (h *PromptHandler) CreateVersion(c *gin.Context) {
+	name, ok := promptName(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreatePromptVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err := prompts.Validate(req.Body, req.Variables); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid prompt template",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	identity, _ := auth.FromContext(c.Request.Context())
+	tpl := &models.PromptTemplate{
+		Name:      name,
+		Body:      req.Body,
+		Variables: req.Variables,
+		IsActive:  req.Activate,
+		CreatedBy: identity.UserID,
+	}
+	if err := h.promptRepo.CreateVersion(c.Request.Context(), tpl); err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "A concurrent update created this version; retry",
+			})
+			return
+		}
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tpl)
+}
+
+// PromoteVersion makes a version the active one; new sessions use it from then on
+func (h *PromptHandler) PromoteVersion(c *gin.Context) {
+	name, ok := promptName(c)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "version must be a positive integer",
+		})
+		return
+	}
+
+	// Versions created before validation tightened may not render for sessions
+	existing, err := h.promptRepo.GetVersion(c.Request.Context(), name, version)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	if existing == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Prompt version not found",
+		})
+		return
+	}
+	if err := prompts.Validate(existing.Body, existing.Variables); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid prompt template",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	tpl, err := h.promptRepo.Promote(c.Request.Context(), name, version)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Prompt version not found",
+			})
+			return
+		}
+		respondInternalError(c)
+		return
+	}
+
+	identity, _ := auth.FromContext(c.Request.Context())
+	h.logger.Info("Prompt promoted",
+		logger.F("name", name),
+		logger.F("version", version),
+		logger.F("promoted_by", identity.UserID),
+	)
+
+	c.JSON(http.StatusOK, tpl)
+}
+
+// promptName reads and validates the :name path parameter. It writes a 400
+// response and reports false when it is invalid.
+func promptName(c *gin.Context) (string, bool) {
+	name := c.Param("name")
+	if !promptNamePattern.MatchString(name) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Prompt names are lowercase letters, digits, '.', '_' and '-', up to 100 characters",
+		})
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RetentionHandler handles retention policy endpoints
+type RetentionHandler struct {
+	retentionRepo *database.RetentionRepository
+	assistantRepo *database.AssistantRepository
+	logger        logger.Logger
+}
+
+// NewRetentionHandler creates a new retention handler
+func NewRetentionHandler(retentionRepo *database.RetentionRepository, assistantRepo *database.AssistantRepository, logger logger.Logger) *RetentionHandler {
+	return &RetentionHandler{
+		retentionRepo: retentionRepo,
+		assistantRepo: assistantRepo,
+		logger:        logger,
+	}
+}
+
+// ListPolicies lists the organization's retention policies
+func (h *RetentionHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.retentionRepo.ListPolicies(c.Request.Context())
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"total":    len(policies),
+	})
+}
+
+// SavePolicy creates or replaces the organization-wide policy, or the policy
+// of the assistant named in the request
+func (h *RetentionHandler) SavePolicy(c *gin.Context) {
+	var req models.SaveRetentionPolicyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if req.AssistantID != "" {
+		if req.AnonymizeUserDays > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "anonymize_user_days can only be set on the organization-wide policy",
+			})
+			return
+		}
+		assistant, err := h.assistantRepo.GetAssistantByID(ctx, req.AssistantID)
+		if err != nil {
+			respondInternalError(c)
+			return
+		}
+		if assistant == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Unknown assistant_id",
+			})
+			return
+		}
+	}
+
+	policy := &models.RetentionPolicy{
+		AssistantID:       req.AssistantID,
+		MessageDays:       req.MessageDays,
+		AnonymizeUserDays: req.AnonymizeUserDays,
+	}
+	if err := h.retentionRepo.SavePolicy(ctx, policy); err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "The policy was changed concurrently, please retry",
+			})
+			return
+		}
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy removes a retention policy
+func (h *RetentionHandler) DeletePolicy(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.retentionRepo.DeletePolicy(c.Request.Context(), id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Retention policy not found",
+			})
+			return
+		}
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Retention policy deleted successfully",
+		"policy_id": id,
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// UsageHandler reports token usage, cost and quotas
+type UsageHandler struct {
+	usageRepo *database.UsageRepository
+	quota     *usage.Enforcer
+	logger    logger.Logger
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(usageRepo *database.UsageRepository, quota *usage.Enforcer, logger logger.Logger) *UsageHandler {
+	return &UsageHandler{
+		usageRepo: usageRepo,
+		quota:     quota,
+		logger:    logger,
+	}
+}
+
+// GetUsage reports the tokens and cost of provider calls made by the caller,
+// another user (user_id) or the whole organization (all=true), optionally
+// broken down by day, month, model or user. The period defaults to the
+// current month.
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	identity, _ := auth.FromContext(c.Request.Context())
+
+	filter := database.UsageFilter{UserID: c.DefaultQuery("user_id", identity.UserID)}
+	if c.Query("all") == "true" {
+		filter.UserID = ""
+		if !identity.Can(auth.PermReadUsage) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":      "Forbidden",
+				"permission": auth.PermReadUsage,
+			})
+			return
+		}
+	} else if !authorizeSelfOr(c, filter.UserID, auth.PermReadUsage) {
+		return
+	}
+
+	by := c.Query("by")
+	if by != "" && !database.ValidUsageDimension(by) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "by must be day, month, model or user",
+		})
+		return
+	}
+
+	var ok bool
+	if filter.From, filter.To, ok = parseTimeRange(c); !ok {
+		return
+	}
+	if filter.From == nil {
+		_, month, _, _ := usage.Periods(time.Now())
+		filter.From = &month
+	}
+
+	total, breakdown, err := h.usageRepo.SummarizeUsage(c.Request.Context(), filter, by)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	response := gin.H{
+		"user_id": filter.UserID,
+		"from":    filter.From,
+		"to":      filter.To,
+		"total":   total,
+	}
+	if by != "" {
+		response["by"] = by
+		response["breakdown"] = breakdown
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetQuota reports the tokens the caller's user, key and organization have
+// used today and this month against their limits
+func (h *UsageHandler) GetQuota(c *gin.Context) {
+	ctx := c.Request.Context()
+	identity, _ := auth.FromContext(ctx)
+
+	totals, err := h.quota.Totals(ctx, identity)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	_, _, nextDay, nextMonth := usage.Periods(time.Now())
+	c.JSON(http.StatusOK, gin.H{
+		"limits":        h.quota.Quotas(),
+		"used":          totals,
+		"daily_reset":   nextDay,
+		"monthly_reset": nextMonth,
+	})
+}
+
+// respondQuotaExceeded answers 429 with the quota that was used up and when
+// it resets
+func respondQuotaExceeded(c *gin.Context, exceeded *usage.QuotaError) {
+	retryAfter := int(time.Until(exceeded.ResetAt).Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": "Token quota exceeded",
+		"quota": exceeded,
+	})
+}
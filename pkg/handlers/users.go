@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/redact"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// UserHandler handles user management endpoints
+type UserHandler struct {
+	userRepo  *database.UserRepository
+	auditRepo *database.AuditRepository
+	vault     *redact.Vault
+	logger    logger.Logger
+}
+
+// NewUserHandler creates a new user handler. vault decrypts the originals of
+// redacted messages for exports; it may be nil when vault mode is off.
+func NewUserHandler(userRepo *database.UserRepository, auditRepo *database.AuditRepository, vault *redact.Vault, logger logger.Logger) *UserHandler {
+	return &UserHandler{
+		userRepo:  userRepo,
+		auditRepo: auditRepo,
+		vault:     vault,
+		logger:    logger,
+	}
+}
+
+// CreateUser creates a user in the caller's organization
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var req models.CreateUserRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	user := &models.User{
+		Username:    req.Username,
+		Email:       req.Email,
+		DisplayName: req.DisplayName,
+	}
+	if err := h.userRepo.CreateUser(c.Request.Context(), user); err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Username or email already in use",
+			})
+			return
+		}
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// GetUser returns a single user. Users may read their own profile; reading
+// others requires the users:manage permission.
+func (h *UserHandler) GetUser(c *gin.Context) {
+	id := c.Param("id")
+	if !authorizeSelfOr(c, id, auth.PermManageUsers) {
+		return
+	}
+	h.respondWithUser(c, id)
+}
+
+// Me returns the authenticated user
+func (h *UserHandler) Me(c *gin.Context) {
+	identity, _ := auth.FromContext(c.Request.Context())
+	h.respondWithUser(c, identity.UserID)
+}
+
+func (h *UserHandler) respondWithUser(c *gin.Context, id string) {
+	user, err := h.userRepo.GetUserByID(c.Request.Context(), id)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ListUsers returns a page of users
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	users, total, err := h.userRepo.ListUsers(c.Request.Context(), (page-1)*pageSize, pageSize)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":     users,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// UpdateUser applies a partial profile update
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	var req models.UpdateUserRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	id := c.Param("id")
+	if !authorizeSelfOr(c, id, auth.PermManageUsers) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	if req.Username != nil {
+		user.Username = *req.Username
+	}
+	if req.Email != nil {
+		user.Email = *req.Email
+	}
+	if req.DisplayName != nil {
+		user.DisplayName = *req.DisplayName
+	}
+
+	if err := h.userRepo.UpdateUser(ctx, user); err != nil {
+		switch {
+		case errors.Is(err, database.ErrConflict):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Username or email already in use",
+			})
+		case errors.Is(err, database.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+		default:
+			respondInternalError(c)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateRole changes a user's role
+func (h *UserHandler) UpdateRole(c *gin.Context) {
+	var req models.UpdateRoleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	id := c.Param("id")
+	identity, _ := auth.FromContext(c.Request.Context())
+	if id == identity.UserID && req.Role != identity.Role {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "You cannot change your own role",
+		})
+		return
+	}
+
+	if err := h.userRepo.UpdateUserRole(c.Request.Context(), id, req.Role); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+		respondInternalError(c)
+		return
+	}
+
+	h.logger.Info("User role changed",
+		logger.F("user_id", id),
+		logger.F("role", req.Role),
+		logger.F("changed_by", identity.UserID),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id": id,
+		"role":    req.Role,
+	})
+}
+
+// DeleteUser soft-deletes a user
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id := c.Param("id")
+
+	erase, err := strconv.ParseBool(c.DefaultQuery("erase", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "erase must be true or false",
+		})
+		return
+	}
+	if erase {
+		h.eraseUser(c, id)
+		return
+	}
+
+	if err := h.userRepo.DeleteUser(c.Request.Context(), id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User deleted successfully",
+		"user_id": id,
+	})
+}
+
+// eraseUser permanently deletes a user and all of their data
+func (h *UserHandler) eraseUser(c *gin.Context, id string) {
+	erased, err := h.userRepo.EraseUser(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User erased successfully",
+		"user_id": id,
+		"erased":  erased,
+	})
+}
+
+// ExportUser returns everything stored about a user as a JSON document, or
+// with format=zip as a ZIP archive with one JSON file per kind of data
+func (h *UserHandler) ExportUser(c *gin.Context) {
+	id := c.Param("id")
+	if !authorizeSelfOr(c, id, auth.PermManageUsers) {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "format must be json or zip",
+		})
+		return
+	}
+
+	export, err := h.userRepo.ExportUserData(c.Request.Context(), id)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	if export == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+	if err := h.revealOriginals(export); err != nil {
+		h.logger.Error("Failed to open message original for export",
+			logger.F("user_id", id),
+			logger.F("error", err.Error()),
+		)
+		respondInternalError(c)
+		return
+	}
+
+	h.recordAudit(c, &models.AuditEvent{
+		Action:     audit.ActionUserExport,
+		TargetType: "user",
+		TargetID:   id,
+		Metadata:   models.AuditMetadata{"format": format},
+	})
+
+	filename := fmt.Sprintf("user-%s-export", id)
+	if format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	archive, err := zipExport(export)
+	if err != nil {
+		h.logger.Error("Failed to build export archive", logger.F("error", err.Error()))
+		respondInternalError(c)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// revealOriginals decrypts the vault originals of the exported messages into
+// export.Originals. Without a vault they cannot be read and are left out.
+func (h *UserHandler) revealOriginals(export *models.UserExport) error {
+	export.Originals = []models.MessageOriginal{}
+	if h.vault == nil {
+		return nil
+	}
+	for _, message := range export.Messages {
+		if message.Vault == nil {
+			continue
+		}
+		original, err := h.vault.Open(message.Vault.Ciphertext, message.Vault.MessageID, message.Vault.OrganizationID)
+		if err != nil {
+			return fmt.Errorf("message %s: %w", message.ID, err)
+		}
+		export.Originals = append(export.Originals, models.MessageOriginal{
+			MessageID: message.ID,
+			Message:   original,
+		})
+	}
+	return nil
+}
+
+// zipExport packs an export into a ZIP archive with one JSON file per section
+func zipExport(export *models.UserExport) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.User},
+		{"sessions.json", export.Sessions},
+		{"messages.json", export.Messages},
+		{"moderation_flags.json", export.ModerationFlags},
+		{"feedback.json", export.Feedback},
+		{"usage.json", export.Usage},
+		{"api_keys.json", export.APIKeys},
+		{"channel_accounts.json", export.ChannelAccounts},
+		{"originals.json", export.Originals},
+	}
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     f.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// recordAudit records an action that changes nothing in the database, such
+// as a read of sensitive data. A failure is logged by the repository but does
+// not fail the request, which has already completed.
+func (h *UserHandler) recordAudit(c *gin.Context, event *models.AuditEvent) {
+	_ = h.auditRepo.Record(c.Request.Context(), event)
+}
+
+// authorizeSelfOr allows the request when the caller is userID or holds perm.
+// It writes a 403 response and reports false otherwise.
+func authorizeSelfOr(c *gin.Context, userID string, perm auth.Permission) bool {
+	identity, _ := auth.FromContext(c.Request.Context())
+	if identity.IsSelfOr(userID, perm) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":      "Forbidden",
+		"permission": perm,
+	})
+	return false
+}
+
+// parsePagination reads the page and page_size query parameters. It writes a
+// 400 response and reports false when they are invalid.
+func parsePagination(c *gin.Context) (page int, pageSize int, ok bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "page must be a positive integer",
+		})
+		return 0, 0, false
+	}
+
+	pageSize, err = strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "page_size must be between 1 and " + strconv.Itoa(maxPageSize),
+		})
+		return 0, 0, false
+	}
+
+	return page, pageSize, true
+}
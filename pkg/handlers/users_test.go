@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/redact"
+)
+
+func TestParsePagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		query    string
+		ok       bool
+		page     int
+		pageSize int
+	}{
+		{"", true, 1, defaultPageSize},
+		{"page=3&page_size=50", true, 3, 50},
+		{"page=0", false, 0, 0},
+		{"page=abc", false, 0, 0},
+		{"page_size=1000", false, 0, 0},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/users/?"+tt.query, nil)
+
+		page, pageSize, ok := parsePagination(c)
+
+		assert.Equal(t, tt.ok, ok, tt.query)
+		assert.Equal(t, tt.page, page, tt.query)
+		assert.Equal(t, tt.pageSize, pageSize, tt.query)
+		if !tt.ok {
+			assert.Equal(t, http.StatusBadRequest, w.Code, tt.query)
+		}
+	}
+}
+
+func TestZipExport(t *testing.T) {
+	export := &models.UserExport{
+		ExportedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		User:       models.User{ID: "u1", Username: "jane"},
+		Messages:   []models.ChatMessage{{ID: "m1", UserID: "u1", Message: "hello"}},
+	}
+
+	archive, err := zipExport(export)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	assert.Len(t, files, 9)
+
+	rc, err := files["messages.json"].Open()
+	require.NoError(t, err)
+	defer rc.Close()
+
+	var messages []models.ChatMessage
+	require.NoError(t, json.NewDecoder(rc).Decode(&messages))
+	require.Len(t, messages, 1)
+	assert.Equal(t, "hello", messages[0].Message)
+}
+
+func TestRevealOriginals(t *testing.T) {
+	vault, err := redact.NewVault(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	require.NoError(t, err)
+	sealed, err := vault.Seal("mail jane.doe@example.com", "m1", "org-1")
+	require.NoError(t, err)
+
+	export := &models.UserExport{Messages: []models.ChatMessage{
+		{ID: "m1", Message: "mail [EMAIL]", Vault: &models.MessageVault{MessageID: "m1", OrganizationID: "org-1", Ciphertext: sealed}},
+		{ID: "m2", Message: "hello"},
+	}}
+
+	h := NewUserHandler(nil, nil, vault, logger.NewLogrusLogger("error", "text"))
+	require.NoError(t, h.revealOriginals(export))
+	assert.Equal(t, []models.MessageOriginal{{MessageID: "m1", Message: "mail jane.doe@example.com"}}, export.Originals)
+
+	// Without a vault the originals cannot be read
+	h = NewUserHandler(nil, nil, nil, logger.NewLogrusLogger("error", "text"))
+	require.NoError(t, h.revealOriginals(export))
+	assert.Empty(t, export.Originals)
+
+	// A ciphertext sealed for another message fails the export
+	export.Messages[0].Vault.MessageID = "m2"
+	h = NewUserHandler(nil, nil, vault, logger.NewLogrusLogger("error", "text"))
+	assert.ErrorIs(t, h.revealOriginals(export), redact.ErrInvalidCiphertext)
+}
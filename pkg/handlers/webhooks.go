@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/internal/database"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/webhooks"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler handles webhook endpoint and delivery log endpoints
+type WebhookHandler struct {
+	webhookRepo *database.WebhookRepository
+	dispatcher  *webhooks.Dispatcher
+	logger      logger.Logger
+}
+
+// NewWebhookHandler creates a new webhook handler. dispatcher may be nil when
+// webhooks are disabled, in which case redeliveries are refused.
+func NewWebhookHandler(webhookRepo *database.WebhookRepository, dispatcher *webhooks.Dispatcher, logger logger.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo: webhookRepo,
+		dispatcher:  dispatcher,
+		logger:      logger,
+	}
+}
+
+// CreateEndpoint registers a webhook endpoint. The signing secret is only
+// returned here.
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	var req models.CreateWebhookRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+	if !validWebhookURL(req.URL) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "url must be an http or https URL",
+		})
+		return
+	}
+	events, ok := webhookEvents(c, req.Events)
+	if !ok {
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	endpoint := &models.WebhookEndpoint{
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      events,
+		Secret:      secret,
+		Active:      true,
+	}
+	if err := h.webhookRepo.CreateEndpoint(c.Request.Context(), endpoint); err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreateWebhookResponse{
+		WebhookEndpoint: *endpoint,
+		Secret:          secret,
+	})
+}
+
+// ListEndpoints lists the organization's webhook endpoints
+func (h *WebhookHandler) ListEndpoints(c *gin.Context) {
+	endpoints, err := h.webhookRepo.ListEndpoints(c.Request.Context())
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"endpoints": endpoints,
+		"total":     len(endpoints),
+		"events":    webhooks.Events,
+	})
+}
+
+// GetEndpoint returns a webhook endpoint
+func (h *WebhookHandler) GetEndpoint(c *gin.Context) {
+	endpoint, ok := h.endpoint(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// UpdateEndpoint changes an endpoint's URL, description, events or whether
+// it is active
+func (h *WebhookHandler) UpdateEndpoint(c *gin.Context) {
+	var req models.UpdateWebhookRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	endpoint, ok := h.endpoint(c)
+	if !ok {
+		return
+	}
+	if req.URL != nil {
+		if !validWebhookURL(*req.URL) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "url must be an http or https URL",
+			})
+			return
+		}
+		endpoint.URL = *req.URL
+	}
+	if req.Description != nil {
+		endpoint.Description = *req.Description
+	}
+	if req.Events != nil {
+		events, ok := webhookEvents(c, *req.Events)
+		if !ok {
+			return
+		}
+		endpoint.Events = events
+	}
+	if req.Active != nil {
+		endpoint.Active = *req.Active
+	}
+
+	if err := h.webhookRepo.UpdateEndpoint(c.Request.Context(), endpoint); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Webhook endpoint not found",
+			})
+			return
+		}
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// DeleteEndpoint removes a webhook endpoint
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.webhookRepo.DeleteEndpoint(c.Request.Context(), id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Webhook endpoint not found",
+			})
+			return
+		}
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Webhook endpoint deleted successfully",
+		"endpoint_id": id,
+	})
+}
+
+// ListDeliveries returns a page of an endpoint's delivery log, newest first
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	endpoint, ok := h.endpoint(c)
+	if !ok {
+		return
+	}
+
+	deliveries, total, err := h.webhookRepo.ListDeliveries(c.Request.Context(), endpoint.ID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"total":      total,
+		"page":       page,
+		"page_size":  pageSize,
+	})
+}
+
+// Redeliver queues another delivery of a logged event to its endpoint, with
+// the same event ID and payload
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	if h.dispatcher == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Webhooks are disabled",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	original, err := h.webhookRepo.GetDelivery(ctx, c.Param("deliveryID"))
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+	if original == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Webhook delivery not found",
+		})
+		return
+	}
+
+	delivery, err := h.dispatcher.Redeliver(ctx, original)
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// endpoint loads the endpoint named in the path, responding 404 if there is
+// none
+func (h *WebhookHandler) endpoint(c *gin.Context) (*models.WebhookEndpoint, bool) {
+	endpoint, err := h.webhookRepo.GetEndpoint(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondInternalError(c)
+		return nil, false
+	}
+	if endpoint == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Webhook endpoint not found",
+		})
+		return nil, false
+	}
+	return endpoint, true
+}
+
+// webhookEvents validates the events an endpoint subscribes to and drops
+// duplicates, responding 400 on an unknown event
+func webhookEvents(c *gin.Context, requested models.StringList) (models.StringList, bool) {
+	events := make(models.StringList, 0, len(requested))
+	seen := make(map[string]bool, len(requested))
+	for _, event := range requested {
+		if !webhooks.ValidEvent(event) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":  "Unknown event " + event,
+				"events": webhooks.Events,
+			})
+			return nil, false
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	return events, true
+}
@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/webhooks"
+)
+
+func TestMemoryQueueClaimsInOrder(t *testing.T) {
+	ctx := auth.ForOrganization(context.Background(), "org1")
+	q := NewMemoryQueue()
+
+	first := &models.Job{UserID: "u1"}
+	second := &models.Job{UserID: "u1"}
+	require.NoError(t, q.Enqueue(ctx, first))
+	require.NoError(t, q.Enqueue(ctx, second))
+
+	job, err := q.Claim(context.Background(), time.Minute, 3)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, first.ID, job.ID)
+	assert.Equal(t, models.JobRunning, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+
+	job, _ = q.Claim(context.Background(), time.Minute, 3)
+	assert.Equal(t, second.ID, job.ID)
+	job, _ = q.Claim(context.Background(), time.Minute, 3)
+	assert.Nil(t, job, "both jobs are leased")
+}
+
+func TestMemoryQueueReclaimsExpiredLeases(t *testing.T) {
+	ctx := auth.ForOrganization(context.Background(), "org1")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	q := NewMemoryQueue()
+	q.now = func() time.Time { return now }
+
+	require.NoError(t, q.Enqueue(ctx, &models.Job{UserID: "u1"}))
+	abandoned, _ := q.Claim(ctx, time.Minute, 2)
+	require.NotNil(t, abandoned)
+
+	now = now.Add(time.Minute)
+	retried, _ := q.Claim(ctx, time.Minute, 2)
+	require.NotNil(t, retried)
+	assert.Equal(t, 2, retried.Attempts)
+
+	abandoned.Status = models.JobSucceeded
+	require.NoError(t, q.Finish(ctx, abandoned))
+	stored, _ := q.GetJob(ctx, retried.ID)
+	assert.Equal(t, models.JobRunning, stored.Status, "a stale worker cannot finish a reclaimed job")
+
+	now = now.Add(time.Minute)
+	job, _ := q.Claim(ctx, time.Minute, 2)
+	assert.Nil(t, job)
+	stored, _ = q.GetJob(ctx, retried.ID)
+	assert.Equal(t, models.JobFailed, stored.Status)
+	assert.Equal(t, AbandonedMessage, stored.Error)
+}
+
+func TestMemoryQueueGetJobIsPerOrganization(t *testing.T) {
+	org1 := auth.ForOrganization(context.Background(), "org1")
+	org2 := auth.ForOrganization(context.Background(), "org2")
+	q := NewMemoryQueue()
+
+	job := &models.Job{UserID: "u1"}
+	require.NoError(t, q.Enqueue(org1, job))
+
+	found, err := q.GetJob(org1, job.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, found)
+	found, err = q.GetJob(org2, job.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	assert.ErrorIs(t, q.Enqueue(context.Background(), &models.Job{}), ErrNoOrganization)
+}
+
+type fakeProcessor struct {
+	err error
+}
+
+func (p *fakeProcessor) ProcessJob(ctx context.Context, job *models.Job) error {
+	identity, _ := auth.FromContext(ctx)
+	if identity.UserID != job.UserID {
+		return errors.New("job runs as the wrong user")
+	}
+	reply := "reply-" + job.ID
+	job.ReplyID = &reply
+	return p.err
+}
+
+func (p *fakeProcessor) JobReport(ctx context.Context, job *models.Job) (interface{}, error) {
+	return map[string]string{"id": job.ID, "status": job.Status, "error": job.Error}, nil
+}
+
+// keySecrets holds the webhook secrets of API keys
+type keySecrets map[string]string
+
+func (s keySecrets) WebhookSecret(ctx context.Context, apiKeyID string) (string, error) {
+	return s[apiKeyID], nil
+}
+
+func TestPoolProcessesJobsAndCallsWebhooks(t *testing.T) {
+	reports := make(chan map[string]string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, webhooks.Verify("whsec_key1", r.Header.Get(webhooks.HeaderSignature), body, time.Now(), time.Minute))
+		var report map[string]string
+		if err := json.Unmarshal(body, &report); err == nil {
+			assert.Equal(t, report["id"], r.Header.Get("X-Job-ID"))
+			reports <- report
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := NewMemoryQueue()
+	pool := NewPool(q, keySecrets{"key1": "whsec_key1"},
+		PoolOptions{Workers: 2, PollInterval: 10 * time.Millisecond, Lease: time.Minute, MaxAttempts: 1, WebhookTimeout: time.Second, AllowPrivateNetworks: true},
+		logger.NewLogrusLogger("error", "text"))
+	done := make(chan struct{})
+	go func() {
+		pool.Start(ctx, &fakeProcessor{})
+		close(done)
+	}()
+
+	orgCtx := auth.ForOrganization(context.Background(), "org1")
+	job := &models.Job{UserID: "u1", APIKeyID: "key1", WebhookURL: server.URL}
+	require.NoError(t, pool.Enqueue(orgCtx, job))
+
+	select {
+	case report := <-reports:
+		assert.Equal(t, job.ID, report["id"])
+		assert.Equal(t, models.JobSucceeded, report["status"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+
+	stored, err := pool.GetJob(orgCtx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobSucceeded, stored.Status)
+	require.NotNil(t, stored.ReplyID)
+	assert.Equal(t, "reply-"+job.ID, *stored.ReplyID)
+
+	cancel()
+	<-done
+}
+
+func TestPoolRecordsFailures(t *testing.T) {
+	ctx := auth.ForOrganization(context.Background(), "org1")
+	q := NewMemoryQueue()
+	pool := NewPool(q, keySecrets{}, PoolOptions{Lease: time.Minute}, logger.NewLogrusLogger("error", "text"))
+
+	job := &models.Job{UserID: "u1"}
+	require.NoError(t, pool.Enqueue(ctx, job))
+	claimed, _ := q.Claim(ctx, time.Minute, 1)
+	pool.run(context.Background(), &fakeProcessor{err: errors.New("provider down")}, claimed)
+
+	stored, _ := q.GetJob(ctx, job.ID)
+	assert.Equal(t, models.JobFailed, stored.Status)
+	assert.Equal(t, "provider down", stored.Error)
+	assert.NotNil(t, stored.FinishedAt)
+}
+
+func TestPoolRefusesPrivateWebhooks(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	pool := NewPool(NewMemoryQueue(), keySecrets{"key1": "whsec_key1"}, PoolOptions{WebhookTimeout: time.Second}, logger.NewLogrusLogger("error", "text"))
+	job := &models.Job{ID: "job-1", APIKeyID: "key1", WebhookURL: server.URL}
+	err := pool.post(context.Background(), job, "whsec_key1", []byte(`{}`))
+	assert.ErrorIs(t, err, webhooks.ErrForbiddenAddress)
+	assert.False(t, called)
+}
@@ -0,0 +1,136 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// finishedRetention is how long a MemoryQueue keeps finished jobs readable
+const finishedRetention = 24 * time.Hour
+
+// MemoryQueue is a Queue kept in process memory. Jobs are lost on restart and
+// are not shared between instances, so it suits development and tests.
+// Finished jobs are forgotten after a day.
+type MemoryQueue struct {
+	mu    sync.Mutex
+	jobs  map[string]*models.Job
+	order []string
+	now   func() time.Time
+}
+
+// NewMemoryQueue creates an empty in-memory queue
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		jobs: make(map[string]*models.Job),
+		now:  time.Now,
+	}
+}
+
+// Enqueue implements Queue
+func (q *MemoryQueue) Enqueue(ctx context.Context, job *models.Job) error {
+	orgID, ok := auth.OrganizationID(ctx)
+	if !ok {
+		return ErrNoOrganization
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job.ID == "" {
+		job.ID = models.NewID()
+	}
+	now := q.now().UTC()
+	job.OrganizationID = orgID
+	job.Status = models.JobQueued
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	stored := *job
+	q.jobs[job.ID] = &stored
+	q.order = append(q.order, job.ID)
+	return nil
+}
+
+// Claim implements Queue
+func (q *MemoryQueue) Claim(ctx context.Context, lease time.Duration, maxAttempts int) (*models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now().UTC()
+	remaining := q.order[:0]
+	var claimed *models.Job
+	for _, id := range q.order {
+		job := q.jobs[id]
+		expired := job.Status == models.JobRunning && job.LeaseUntil != nil && !now.Before(*job.LeaseUntil)
+		if expired && job.Attempts >= maxAttempts {
+			job.Status = models.JobFailed
+			job.Error = AbandonedMessage
+			job.LeaseUntil = nil
+			job.FinishedAt = &now
+			job.UpdatedAt = now
+		}
+		if job.Finished() {
+			continue
+		}
+		remaining = append(remaining, id)
+
+		if claimed == nil && (job.Status == models.JobQueued || expired) {
+			until := now.Add(lease)
+			job.Status = models.JobRunning
+			job.Attempts++
+			job.LeaseUntil = &until
+			job.StartedAt = &now
+			job.UpdatedAt = now
+			copied := *job
+			claimed = &copied
+		}
+	}
+	q.order = remaining
+	return claimed, nil
+}
+
+// Finish implements Queue
+func (q *MemoryQueue) Finish(ctx context.Context, job *models.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stored, ok := q.jobs[job.ID]
+	if !ok || stored.Status != models.JobRunning || stored.Attempts != job.Attempts {
+		return nil
+	}
+	now := q.now().UTC()
+	for id, old := range q.jobs {
+		if old.FinishedAt != nil && now.Sub(*old.FinishedAt) > finishedRetention {
+			delete(q.jobs, id)
+		}
+	}
+	stored.Status = job.Status
+	stored.Error = job.Error
+	stored.ReplyID = job.ReplyID
+	stored.LeaseUntil = nil
+	stored.FinishedAt = &now
+	stored.UpdatedAt = now
+	return nil
+}
+
+// GetJob implements Queue
+func (q *MemoryQueue) GetJob(ctx context.Context, id string) (*models.Job, error) {
+	orgID, ok := auth.OrganizationID(ctx)
+	if !ok {
+		return nil, ErrNoOrganization
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok || job.OrganizationID != orgID {
+		return nil, nil
+	}
+	copied := *job
+	return &copied, nil
+}
@@ -0,0 +1,272 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/webhooks"
+)
+
+// webhookAttempts is how often a job's webhook is tried before giving up
+const webhookAttempts = 3
+
+// Processor runs jobs
+type Processor interface {
+	// ProcessJob does the work of a job, recording what it produced on it
+	ProcessJob(ctx context.Context, job *models.Job) error
+	// JobReport returns the body sent to a finished job's webhook
+	JobReport(ctx context.Context, job *models.Job) (interface{}, error)
+}
+
+// SecretSource finds the secret a job's webhook calls are signed with
+type SecretSource interface {
+	// WebhookSecret returns the secret of the API key a job was queued with
+	// in the organization in the context, or "" if it has none
+	WebhookSecret(ctx context.Context, apiKeyID string) (string, error)
+}
+
+// PoolOptions configures a Pool. Lease must exceed Timeout, or jobs still
+// being worked on are handed to a second worker.
+type PoolOptions struct {
+	Workers      int
+	PollInterval time.Duration
+	// Timeout bounds each job; zero means no limit
+	Timeout     time.Duration
+	Lease       time.Duration
+	MaxAttempts int
+	// WebhookTimeout bounds each webhook call
+	WebhookTimeout time.Duration
+	// AllowPrivateNetworks lets webhooks call loopback and private addresses
+	AllowPrivateNetworks bool
+}
+
+// Pool runs a fixed number of workers that claim jobs from a queue and hand
+// them to a processor. Each job runs as the identity that queued it.
+type Pool struct {
+	queue   Queue
+	secrets SecretSource
+	opts    PoolOptions
+	client  *http.Client
+	logger  logger.Logger
+	wake    chan struct{}
+}
+
+// NewPool creates a worker pool. Jobs can be queued before it is started.
+// Webhook calls are signed with the secret secrets holds for the job's API
+// key.
+func NewPool(queue Queue, secrets SecretSource, opts PoolOptions, logger logger.Logger) *Pool {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	return &Pool{
+		queue:   queue,
+		secrets: secrets,
+		opts:    opts,
+		client:  webhooks.NewClient(opts.WebhookTimeout, opts.AllowPrivateNetworks),
+		logger:  logger,
+		wake:    make(chan struct{}, opts.Workers),
+	}
+}
+
+// Enqueue queues a job and wakes an idle worker
+func (p *Pool) Enqueue(ctx context.Context, job *models.Job) error {
+	if err := p.queue.Enqueue(ctx, job); err != nil {
+		return err
+	}
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// GetJob returns a job of the organization in the context, or nil
+func (p *Pool) GetJob(ctx context.Context, id string) (*models.Job, error) {
+	return p.queue.GetJob(ctx, id)
+}
+
+// Start runs the workers, handing jobs to processor, until ctx is cancelled
+// and waits for them to stop. Jobs interrupted by the shutdown are left
+// running and are picked up again once their lease expires.
+func (p *Pool) Start(ctx context.Context, processor Processor) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx, processor)
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *Pool) work(ctx context.Context, processor Processor) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-p.wake:
+		}
+
+		for ctx.Err() == nil {
+			job, err := p.queue.Claim(ctx, p.opts.Lease, p.opts.MaxAttempts)
+			if err != nil {
+				if ctx.Err() == nil {
+					p.logger.Error("Failed to claim job", logger.F("error", err.Error()))
+				}
+				break
+			}
+			if job == nil {
+				break
+			}
+			p.run(ctx, processor, job)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(p.opts.PollInterval)
+	}
+}
+
+// run processes one claimed job and records its outcome
+func (p *Pool) run(ctx context.Context, processor Processor, job *models.Job) {
+	ctx = auth.WithIdentity(ctx, auth.Identity{
+		UserID:         job.UserID,
+		OrganizationID: job.OrganizationID,
+		APIKeyID:       job.APIKeyID,
+		Role:           auth.Role(job.Role),
+	})
+
+	err := process(ctx, processor, job, p.opts.Timeout)
+	if ctx.Err() != nil {
+		return
+	}
+
+	job.Status = models.JobSucceeded
+	if err != nil {
+		job.Status = models.JobFailed
+		job.Error = err.Error()
+		p.logger.Error("Job failed",
+			logger.F("job_id", job.ID),
+			logger.F("attempt", job.Attempts),
+			logger.F("error", err.Error()),
+		)
+	}
+	if err := p.queue.Finish(ctx, job); err != nil {
+		p.logger.Error("Failed to record job outcome",
+			logger.F("job_id", job.ID),
+			logger.F("error", err.Error()),
+		)
+		return
+	}
+
+	if job.WebhookURL != "" {
+		p.notify(ctx, processor, job)
+	}
+}
+
+func process(ctx context.Context, processor Processor, job *models.Job, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return processor.ProcessJob(ctx, job)
+}
+
+// notify posts a finished job's report to its webhook, signed like event
+// deliveries, retrying failures with a growing delay. Jobs whose API key has
+// no secret are not reported.
+func (p *Pool) notify(ctx context.Context, processor Processor, job *models.Job) {
+	secret, err := p.secrets.WebhookSecret(ctx, job.APIKeyID)
+	if err != nil {
+		p.logger.Error("Failed to load the job webhook secret",
+			logger.F("job_id", job.ID),
+			logger.F("error", err.Error()),
+		)
+		return
+	}
+	if secret == "" {
+		p.logger.Warn("Job webhook skipped: the API key has no webhook secret",
+			logger.F("job_id", job.ID),
+		)
+		return
+	}
+
+	report, err := processor.JobReport(ctx, job)
+	if err != nil {
+		p.logger.Error("Failed to build job report",
+			logger.F("job_id", job.ID),
+			logger.F("error", err.Error()),
+		)
+		return
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		p.logger.Error("Failed to encode job report",
+			logger.F("job_id", job.ID),
+			logger.F("error", err.Error()),
+		)
+		return
+	}
+
+	delay := time.Second
+	for attempt := 1; ; attempt++ {
+		err = p.post(ctx, job, secret, body)
+		if err == nil {
+			return
+		}
+		if attempt == webhookAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	p.logger.Warn("Job webhook failed",
+		logger.F("job_id", job.ID),
+		logger.F("error", err.Error()),
+	)
+}
+
+func (p *Pool) post(ctx context.Context, job *models.Job, secret string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Job-ID", job.ID)
+	req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(secret, time.Now(), body))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
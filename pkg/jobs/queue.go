@@ -0,0 +1,35 @@
+// Package jobs runs slow work, such as generating replies that call long tool
+// chains, in a pool of background workers fed by a queue
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// Queue holds jobs until a worker claims them
+type Queue interface {
+	// Enqueue stores a new job for the organization in the context
+	Enqueue(ctx context.Context, job *models.Job) error
+	// Claim hands the oldest waiting job to the caller, marking it running
+	// until lease has passed. Running jobs whose lease expired are handed out
+	// again; those that have already had maxAttempts attempts are failed
+	// instead. It returns nil when no job is waiting.
+	Claim(ctx context.Context, lease time.Duration, maxAttempts int) (*models.Job, error)
+	// Finish records the outcome of a claimed job. It is ignored if the job
+	// has since been claimed again.
+	Finish(ctx context.Context, job *models.Job) error
+	// GetJob returns a job of the organization in the context, or nil
+	GetJob(ctx context.Context, id string) (*models.Job, error)
+}
+
+// ErrNoOrganization is returned when a job is queued or read without an
+// organization in the context
+var ErrNoOrganization = errors.New("jobs: no organization in context")
+
+// AbandonedMessage is the error recorded on jobs abandoned by their workers
+// too often
+const AbandonedMessage = "job was abandoned by its worker too many times"
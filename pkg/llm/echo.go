@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"context"
+	"strings"
+)
+
+// EchoProvider answers every message by quoting it back. It is the default
+// provider until a real model is configured and is useful in tests.
+type EchoProvider struct{}
+
+// NewEchoProvider creates a new echo provider
+func NewEchoProvider() *EchoProvider {
+	return &EchoProvider{}
+}
+
+// Name implements Provider
+func (p *EchoProvider) Name() string {
+	return "echo"
+}
+
+// Complete implements Provider
+func (p *EchoProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	last, ok := LastUserMessage(req.Messages)
+	if !ok {
+		return nil, ErrEmptyConversation
+	}
+
+	content := "This is a response to: " + last
+
+	model := req.Model
+	if model == "" {
+		model = p.Name()
+	}
+
+	var prompt int
+	for _, m := range req.Messages {
+		prompt += countTokens(m.Content)
+	}
+
+	return &Response{
+		Content:  content,
+		Model:    model,
+		Provider: p.Name(),
+		Usage: Usage{
+			PromptTokens:     prompt,
+			CompletionTokens: countTokens(content),
+		},
+	}, nil
+}
+
+// Stream implements Streamer, sending the answer a word at a time
+func (p *EchoProvider) Stream(ctx context.Context, req Request, onDelta func(string) error) (*Response, error) {
+	resp, err := p.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range strings.SplitAfter(resp.Content, " ") {
+		if err := onDelta(word); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// countTokens approximates a token count by counting words
+func countTokens(s string) int {
+	return len(strings.Fields(s))
+}
@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+)
+
+// echoDimensions is the size of the echo provider's embeddings
+const echoDimensions = 256
+
+// EmbedRequest asks for one embedding per input text
+type EmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbedResponse holds embeddings in the order of the request's inputs
+type EmbedResponse struct {
+	Vectors  [][]float32 `json:"vectors"`
+	Model    string      `json:"model"`
+	Provider string      `json:"provider"`
+	Usage    Usage       `json:"usage"`
+}
+
+// Embedder turns text into vectors whose cosine similarity reflects how
+// close the texts are in meaning
+type Embedder interface {
+	Name() string
+	Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error)
+}
+
+// Embed implements Embedder with hashed bag-of-words vectors, which match
+// texts sharing most of their words. Real providers should be used for
+// meaning.
+func (p *EchoProvider) Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
+	resp := &EmbedResponse{
+		Vectors:  make([][]float32, len(req.Input)),
+		Model:    p.Name(),
+		Provider: p.Name(),
+	}
+	for i, text := range req.Input {
+		vector := make([]float32, echoDimensions)
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			word = strings.Trim(word, ".,;:!?\"'()")
+			if word == "" {
+				continue
+			}
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			vector[h.Sum32()%echoDimensions]++
+			resp.Usage.PromptTokens++
+		}
+		normalize(vector)
+		resp.Vectors[i] = vector
+	}
+	return resp, nil
+}
+
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+}
@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ErrCircuitOpen is returned when a provider's circuit breaker rejects a call
+var ErrCircuitOpen = errors.New("llm: provider circuit is open")
+
+// APIError is an error response from a provider's API
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("llm: %s returned %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// Retryable reports whether a failed call may succeed when retried or sent to
+// another provider: timeouts, network failures, rate limiting and 5xx
+// responses. Errors caused by the request itself, and streams that already
+// sent part of their content, are not retryable.
+func Retryable(err error) bool {
+	var streamErr *StreamError
+	if errors.As(err, &streamErr) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"errors"
+)
+
+// Role is the author of a message in a completion request
+type Role string
+
+// Message roles understood by providers
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// ErrEmptyConversation is returned when a request has no messages to answer
+var ErrEmptyConversation = errors.New("llm: conversation has no messages")
+
+// Message is a single turn of a conversation
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+// Target names a provider and the model to use on it
+type Target struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// Request describes a completion to generate. Provider and Fallbacks are
+// read by the Router; an empty Provider uses its default.
+type Request struct {
+	Provider    string    `json:"provider,omitempty"`
+	Model       string    `json:"model"`
+	Temperature float64   `json:"temperature"`
+	Messages    []Message `json:"messages"`
+	Fallbacks   []Target  `json:"fallbacks,omitempty"`
+}
+
+// Usage reports the tokens consumed by a completion
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Response is a generated completion
+type Response struct {
+	Content  string `json:"content"`
+	Model    string `json:"model"`
+	Provider string `json:"provider"`
+	Usage    Usage  `json:"usage"`
+}
+
+// Provider generates completions
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, req Request) (*Response, error)
+}
+
+// LastUserMessage returns the content of the most recent user message
+func LastUserMessage(messages []Message) (string, bool) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == RoleUser {
+			return messages[i].Content, true
+		}
+	}
+	return "", false
+}
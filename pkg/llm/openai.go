@@ -0,0 +1,251 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// maxErrorBody bounds how much of an error response is read
+	maxErrorBody = 4096
+	// maxStreamLine bounds the length of one server-sent event
+	maxStreamLine = 1 << 20
+)
+
+// OpenAIProvider calls a chat completions API compatible with OpenAI's, which
+// most hosted and self-hosted model servers offer
+type OpenAIProvider struct {
+	name    string
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewOpenAIProvider creates a provider for the API at baseURL, such as
+// https://api.openai.com/v1
+func NewOpenAIProvider(name, baseURL, apiKey string, client *http.Client) *OpenAIProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OpenAIProvider{
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  client,
+	}
+}
+
+// Name implements Provider
+func (p *OpenAIProvider) Name() string {
+	return p.name
+}
+
+type openAIRequest struct {
+	Model         string               `json:"model,omitempty"`
+	Temperature   float64              `json:"temperature"`
+	Messages      []Message            `json:"messages"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Usage Usage `json:"usage"`
+}
+
+// Complete implements Provider
+func (p *OpenAIProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	if len(req.Messages) == 0 {
+		return nil, ErrEmptyConversation
+	}
+
+	var out openAIResponse
+	err := p.post(ctx, "/chat/completions", openAIRequest{
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		Messages:    req.Messages,
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("llm: %s returned no choices", p.name)
+	}
+
+	model := out.Model
+	if model == "" {
+		model = req.Model
+	}
+	return &Response{
+		Content:  out.Choices[0].Message.Content,
+		Model:    model,
+		Provider: p.name,
+		Usage:    out.Usage,
+	}, nil
+}
+
+type openAIStreamChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta Message `json:"delta"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage"`
+}
+
+// Stream implements Streamer, reading the API's server-sent events
+func (p *OpenAIProvider) Stream(ctx context.Context, req Request, onDelta func(string) error) (*Response, error) {
+	if len(req.Messages) == 0 {
+		return nil, ErrEmptyConversation
+	}
+
+	httpResp, err := p.send(ctx, "/chat/completions", openAIRequest{
+		Model:         req.Model,
+		Temperature:   req.Temperature,
+		Messages:      req.Messages,
+		Stream:        true,
+		StreamOptions: &openAIStreamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	resp := &Response{Model: req.Model, Provider: p.name}
+	var content strings.Builder
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			resp.Content = content.String()
+			return resp, nil
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("llm: invalid stream from %s: %w", p.name, err)
+		}
+		if chunk.Model != "" {
+			resp.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		if err := onDelta(chunk.Choices[0].Delta.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("llm: %s ended the stream early: %w", p.name, io.ErrUnexpectedEOF)
+}
+
+// post sends body as JSON to the API path and decodes the response into out.
+// Error responses are returned as an APIError.
+func (p *OpenAIProvider) post(ctx context.Context, path string, body, out interface{}) error {
+	httpResp, err := p.send(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if err := json.NewDecoder(httpResp.Body).Decode(out); err != nil {
+		return fmt.Errorf("llm: invalid response from %s: %w", p.name, err)
+	}
+	return nil
+}
+
+// send posts body as JSON to the API path and returns the response for the
+// caller to read and close. Error responses are returned as an APIError.
+func (p *OpenAIProvider) send(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	httpResp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		defer httpResp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxErrorBody))
+		return nil, &APIError{
+			Provider:   p.name,
+			StatusCode: httpResp.StatusCode,
+			Message:    strings.TrimSpace(string(msg)),
+		}
+	}
+	return httpResp, nil
+}
+
+type openAIEmbedResponse struct {
+	Model string `json:"model"`
+	Data  []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage Usage `json:"usage"`
+}
+
+// Embed implements Embedder
+func (p *OpenAIProvider) Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
+	var out openAIEmbedResponse
+	if err := p.post(ctx, "/embeddings", req, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Data) != len(req.Input) {
+		return nil, fmt.Errorf("llm: %s returned %d embeddings for %d inputs", p.name, len(out.Data), len(req.Input))
+	}
+
+	vectors := make([][]float32, len(req.Input))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("llm: %s returned an embedding for unknown input %d", p.name, d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+
+	model := out.Model
+	if model == "" {
+		model = req.Model
+	}
+	return &EmbedResponse{
+		Vectors:  vectors,
+		Model:    model,
+		Provider: p.name,
+		Usage:    out.Usage,
+	}, nil
+}
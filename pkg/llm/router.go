@@ -0,0 +1,272 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+)
+
+// RetryPolicy controls how often a provider is retried before falling back.
+// Delays grow exponentially from BaseDelay up to MaxDelay, with full jitter.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// RouterOptions configures a Router
+type RouterOptions struct {
+	// Fallbacks are tried, in order, after the targets a request names
+	Fallbacks []Target
+	Retry     RetryPolicy
+	// AttemptTimeout bounds each call to a provider; zero means no limit
+	AttemptTimeout time.Duration
+	// FailureThreshold consecutive failures open a provider's circuit for Cooldown
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// Router sends each request to the provider and model it names, retrying
+// transient failures and falling back to other targets when a provider is
+// down. Every provider has its own circuit breaker, so one that keeps failing
+// is skipped until it recovers.
+type Router struct {
+	providers       map[string]Provider
+	breakers        map[string]*Breaker
+	defaultProvider string
+	opts            RouterOptions
+	logger          logger.Logger
+	sleep           func(ctx context.Context, d time.Duration) error
+}
+
+// NewRouter creates a router over providers, keyed by name. Requests that
+// name no provider go to defaultProvider.
+func NewRouter(defaultProvider string, providers map[string]Provider, opts RouterOptions, logger logger.Logger) (*Router, error) {
+	if _, ok := providers[defaultProvider]; !ok {
+		return nil, fmt.Errorf("llm: default provider %q is not configured", defaultProvider)
+	}
+	for _, target := range opts.Fallbacks {
+		if _, ok := providers[target.Provider]; !ok {
+			return nil, fmt.Errorf("llm: fallback provider %q is not configured", target.Provider)
+		}
+	}
+	if opts.Retry.MaxAttempts < 1 {
+		opts.Retry.MaxAttempts = 1
+	}
+
+	breakers := make(map[string]*Breaker, len(providers))
+	for name := range providers {
+		breakers[name] = NewBreaker(opts.FailureThreshold, opts.Cooldown)
+	}
+	return &Router{
+		providers:       providers,
+		breakers:        breakers,
+		defaultProvider: defaultProvider,
+		opts:            opts,
+		logger:          logger,
+		sleep:           sleepContext,
+	}, nil
+}
+
+// Name implements Provider
+func (r *Router) Name() string {
+	return "router"
+}
+
+// Providers returns the names of the configured providers, sorted
+func (r *Router) Providers() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Embedder returns the named provider's embedder, or the default provider's
+// when name is empty. It reports false when that provider cannot embed.
+func (r *Router) Embedder(name string) (Embedder, bool) {
+	if name == "" {
+		name = r.defaultProvider
+	}
+	embedder, ok := r.providers[name].(Embedder)
+	return embedder, ok
+}
+
+// completeFunc sends a request to one provider
+type completeFunc func(ctx context.Context, provider Provider, req Request) (*Response, error)
+
+// Complete implements Provider. Errors that retrying cannot fix, such as an
+// invalid request, are returned without trying the fallbacks.
+func (r *Router) Complete(ctx context.Context, req Request) (*Response, error) {
+	return r.route(ctx, req, func(ctx context.Context, provider Provider, req Request) (*Response, error) {
+		return provider.Complete(ctx, req)
+	})
+}
+
+// Stream implements Streamer. Until the first part has been passed to
+// onDelta it retries and falls back like Complete; a later failure ends the
+// stream with a *StreamError.
+func (r *Router) Stream(ctx context.Context, req Request, onDelta func(string) error) (*Response, error) {
+	var started bool
+	forward := func(delta string) error {
+		started = true
+		return onDelta(delta)
+	}
+	return r.route(ctx, req, func(ctx context.Context, provider Provider, req Request) (*Response, error) {
+		resp, err := Stream(ctx, provider, req, forward)
+		if err != nil && started {
+			return nil, &StreamError{Err: err}
+		}
+		return resp, err
+	})
+}
+
+// route sends req to its targets in turn until one answers
+func (r *Router) route(ctx context.Context, req Request, complete completeFunc) (*Response, error) {
+	var lastErr error
+	for i, target := range r.targets(req) {
+		provider, ok := r.providers[target.Provider]
+		if !ok {
+			r.logger.Warn("Skipping unknown provider", logger.F("provider", target.Provider))
+			continue
+		}
+		if i > 0 {
+			r.logger.Warn("Falling back to another provider",
+				logger.F("provider", target.Provider),
+				logger.F("model", target.Model),
+				logger.F("error", errString(lastErr)),
+			)
+		}
+
+		attempt := req
+		attempt.Provider = target.Provider
+		attempt.Model = target.Model
+		attempt.Fallbacks = nil
+
+		resp, err := r.try(ctx, provider, r.breakers[target.Provider], attempt, complete)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil || !Retryable(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("llm: no provider configured for %q", req.Provider)
+	}
+	return nil, fmt.Errorf("llm: all providers failed: %w", lastErr)
+}
+
+// try calls a provider up to the retry policy's number of attempts
+func (r *Router) try(ctx context.Context, provider Provider, breaker *Breaker, req Request, complete completeFunc) (*Response, error) {
+	var err error
+	for attempt := 0; attempt < r.opts.Retry.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			if err := r.sleep(ctx, r.backoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+		if !breaker.Allow() {
+			return nil, ErrCircuitOpen
+		}
+
+		var resp *Response
+		resp, err = r.call(ctx, provider, req, complete)
+		if err == nil {
+			breaker.Success()
+			return resp, nil
+		}
+		if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+			// The caller gave up; the provider may be fine or not
+			breaker.Release()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		if !Retryable(err) {
+			// The provider answered, even if it rejected the request
+			breaker.Success()
+			return nil, err
+		}
+		breaker.Failure()
+		r.logger.Warn("Provider call failed",
+			logger.F("provider", provider.Name()),
+			logger.F("attempt", attempt+1),
+			logger.F("error", err.Error()),
+		)
+	}
+	return nil, err
+}
+
+func (r *Router) call(ctx context.Context, provider Provider, req Request, complete completeFunc) (*Response, error) {
+	if r.opts.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.opts.AttemptTimeout)
+		defer cancel()
+	}
+	return complete(ctx, provider, req)
+}
+
+// targets lists where a request may be sent: the provider and model it names,
+// its own fallbacks, then the router's, without repeats
+func (r *Router) targets(req Request) []Target {
+	primary := Target{Provider: req.Provider, Model: req.Model}
+	if primary.Provider == "" {
+		primary.Provider = r.defaultProvider
+	}
+
+	candidates := append([]Target{primary}, req.Fallbacks...)
+	candidates = append(candidates, r.opts.Fallbacks...)
+
+	seen := make(map[Target]bool, len(candidates))
+	targets := candidates[:0]
+	for _, t := range candidates {
+		if !seen[t] {
+			seen[t] = true
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// backoff returns a random delay of up to BaseDelay * 2^(attempt-1), capped
+// at MaxDelay
+func (r *Router) backoff(attempt int) time.Duration {
+	ceiling := r.opts.Retry.BaseDelay << uint(attempt-1)
+	if r.opts.Retry.MaxDelay > 0 && (ceiling > r.opts.Retry.MaxDelay || ceiling <= 0) {
+		ceiling = r.opts.Retry.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
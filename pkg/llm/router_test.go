@@ -0,0 +1,324 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+)
+
+// scriptedProvider returns the queued errors in turn, then succeeds
+type scriptedProvider struct {
+	name   string
+	errs   []error
+	calls  int
+	models []string
+}
+
+func (p *scriptedProvider) Name() string { return p.name }
+
+func (p *scriptedProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	p.calls++
+	p.models = append(p.models, req.Model)
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		return nil, err
+	}
+	return &Response{Content: "ok from " + p.name, Model: req.Model, Provider: p.name}, nil
+}
+
+func newTestRouter(t *testing.T, providers map[string]Provider, opts RouterOptions) *Router {
+	t.Helper()
+	router, err := NewRouter("primary", providers, opts, logger.NewLogrusLogger("error", "text"))
+	require.NoError(t, err)
+	router.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+	return router
+}
+
+func unavailable(name string) error {
+	return &APIError{Provider: name, StatusCode: http.StatusServiceUnavailable, Message: "overloaded"}
+}
+
+func TestRouterRetriesThenSucceeds(t *testing.T) {
+	primary := &scriptedProvider{name: "primary", errs: []error{unavailable("primary")}}
+	router := newTestRouter(t, map[string]Provider{"primary": primary}, RouterOptions{
+		Retry:            RetryPolicy{MaxAttempts: 2},
+		FailureThreshold: 5,
+	})
+
+	resp, err := router.Complete(context.Background(), Request{Model: "m1"})
+	require.NoError(t, err)
+	assert.Equal(t, "primary", resp.Provider)
+	assert.Equal(t, 2, primary.calls)
+}
+
+func TestRouterFallsBack(t *testing.T) {
+	primary := &scriptedProvider{name: "primary", errs: []error{unavailable("primary"), context.DeadlineExceeded}}
+	secondary := &scriptedProvider{name: "secondary"}
+	router := newTestRouter(t, map[string]Provider{"primary": primary, "secondary": secondary}, RouterOptions{
+		Retry:            RetryPolicy{MaxAttempts: 2},
+		FailureThreshold: 5,
+	})
+
+	resp, err := router.Complete(context.Background(), Request{
+		Model:     "big",
+		Fallbacks: []Target{{Provider: "secondary", Model: "small"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "secondary", resp.Provider)
+	assert.Equal(t, []string{"small"}, secondary.models)
+}
+
+func TestRouterDoesNotFallBackOnClientErrors(t *testing.T) {
+	badRequest := &APIError{Provider: "primary", StatusCode: http.StatusBadRequest, Message: "bad model"}
+	primary := &scriptedProvider{name: "primary", errs: []error{badRequest}}
+	secondary := &scriptedProvider{name: "secondary"}
+	router := newTestRouter(t, map[string]Provider{"primary": primary, "secondary": secondary}, RouterOptions{
+		Fallbacks: []Target{{Provider: "secondary"}},
+		Retry:     RetryPolicy{MaxAttempts: 3},
+	})
+
+	_, err := router.Complete(context.Background(), Request{})
+	assert.ErrorIs(t, err, error(badRequest))
+	assert.Equal(t, 1, primary.calls)
+	assert.Zero(t, secondary.calls)
+}
+
+func TestRouterSkipsOpenCircuit(t *testing.T) {
+	primary := &scriptedProvider{name: "primary", errs: []error{unavailable("primary"), unavailable("primary")}}
+	secondary := &scriptedProvider{name: "secondary"}
+	router := newTestRouter(t, map[string]Provider{"primary": primary, "secondary": secondary}, RouterOptions{
+		Fallbacks:        []Target{{Provider: "secondary"}},
+		Retry:            RetryPolicy{MaxAttempts: 3},
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+	})
+
+	_, err := router.Complete(context.Background(), Request{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, primary.calls, "the open circuit stops further retries")
+	assert.True(t, router.breakers["primary"].Open())
+
+	_, err = router.Complete(context.Background(), Request{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, primary.calls, "an open circuit is skipped")
+	assert.Equal(t, 2, secondary.calls)
+}
+
+func TestRouterAllProvidersFail(t *testing.T) {
+	primary := &scriptedProvider{name: "primary", errs: []error{unavailable("primary")}}
+	router := newTestRouter(t, map[string]Provider{"primary": primary}, RouterOptions{FailureThreshold: 5})
+
+	_, err := router.Complete(context.Background(), Request{})
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+}
+
+func TestRouterReleasesProbeOnCancel(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for name, cancelled := range map[string]error{
+		"parent context": nil,
+		"bare canceled":  context.Canceled,
+	} {
+		t.Run(name, func(t *testing.T) {
+			primary := &scriptedProvider{name: "primary"}
+			router := newTestRouter(t, map[string]Provider{"primary": primary}, RouterOptions{
+				Retry:            RetryPolicy{MaxAttempts: 1},
+				FailureThreshold: 1,
+				Cooldown:         time.Minute,
+			})
+			breaker := router.breakers["primary"]
+			breaker.now = func() time.Time { return now }
+			breaker.Failure()
+			now = now.Add(time.Minute)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if cancelled == nil {
+				cancel()
+				primary.errs = []error{context.Canceled}
+			} else {
+				primary.errs = []error{cancelled}
+			}
+			_, err := router.Complete(ctx, Request{})
+			cancel()
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.True(t, breaker.Open(), "a cancelled probe does not close the circuit")
+
+			// The probe was given up, so the next call may probe again
+			_, err = router.Complete(context.Background(), Request{})
+			require.NoError(t, err)
+			assert.False(t, breaker.Open())
+		})
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	b := NewBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.Failure()
+	assert.False(t, b.Allow())
+
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow(), "one probe is let through after the cooldown")
+	assert.False(t, b.Allow(), "only one probe at a time")
+
+	b.Failure()
+	assert.False(t, b.Allow(), "a failed probe reopens the circuit")
+
+	now = now.Add(time.Minute)
+	require.True(t, b.Allow())
+	b.Release()
+	assert.True(t, b.Open(), "a released probe leaves the circuit open")
+	require.True(t, b.Allow(), "and lets the next call probe")
+	b.Success()
+	assert.True(t, b.Allow())
+	assert.False(t, b.Open())
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var req openAIRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Model == "broken" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"model":   req.Model,
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "Hi!"}}},
+			"usage":   map[string]int{"prompt_tokens": 3, "completion_tokens": 2},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("vendor", server.URL+"/v1/", "secret", server.Client())
+	messages := []Message{{Role: RoleUser, Content: "Hello"}}
+
+	resp, err := provider.Complete(context.Background(), Request{Model: "gpt-x", Messages: messages})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi!", resp.Content)
+	assert.Equal(t, "vendor", resp.Provider)
+	assert.Equal(t, Usage{PromptTokens: 3, CompletionTokens: 2}, resp.Usage)
+
+	_, err = provider.Complete(context.Background(), Request{Model: "broken", Messages: messages})
+	assert.True(t, Retryable(err))
+}
+
+func TestOpenAIProviderStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, req.Stream)
+		require.NotNil(t, req.StreamOptions)
+		assert.True(t, req.StreamOptions.IncludeUsage)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"model\":\"gpt-x-1\",\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"lo!\"}}]}\n\n")
+		if req.Model == "cut" {
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":2}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("vendor", server.URL, "", server.Client())
+	messages := []Message{{Role: RoleUser, Content: "Hello"}}
+
+	var deltas []string
+	resp, err := provider.Stream(context.Background(), Request{Model: "gpt-x", Messages: messages}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hel", "lo!"}, deltas)
+	assert.Equal(t, "Hello!", resp.Content)
+	assert.Equal(t, "gpt-x-1", resp.Model)
+	assert.Equal(t, Usage{PromptTokens: 3, CompletionTokens: 2}, resp.Usage)
+
+	_, err = provider.Stream(context.Background(), Request{Model: "cut", Messages: messages}, func(string) error { return nil })
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "a stream without [DONE] is incomplete")
+}
+
+// streamingProvider streams its parts, failing after failAfter of them when
+// failAfter is not negative
+type streamingProvider struct {
+	scriptedProvider
+	parts     []string
+	failAfter int
+}
+
+func (p *streamingProvider) Stream(ctx context.Context, req Request, onDelta func(string) error) (*Response, error) {
+	p.calls++
+	for i, part := range p.parts {
+		if i == p.failAfter {
+			return nil, unavailable(p.name)
+		}
+		if err := onDelta(part); err != nil {
+			return nil, err
+		}
+	}
+	return &Response{Content: strings.Join(p.parts, ""), Provider: p.name}, nil
+}
+
+func TestRouterStream(t *testing.T) {
+	opts := RouterOptions{Retry: RetryPolicy{MaxAttempts: 2}, FailureThreshold: 5}
+	collect := func(deltas *[]string) func(string) error {
+		return func(delta string) error {
+			*deltas = append(*deltas, delta)
+			return nil
+		}
+	}
+
+	// Failing before the first part, the stream is retried elsewhere
+	primary := &streamingProvider{scriptedProvider: scriptedProvider{name: "primary"}, parts: []string{"a"}, failAfter: 0}
+	secondary := &streamingProvider{scriptedProvider: scriptedProvider{name: "secondary"}, parts: []string{"b", "c"}, failAfter: -1}
+	router := newTestRouter(t, map[string]Provider{"primary": primary, "secondary": secondary}, opts)
+	var deltas []string
+	resp, err := router.Stream(context.Background(), Request{Fallbacks: []Target{{Provider: "secondary"}}}, collect(&deltas))
+	require.NoError(t, err)
+	assert.Equal(t, "secondary", resp.Provider)
+	assert.Equal(t, []string{"b", "c"}, deltas)
+	assert.Equal(t, 2, primary.calls)
+
+	// After the first part it is not, as the parts cannot be taken back
+	primary = &streamingProvider{scriptedProvider: scriptedProvider{name: "primary"}, parts: []string{"a", "b"}, failAfter: 1}
+	secondary = &streamingProvider{scriptedProvider: scriptedProvider{name: "secondary"}, parts: []string{"c"}, failAfter: -1}
+	router = newTestRouter(t, map[string]Provider{"primary": primary, "secondary": secondary}, opts)
+	deltas = nil
+	_, err = router.Stream(context.Background(), Request{Fallbacks: []Target{{Provider: "secondary"}}}, collect(&deltas))
+	var streamErr *StreamError
+	assert.ErrorAs(t, err, &streamErr)
+	assert.False(t, Retryable(err))
+	assert.Equal(t, []string{"a"}, deltas)
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, 0, secondary.calls)
+
+	// Providers that cannot stream send their completion whole
+	plain := &scriptedProvider{name: "primary"}
+	router = newTestRouter(t, map[string]Provider{"primary": plain}, opts)
+	deltas = nil
+	_, err = router.Stream(context.Background(), Request{}, collect(&deltas))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ok from primary"}, deltas)
+}
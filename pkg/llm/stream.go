@@ -0,0 +1,49 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// Streamer is a Provider that can send a completion while it is generated
+type Streamer interface {
+	Provider
+	// Stream generates a completion like Complete, passing each part of the
+	// content to onDelta as it arrives. An error from onDelta ends the
+	// stream and is returned.
+	Stream(ctx context.Context, req Request, onDelta func(string) error) (*Response, error)
+}
+
+// StreamError is a failure after part of a completion was streamed. The
+// parts cannot be taken back, so it is neither retried nor sent to another
+// provider.
+type StreamError struct {
+	Err error
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("llm: stream interrupted: %v", e.Err)
+}
+
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
+
+// Stream generates a completion with provider, streaming it to onDelta if
+// the provider is a Streamer. Other providers' completions are passed to
+// onDelta whole once they are complete.
+func Stream(ctx context.Context, provider Provider, req Request, onDelta func(string) error) (*Response, error) {
+	if streamer, ok := provider.(Streamer); ok {
+		return streamer.Stream(ctx, req, onDelta)
+	}
+	resp, err := provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Content != "" {
+		if err := onDelta(resp.Content); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
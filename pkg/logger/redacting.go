@@ -0,0 +1,67 @@
+package logger
+
+import "fmt"
+
+// RedactingLogger scrubs messages and field values before passing them to
+// another logger
+type RedactingLogger struct {
+	next   Logger
+	redact func(string) string
+}
+
+// NewRedactingLogger wraps next so every message and string, error or
+// Stringer field value is passed through redact first
+func NewRedactingLogger(next Logger, redact func(string) string) Logger {
+	return &RedactingLogger{next: next, redact: redact}
+}
+
+func (l *RedactingLogger) Debug(msg string, fields ...Field) {
+	l.next.Debug(l.redact(msg), l.fields(fields)...)
+}
+
+func (l *RedactingLogger) Info(msg string, fields ...Field) {
+	l.next.Info(l.redact(msg), l.fields(fields)...)
+}
+
+func (l *RedactingLogger) Warn(msg string, fields ...Field) {
+	l.next.Warn(l.redact(msg), l.fields(fields)...)
+}
+
+func (l *RedactingLogger) Error(msg string, fields ...Field) {
+	l.next.Error(l.redact(msg), l.fields(fields)...)
+}
+
+func (l *RedactingLogger) Fatal(msg string, fields ...Field) {
+	l.next.Fatal(l.redact(msg), l.fields(fields)...)
+}
+
+func (l *RedactingLogger) WithFields(fields ...Field) Logger {
+	return &RedactingLogger{next: l.next.WithFields(l.fields(fields)...), redact: l.redact}
+}
+
+func (l *RedactingLogger) fields(fields []Field) []Field {
+	redacted := make([]Field, len(fields))
+	for i, field := range fields {
+		redacted[i] = Field{Key: field.Key, Value: l.value(field.Value)}
+	}
+	return redacted
+}
+
+func (l *RedactingLogger) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return l.redact(v)
+	case []string:
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i] = l.redact(s)
+		}
+		return out
+	case error:
+		return l.redact(v.Error())
+	case fmt.Stringer:
+		return l.redact(v.String())
+	default:
+		return v
+	}
+}
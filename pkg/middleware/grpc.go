@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryAuthInterceptor is AuthMiddleware for unary gRPC calls: it
+// authenticates an "authorization: Bearer <key>" metadata entry and stores
+// the identity in the call's context
+func UnaryAuthInterceptor(resolver IdentityResolver, log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticateRPC(ctx, resolver, info.FullMethod, log)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor is UnaryAuthInterceptor for streaming gRPC calls
+func StreamAuthInterceptor(resolver IdentityResolver, log logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateRPC(ss.Context(), resolver, info.FullMethod, log)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// UnaryPermissionInterceptor is RequirePermission for unary gRPC calls. It
+// must run after UnaryAuthInterceptor.
+func UnaryPermissionInterceptor(perm auth.Permission) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorizeRPC(ctx, perm); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamPermissionInterceptor is UnaryPermissionInterceptor for streaming
+// gRPC calls
+func StreamPermissionInterceptor(perm auth.Permission) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorizeRPC(ss.Context(), perm); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// UnaryAuditInterceptor is AuditMiddleware for unary gRPC calls: it makes the
+// client address available to audited repository writes, and records every
+// call except those listed in skip, as full method names, and every call
+// that is denied permission. It must run after UnaryAuthInterceptor.
+func UnaryAuditInterceptor(recorder AuditRecorder, skip ...string) grpc.UnaryServerInterceptor {
+	skipped := skipMethods(skip)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = audit.WithRequest(ctx, rpcRequest(ctx))
+		resp, err := handler(ctx, req)
+		recordRPC(ctx, recorder, skipped, info.FullMethod, err)
+		return resp, err
+	}
+}
+
+// StreamAuditInterceptor is UnaryAuditInterceptor for streaming gRPC calls
+func StreamAuditInterceptor(recorder AuditRecorder, skip ...string) grpc.StreamServerInterceptor {
+	skipped := skipMethods(skip)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := audit.WithRequest(ss.Context(), rpcRequest(ss.Context()))
+		err := handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+		recordRPC(ctx, recorder, skipped, info.FullMethod, err)
+		return err
+	}
+}
+
+// UnaryRecoveryInterceptor recovers from panics in unary gRPC calls
+func UnaryRecoveryInterceptor(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer recoverRPC(info.FullMethod, log, &err)
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecoveryInterceptor recovers from panics in streaming gRPC calls
+func StreamRecoveryInterceptor(log logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverRPC(info.FullMethod, log, &err)
+		return handler(srv, ss)
+	}
+}
+
+func authenticateRPC(ctx context.Context, resolver IdentityResolver, method string, log logger.Logger) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "Missing or malformed authorization metadata")
+	}
+	token, found := strings.CutPrefix(values[0], "Bearer ")
+	if !found || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "Missing or malformed authorization metadata")
+	}
+
+	identity, err := resolver.ResolveAPIKey(ctx, token)
+	if errors.Is(err, auth.ErrInvalidKey) {
+		log.Warn("Authentication failed", logger.F("method", method))
+		return nil, status.Error(codes.Unauthenticated, "Invalid API key")
+	}
+	if err != nil {
+		log.Error("Failed to resolve API key", logger.F("error", err.Error()))
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	return auth.WithIdentity(ctx, *identity), nil
+}
+
+func authorizeRPC(ctx context.Context, perm auth.Permission) error {
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "Authentication required")
+	}
+	if !identity.Can(perm) {
+		return status.Error(codes.PermissionDenied, "Forbidden")
+	}
+	return nil
+}
+
+func skipMethods(skip []string) map[string]bool {
+	skipped := make(map[string]bool, len(skip))
+	for _, method := range skip {
+		skipped[method] = true
+	}
+	return skipped
+}
+
+// rpcRequest returns the audit details of the call in ctx
+func rpcRequest(ctx context.Context) audit.Request {
+	request := audit.Request{}
+	if p, ok := peer.FromContext(ctx); ok {
+		request.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(request.IP); err == nil {
+			request.IP = host
+		}
+	}
+	return request
+}
+
+func recordRPC(ctx context.Context, recorder AuditRecorder, skipped map[string]bool, method string, err error) {
+	code := status.Code(err)
+	if code != codes.PermissionDenied && skipped[method] {
+		return
+	}
+	if _, ok := auth.OrganizationID(ctx); !ok {
+		return
+	}
+
+	// Failures are logged by the recorder; the call has already returned
+	_ = recorder.Record(ctx, &models.AuditEvent{
+		Action:     audit.ActionCall,
+		TargetType: "rpc",
+		TargetID:   method,
+		Metadata: models.AuditMetadata{
+			"code": code.String(),
+		},
+	})
+}
+
+func recoverRPC(method string, log logger.Logger, err *error) {
+	if recovered := recover(); recovered != nil {
+		log.Error("Panic recovered",
+			logger.F("error", recovered),
+			logger.F("method", method),
+		)
+		*err = status.Error(codes.Internal, "Internal server error")
+	}
+}
+
+// authenticatedStream is a server stream whose context carries the caller's
+// identity and audit details
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+)
+
+// chainUnary runs interceptors in order around handler, as
+// grpc.ChainUnaryInterceptor does
+func chainUnary(handler grpc.UnaryHandler, info *grpc.UnaryServerInfo, interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor(ctx, req, info, next)
+		}
+	}
+	return handler
+}
+
+func grpcResolver() *fakeResolver {
+	return &fakeResolver{tokens: map[string]auth.Identity{
+		"ca_valid": {UserID: "user-1", OrganizationID: "org-1", Role: auth.RoleUser},
+	}}
+}
+
+func callWithAuth(t *testing.T, perm auth.Permission, authorization string) (auth.Identity, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	if authorization != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", authorization))
+	}
+
+	var identity auth.Identity
+	call := chainUnary(func(ctx context.Context, req interface{}) (interface{}, error) {
+		identity, _ = auth.FromContext(ctx)
+		return nil, nil
+	}, &grpc.UnaryServerInfo{FullMethod: "/chat.v1.ChatService/SendMessage"},
+		UnaryAuthInterceptor(grpcResolver(), logger.NewLogrusLogger("error", "text")),
+		UnaryPermissionInterceptor(perm),
+	)
+	_, err := call(ctx, nil)
+	return identity, err
+}
+
+func TestUnaryAuthInterceptor(t *testing.T) {
+	identity, err := callWithAuth(t, auth.PermChat, "Bearer ca_valid")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", identity.UserID)
+	assert.Equal(t, "org-1", identity.OrganizationID)
+
+	_, err = callWithAuth(t, auth.PermChat, "")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = callWithAuth(t, auth.PermChat, "Bearer ca_unknown")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = callWithAuth(t, auth.PermReadAudit, "Bearer ca_valid")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestUnaryAuditInterceptor(t *testing.T) {
+	recorder := &fakeRecorder{}
+	call := func(method string, perm auth.Permission) error {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer ca_valid"))
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4321}})
+		_, err := chainUnary(func(ctx context.Context, req interface{}) (interface{}, error) {
+			assert.Equal(t, "10.0.0.1", audit.RequestFrom(ctx).IP)
+			return nil, nil
+		}, &grpc.UnaryServerInfo{FullMethod: method},
+			UnaryAuthInterceptor(grpcResolver(), logger.NewLogrusLogger("error", "text")),
+			UnaryAuditInterceptor(recorder, "/chat.v1.ChatService/SendMessage"),
+			UnaryPermissionInterceptor(perm),
+		)(ctx, nil)
+		return err
+	}
+
+	require.NoError(t, call("/chat.v1.ChatService/CloseSession", auth.PermChat))
+	require.NoError(t, call("/chat.v1.ChatService/SendMessage", auth.PermChat))
+	assert.Equal(t, codes.PermissionDenied, status.Code(call("/chat.v1.ChatService/SendMessage", auth.PermReadAudit)))
+
+	if assert.Len(t, recorder.events, 2, "skipped methods are recorded only when denied") {
+		assert.Equal(t, audit.ActionCall, recorder.events[0].Action)
+		assert.Equal(t, "/chat.v1.ChatService/CloseSession", recorder.events[0].TargetID)
+		assert.Equal(t, "OK", recorder.events[0].Metadata["code"])
+		assert.Equal(t, "/chat.v1.ChatService/SendMessage", recorder.events[1].TargetID)
+		assert.Equal(t, "PermissionDenied", recorder.events[1].Metadata["code"])
+	}
+}
+
+func TestUnaryRecoveryInterceptor(t *testing.T) {
+	interceptor := UnaryRecoveryInterceptor(logger.NewLogrusLogger("error", "text"))
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test/Panic"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			panic("boom")
+		})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
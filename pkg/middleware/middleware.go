@@ -1,13 +1,31 @@
 package middleware
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
 	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
 	"github.com/gin-gonic/gin"
 )
 
+// IdentityResolver maps a bearer token to the identity it was issued for
+type IdentityResolver interface {
+	ResolveAPIKey(ctx context.Context, token string) (*auth.Identity, error)
+}
+
+// AuditRecorder appends events to the audit log
+type AuditRecorder interface {
+	Record(ctx context.Context, event *models.AuditEvent) error
+}
+
 // LoggerMiddleware logs HTTP requests
 func LoggerMiddleware(log logger.Logger) gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
@@ -63,15 +81,111 @@ func RateLimitMiddleware() gin.HandlerFunc {
 	}
 }
 
-// AuthMiddleware handles authentication
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware authenticates requests with an "Authorization: Bearer <key>"
+// header and stores the resolved identity, including its organization, in the
+// request context
+func AuthMiddleware(resolver IdentityResolver, log logger.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: Implement proper authentication
-		// For now, just continue
+		header := c.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Missing or malformed Authorization header",
+			})
+			return
+		}
+
+		identity, err := resolver.ResolveAPIKey(c.Request.Context(), token)
+		if errors.Is(err, auth.ErrInvalidKey) {
+			log.Warn("Authentication failed", logger.F("path", c.Request.URL.Path))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid API key",
+			})
+			return
+		}
+		if err != nil {
+			log.Error("Failed to resolve API key", logger.F("error", err.Error()))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Internal server error",
+			})
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), *identity))
+		c.Set("Identity", *identity)
 		c.Next()
 	}
 }
 
+// RequirePermission rejects requests whose identity's role does not grant perm.
+// It must run after AuthMiddleware.
+func RequirePermission(perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := auth.FromContext(c.Request.Context())
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required",
+			})
+			return
+		}
+
+		if !identity.Can(perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":      "Forbidden",
+				"permission": perm,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AuditMiddleware makes the client IP and request ID available to audited
+// repository writes, and records every request that changes data or is denied
+// by RequirePermission. Routes listed in skip, as "METHOD /full/path", are
+// not recorded themselves. It must run after AuthMiddleware.
+func AuditMiddleware(recorder AuditRecorder, skip ...string) gin.HandlerFunc {
+	skipped := make(map[string]bool, len(skip))
+	for _, route := range skip {
+		skipped[route] = true
+	}
+
+	return func(c *gin.Context) {
+		ctx := audit.WithRequest(c.Request.Context(), audit.Request{
+			IP:        c.ClientIP(),
+			RequestID: c.GetString("RequestID"),
+		})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		switch {
+		case status == http.StatusForbidden:
+		case c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead:
+			return
+		case skipped[c.Request.Method+" "+c.FullPath()]:
+			return
+		}
+		if _, ok := auth.OrganizationID(ctx); !ok {
+			return
+		}
+
+		// Failures are logged by the recorder; the response has already been sent
+		_ = recorder.Record(ctx, &models.AuditEvent{
+			Action:     audit.ActionRequest,
+			TargetType: "route",
+			TargetID:   c.FullPath(),
+			Metadata: models.AuditMetadata{
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+				"status": status,
+			},
+		})
+	}
+}
+
 // SecurityHeadersMiddleware adds security headers
 func SecurityHeadersMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -104,7 +218,9 @@ func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 
 // Helper function to generate request ID
 func generateRequestID() string {
-	// TODO: Implement proper UUID generation
-	return "req_" + time.Now().Format("20060102150405")
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "req_" + time.Now().Format("20060102150405.000000000")
+	}
+	return "req_" + hex.EncodeToString(b[:])
 }
-
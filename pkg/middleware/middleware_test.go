@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/audit"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+type fakeResolver struct {
+	tokens map[string]auth.Identity
+}
+
+func (f *fakeResolver) ResolveAPIKey(ctx context.Context, token string) (*auth.Identity, error) {
+	id, ok := f.tokens[token]
+	if !ok {
+		return nil, auth.ErrInvalidKey
+	}
+	return &id, nil
+}
+
+func newAuthRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	resolver := &fakeResolver{tokens: map[string]auth.Identity{
+		"ca_valid": {UserID: "user-1", OrganizationID: "org-1"},
+	}}
+	router.Use(AuthMiddleware(resolver, logger.NewLogrusLogger("error", "text")))
+	router.GET("/whoami", func(c *gin.Context) {
+		id, _ := auth.FromContext(c.Request.Context())
+		c.JSON(http.StatusOK, id)
+	})
+	return router
+}
+
+func TestAuthMiddleware_MissingHeader(t *testing.T) {
+	router := newAuthRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/whoami", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthMiddleware_InvalidKey(t *testing.T) {
+	router := newAuthRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/whoami", nil)
+	req.Header.Set("Authorization", "Bearer ca_unknown")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthMiddleware_ValidKey(t *testing.T) {
+	router := newAuthRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/whoami", nil)
+	req.Header.Set("Authorization", "Bearer ca_valid")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "org-1")
+	assert.Contains(t, w.Body.String(), "user-1")
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		role auth.Role
+		code int
+	}{
+		{auth.RoleAdmin, http.StatusOK},
+		{auth.RoleAgentOperator, http.StatusForbidden},
+		{auth.RoleUser, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			identity := auth.Identity{UserID: "user-1", OrganizationID: "org-1", Role: tt.role}
+			c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+			c.Next()
+		})
+		router.GET("/admin", RequirePermission(auth.PermManageUsers), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/admin", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.code, w.Code, string(tt.role))
+	}
+}
+
+type fakeRecorder struct {
+	events []*models.AuditEvent
+}
+
+func (f *fakeRecorder) Record(ctx context.Context, event *models.AuditEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := &fakeRecorder{}
+	resolver := &fakeResolver{tokens: map[string]auth.Identity{
+		"ca_user": {UserID: "user-1", OrganizationID: "org-1", Role: auth.RoleUser},
+	}}
+
+	var seen audit.Request
+	router := gin.New()
+	router.Use(RequestIDMiddleware())
+	router.Use(AuthMiddleware(resolver, logger.NewLogrusLogger("error", "text")))
+	router.Use(AuditMiddleware(recorder, "POST /messages"))
+	router.POST("/things/:id", func(c *gin.Context) {
+		seen = audit.RequestFrom(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	router.GET("/things/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/messages", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/admin", RequirePermission(auth.PermManageUsers), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, r := range []struct{ method, path string }{
+		{"POST", "/things/1"},
+		{"GET", "/things/1"},
+		{"POST", "/messages"},
+		{"GET", "/admin"},
+	} {
+		req, _ := http.NewRequest(r.method, r.path, nil)
+		req.Header.Set("Authorization", "Bearer ca_user")
+		req.RemoteAddr = "10.0.0.1:4321"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.NotEmpty(t, seen.RequestID)
+	assert.Equal(t, "10.0.0.1", seen.IP)
+
+	if assert.Len(t, recorder.events, 2) {
+		assert.Equal(t, "/things/:id", recorder.events[0].TargetID)
+		assert.Equal(t, http.StatusOK, recorder.events[0].Metadata["status"])
+		assert.Equal(t, "/admin", recorder.events[1].TargetID)
+		assert.Equal(t, http.StatusForbidden, recorder.events[1].Metadata["status"])
+	}
+}
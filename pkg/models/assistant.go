@@ -0,0 +1,109 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+)
+
+// ModelTargets is an ordered list of providers and models stored as jsonb
+type ModelTargets []llm.Target
+
+// Scan implements sql.Scanner
+func (t *ModelTargets) Scan(src interface{}) error {
+	return scanJSON(src, t)
+}
+
+// Value implements driver.Valuer
+func (t ModelTargets) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	return valueJSON([]llm.Target(t))
+}
+
+// GormDataType tells GORM the column type
+func (ModelTargets) GormDataType() string {
+	return "jsonb"
+}
+
+// Assistant is a persona sessions can be started with. It bundles the system
+// prompt, model settings, tools and knowledge bases a bot uses. Provider and
+// Model pick where replies are generated; Fallbacks are tried in order when
+// that provider is unavailable. CacheResponses answers repeated prompts from
+// the response cache. SemanticCache answers opening questions from earlier
+// answers to similar questions, matched when their embeddings' cosine
+// similarity reaches SemanticThreshold, or the configured default when it is
+// zero.
+type Assistant struct {
+	ID                string         `json:"id" gorm:"primaryKey"`
+	OrganizationID    string         `json:"organization_id" gorm:"not null;uniqueIndex:idx_assistants_org_live_name,where:deleted_at IS NULL"`
+	Name              string         `json:"name" gorm:"not null;uniqueIndex:idx_assistants_org_live_name,where:deleted_at IS NULL"`
+	Description       string         `json:"description"`
+	SystemPrompt      string         `json:"system_prompt" gorm:"type:text"`
+	PromptName        string         `json:"prompt_name"`
+	Provider          string         `json:"provider"`
+	Model             string         `json:"model"`
+	Fallbacks         ModelTargets   `json:"fallbacks"`
+	Temperature       float64        `json:"temperature"`
+	AllowedTools      StringList     `json:"allowed_tools"`
+	KnowledgeBases    StringList     `json:"knowledge_bases"`
+	CacheResponses    bool           `json:"cache_responses" gorm:"default:false"`
+	SemanticCache     bool           `json:"semantic_cache" gorm:"default:false"`
+	SemanticThreshold float64        `json:"semantic_threshold"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// CreateAssistantRequest represents the request structure for creating an assistant.
+// PromptName, when set, takes precedence over SystemPrompt and uses the
+// active version of that prompt template.
+type CreateAssistantRequest struct {
+	Name              string       `json:"name" binding:"required,min=1,max=100"`
+	Description       string       `json:"description" binding:"max=500"`
+	SystemPrompt      string       `json:"system_prompt" binding:"max=20000"`
+	PromptName        string       `json:"prompt_name" binding:"max=100"`
+	Provider          string       `json:"provider" binding:"max=50"`
+	Model             string       `json:"model" binding:"max=100"`
+	Fallbacks         ModelTargets `json:"fallbacks" binding:"max=5"`
+	Temperature       *float64     `json:"temperature" binding:"omitempty,min=0,max=2"`
+	AllowedTools      StringList   `json:"allowed_tools"`
+	KnowledgeBases    StringList   `json:"knowledge_bases"`
+	CacheResponses    bool         `json:"cache_responses"`
+	SemanticCache     bool         `json:"semantic_cache"`
+	SemanticThreshold float64      `json:"semantic_threshold" binding:"min=0,max=1"`
+}
+
+// UpdateAssistantRequest represents a partial assistant update; omitted fields are left unchanged
+type UpdateAssistantRequest struct {
+	Name              *string       `json:"name" binding:"omitempty,min=1,max=100"`
+	Description       *string       `json:"description" binding:"omitempty,max=500"`
+	SystemPrompt      *string       `json:"system_prompt" binding:"omitempty,max=20000"`
+	PromptName        *string       `json:"prompt_name" binding:"omitempty,max=100"`
+	Provider          *string       `json:"provider" binding:"omitempty,max=50"`
+	Model             *string       `json:"model" binding:"omitempty,max=100"`
+	Fallbacks         *ModelTargets `json:"fallbacks" binding:"omitempty,max=5"`
+	Temperature       *float64      `json:"temperature" binding:"omitempty,min=0,max=2"`
+	AllowedTools      *StringList   `json:"allowed_tools"`
+	KnowledgeBases    *StringList   `json:"knowledge_bases"`
+	CacheResponses    *bool         `json:"cache_responses"`
+	SemanticCache     *bool         `json:"semantic_cache"`
+	SemanticThreshold *float64      `json:"semantic_threshold" binding:"omitempty,min=0,max=1"`
+}
+
+// BeforeCreate assigns an ID to the assistant if none is set
+func (a *Assistant) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for Assistant
+func (Assistant) TableName() string {
+	return "assistants"
+}
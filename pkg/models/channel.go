@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Channels sessions are held on
+const (
+	ChannelAPI   = "api"
+	ChannelSlack = "slack"
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// ChannelAccount links an account on a messaging channel, such as a Slack
+// user, to the user it chats as. ExternalID is unique per channel within an
+// organization.
+type ChannelAccount struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"not null;uniqueIndex:idx_channel_accounts_external"`
+	Channel        string    `json:"channel" gorm:"not null;uniqueIndex:idx_channel_accounts_external"`
+	ExternalID     string    `json:"external_id" gorm:"not null;uniqueIndex:idx_channel_accounts_external"`
+	UserID         string    `json:"user_id" gorm:"not null;index"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// BeforeCreate assigns an ID to the account if none is set
+func (a *ChannelAccount) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for ChannelAccount
+func (ChannelAccount) TableName() string {
+	return "channel_accounts"
+}
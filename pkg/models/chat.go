@@ -2,51 +2,172 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
 )
 
 // ChatMessage represents a chat message in the system
 type ChatMessage struct {
-	ID        string    `json:"id" gorm:"primaryKey"`
-	UserID    string    `json:"user_id" gorm:"not null;index"`
-	Message   string    `json:"message" gorm:"not null"`
-	Timestamp string    `json:"timestamp"`
-	IsBot     bool      `json:"is_bot" gorm:"default:false"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             string         `json:"id" gorm:"primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"not null;index"`
+	SessionID      string         `json:"session_id" gorm:"index"`
+	ParentID       *string        `json:"parent_id" gorm:"index"`
+	UserID         string         `json:"user_id" gorm:"not null;index"`
+	Message        string         `json:"message" gorm:"not null"`
+	Timestamp      string         `json:"timestamp"`
+	IsBot          bool           `json:"is_bot" gorm:"default:false"`
+	Model          string         `json:"model,omitempty"`
+	ToolCalls      ToolCalls      `json:"tool_calls,omitempty"`
+	Citations      Citations      `json:"citations,omitempty"`
+	Cached         bool           `json:"cached,omitempty" gorm:"default:false"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	// Vault holds the original text when Message was redacted in vault mode
+	Vault *MessageVault `json:"-" gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE"`
+	// Feedback holds users' ratings; it is removed with the message
+	Feedback []MessageFeedback `json:"-" gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE"`
 }
 
-// ChatMessageRequest represents the request structure for sending a message
+// MaxMessageLength is the most characters a user message may have, whatever
+// the moderation settings; moderation.max_length can only lower it. The
+// binding tags below repeat it.
+const MaxMessageLength = 32000
+
+// ChatMessageRequest represents the request structure for sending a message.
+// The sender is taken from the authenticated identity; UserID is accepted for
+// backwards compatibility only. AssistantID selects the assistant a new
+// session is started with. Moderation may enforce a lower length limit.
+// WebhookURL is called with the job's outcome when the message is sent with
+// async=true.
 type ChatMessageRequest struct {
-	UserID  string `json:"user_id" binding:"required"`
-	Message string `json:"message" binding:"required,min=1,max=1000"`
+	UserID      string `json:"user_id"`
+	SessionID   string `json:"session_id"`
+	AssistantID string `json:"assistant_id"`
+	Message     string `json:"message" binding:"required,min=1,max=32000"`
+	WebhookURL  string `json:"webhook_url" binding:"omitempty,url,max=2048"`
 }
 
 // ChatMessageResponse represents the response structure after sending a message
 type ChatMessageResponse struct {
-	ID        string `json:"id"`
-	Message   string `json:"message"`
-	Timestamp string `json:"timestamp"`
-	Status    string `json:"status"`
+	ID            string `json:"id"`
+	SessionID     string `json:"session_id"`
+	UserMessageID string `json:"user_message_id,omitempty"`
+	ParentID      string `json:"parent_id,omitempty"`
+	Message       string `json:"message"`
+	Timestamp     string `json:"timestamp"`
+	Status        string `json:"status"`
+	Cached        bool   `json:"cached"`
 }
 
-// User represents a user in the system
+// EditMessageRequest represents the request structure for editing a user message.
+// The edit becomes a new branch next to the original message.
+type EditMessageRequest struct {
+	Message string `json:"message" binding:"required,min=1,max=32000"`
+}
+
+// SelectBranchRequest represents the request structure for switching a
+// session to the branch containing a message
+type SelectBranchRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+}
+
+// HistoryMessage is a message on a session's active branch together with the
+// alternatives it can be switched to
+type HistoryMessage struct {
+	ChatMessage
+	SiblingIDs []string `json:"sibling_ids"`
+}
+
+// User represents a user in the system. Usernames and emails are unique
+// within an organization among users that are not deleted.
 type User struct {
-	ID        string    `json:"id" gorm:"primaryKey"`
-	Username  string    `json:"username" gorm:"unique;not null"`
-	Email     string    `json:"email" gorm:"unique;not null"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             string         `json:"id" gorm:"primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"not null;index;uniqueIndex:idx_users_org_username,priority:1,where:deleted_at IS NULL;uniqueIndex:idx_users_org_email,priority:1,where:deleted_at IS NULL"`
+	Username       string         `json:"username" gorm:"not null;uniqueIndex:idx_users_org_username,priority:2,where:deleted_at IS NULL"`
+	Email          string         `json:"email" gorm:"not null;uniqueIndex:idx_users_org_email,priority:2,where:deleted_at IS NULL"`
+	DisplayName    string         `json:"display_name"`
+	Role           auth.Role      `json:"role" gorm:"type:varchar(32);not null;default:user"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	// AnonymizedAt is set once a retention policy has scrubbed a deleted user
+	AnonymizedAt *time.Time `json:"anonymized_at,omitempty"`
+}
+
+// CreateUserRequest represents the request structure for creating a user
+type CreateUserRequest struct {
+	Username    string `json:"username" binding:"required,min=3,max=50"`
+	Email       string `json:"email" binding:"required,email,max=255"`
+	DisplayName string `json:"display_name" binding:"max=100"`
 }
 
-// ChatSession represents a chat session
+// UpdateUserRequest represents a partial profile update; omitted fields are left unchanged
+type UpdateUserRequest struct {
+	Username    *string `json:"username" binding:"omitempty,min=3,max=50"`
+	Email       *string `json:"email" binding:"omitempty,email,max=255"`
+	DisplayName *string `json:"display_name" binding:"omitempty,max=100"`
+}
+
+// UpdateRoleRequest represents the request structure for changing a user's role
+type UpdateRoleRequest struct {
+	Role auth.Role `json:"role" binding:"required,oneof=admin agent-operator user"`
+}
+
+// ChatSession represents a chat session. Messages form a tree through
+// ParentID; ActiveMessageID is the leaf of the branch currently shown.
+// Channel names the medium the session is held on, such as "api" or
+// "slack", and replies go back through it; sessions from before channels
+// were recorded have none and are API sessions. ChannelThread identifies the
+// conversation on a messaging channel. ConversationDigest hashes the
+// conversation as a client of the stateless OpenAI-compatible API last saw
+// it, so its next request continues the session instead of starting another.
 type ChatSession struct {
-	ID        string        `json:"id" gorm:"primaryKey"`
-	UserID    string        `json:"user_id" gorm:"not null;index"`
-	Title     string        `json:"title"`
-	IsActive  bool          `json:"is_active" gorm:"default:true"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
-	Messages  []ChatMessage `json:"messages,omitempty" gorm:"foreignKey:UserID;references:UserID"`
+	ID                 string        `json:"id" gorm:"primaryKey"`
+	OrganizationID     string        `json:"organization_id" gorm:"not null;index"`
+	UserID             string        `json:"user_id" gorm:"not null;index"`
+	Title              string        `json:"title"`
+	ActiveMessageID    *string       `json:"active_message_id"`
+	AssistantID        *string       `json:"assistant_id,omitempty" gorm:"index"`
+	PromptName         string        `json:"prompt_name,omitempty"`
+	PromptID           *string       `json:"prompt_id,omitempty"`
+	PromptVersion      int           `json:"prompt_version,omitempty"`
+	IsActive           bool          `json:"is_active" gorm:"default:true"`
+	CreatedAt          time.Time     `json:"created_at"`
+	UpdatedAt          time.Time     `json:"updated_at"`
+	Messages           []ChatMessage `json:"messages,omitempty" gorm:"foreignKey:SessionID"`
+	Channel            string        `json:"channel,omitempty" gorm:"index:idx_chat_sessions_channel_thread"`
+	ChannelThread      string        `json:"channel_thread,omitempty" gorm:"index:idx_chat_sessions_channel_thread"`
+	ConversationDigest string        `json:"-" gorm:"index"`
+}
+
+// BeforeCreate assigns an ID to the message if none is set
+func (m *ChatMessage) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = NewID()
+	}
+	return nil
+}
+
+// BeforeCreate assigns an ID and the default role to the user if none is set
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == "" {
+		u.ID = NewID()
+	}
+	if u.Role == "" {
+		u.Role = auth.RoleUser
+	}
+	return nil
+}
+
+// BeforeCreate assigns an ID to the session if none is set
+func (s *ChatSession) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = NewID()
+	}
+	return nil
 }
 
 // TableName returns the table name for ChatMessage
@@ -63,4 +184,3 @@ func (User) TableName() string {
 func (ChatSession) TableName() string {
 	return "chat_sessions"
 }
-
@@ -0,0 +1,65 @@
+package models
+
+import "encoding/json"
+
+// Object types of the OpenAI-compatible API
+const (
+	ObjectChatCompletion      = "chat.completion"
+	ObjectChatCompletionChunk = "chat.completion.chunk"
+	ObjectModel               = "model"
+	ObjectList                = "list"
+)
+
+// ChatCompletionRequest is a request to the OpenAI-compatible chat
+// completions endpoint. Model names one of the organization's assistants, by
+// name or ID. SessionID is an extension that continues one of the caller's
+// sessions, which then holds the conversation so far; without it the
+// messages start a new session.
+type ChatCompletionRequest struct {
+	Model     string                  `json:"model" binding:"required"`
+	Messages  []ChatCompletionMessage `json:"messages" binding:"required,min=1"`
+	Stream    bool                    `json:"stream"`
+	SessionID string                  `json:"session_id"`
+}
+
+// ChatCompletionMessage is a turn of the conversation sent with a request.
+// Content is a string or a list of parts, of which the text parts are read.
+type ChatCompletionMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// ChatCompletion is the answer to a chat completions request, or with Object
+// set to ObjectChatCompletionChunk a part of a streamed answer. SessionID is
+// an extension naming the session the answer was stored in.
+type ChatCompletion struct {
+	ID        string                 `json:"id"`
+	Object    string                 `json:"object"`
+	Created   int64                  `json:"created"`
+	Model     string                 `json:"model"`
+	Choices   []ChatCompletionChoice `json:"choices"`
+	SessionID string                 `json:"session_id"`
+}
+
+// ChatCompletionChoice holds the answer in Message, or a streamed part of it
+// in Delta. FinishReason is null until the answer is complete.
+type ChatCompletionChoice struct {
+	Index        int                  `json:"index"`
+	Message      *ChatCompletionDelta `json:"message,omitempty"`
+	Delta        *ChatCompletionDelta `json:"delta,omitempty"`
+	FinishReason *string              `json:"finish_reason"`
+}
+
+// ChatCompletionDelta is an answer, or a streamed part of one
+type ChatCompletionDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// CompletionModel lists an assistant as a model of the OpenAI-compatible API
+type CompletionModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Feedback ratings
+const (
+	RatingUp   = "up"
+	RatingDown = "down"
+)
+
+// MessageFeedback is a user's rating of an assistant message. The assistant,
+// prompt version and model that produced the message are copied onto the
+// feedback so it can be aggregated without walking sessions.
+type MessageFeedback struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"not null;index"`
+	MessageID      string    `json:"message_id" gorm:"not null;uniqueIndex:idx_message_feedback_user"`
+	UserID         string    `json:"user_id" gorm:"not null;uniqueIndex:idx_message_feedback_user;index"`
+	SessionID      string    `json:"session_id" gorm:"not null"`
+	Rating         string    `json:"rating" gorm:"type:varchar(8);not null"`
+	Reason         string    `json:"reason,omitempty"`
+	Comment        string    `json:"comment,omitempty" gorm:"type:text"`
+	AssistantID    *string   `json:"assistant_id,omitempty" gorm:"index"`
+	PromptName     string    `json:"prompt_name,omitempty"`
+	PromptVersion  int       `json:"prompt_version,omitempty"`
+	Model          string    `json:"model,omitempty"`
+	CreatedAt      time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// BeforeCreate assigns an ID to the feedback if none is set
+func (f *MessageFeedback) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == "" {
+		f.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for MessageFeedback
+func (MessageFeedback) TableName() string {
+	return "message_feedback"
+}
+
+// SubmitFeedbackRequest represents the request structure for rating an
+// assistant message. Submitting again replaces the earlier rating.
+type SubmitFeedbackRequest struct {
+	Rating  string `json:"rating" binding:"required,oneof=up down"`
+	Reason  string `json:"reason" binding:"max=100"`
+	Comment string `json:"comment" binding:"max=2000"`
+}
+
+// FeedbackAggregate counts ratings for one assistant, prompt version or model
+type FeedbackAggregate struct {
+	AssistantID   string `json:"assistant_id,omitempty"`
+	PromptName    string `json:"prompt_name,omitempty"`
+	PromptVersion int    `json:"prompt_version,omitempty"`
+	Model         string `json:"model,omitempty"`
+	Up            int64  `json:"up"`
+	Down          int64  `json:"down"`
+	Total         int64  `json:"total"`
+	// Satisfaction is the share of ratings that are thumbs up
+	Satisfaction float64 `json:"satisfaction"`
+}
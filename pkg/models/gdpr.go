@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// UserExport holds everything stored about a user, for data subject access
+// requests
+type UserExport struct {
+	ExportedAt      time.Time         `json:"exported_at"`
+	User            User              `json:"user"`
+	Sessions        []ChatSession     `json:"sessions"`
+	Messages        []ChatMessage     `json:"messages"`
+	ModerationFlags []ModerationFlag  `json:"moderation_flags"`
+	Feedback        []MessageFeedback `json:"feedback"`
+	Usage           []UsageRecord     `json:"usage"`
+	APIKeys         []APIKey          `json:"api_keys"`
+	ChannelAccounts []ChannelAccount  `json:"channel_accounts"`
+	// Originals holds the decrypted originals of messages stored redacted in
+	// vault mode
+	Originals []MessageOriginal `json:"originals"`
+}
+
+// MessageOriginal is the text a message had before personal data was redacted
+type MessageOriginal struct {
+	MessageID string `json:"message_id"`
+	Message   string `json:"message"`
+}
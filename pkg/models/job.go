@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// JobTypeChatReply generates the reply to a stored user message
+const JobTypeChatReply = "chat.reply"
+
+// Job is a unit of work processed in the background on behalf of the caller
+// recorded on it. A running job holds a lease; if its worker dies the job is
+// handed out again once the lease expires, up to the queue's attempt limit.
+// Jobs are claimed across organizations, so unlike most tenant tables the
+// jobs table is not under row-level security.
+type Job struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	OrganizationID string     `json:"organization_id" gorm:"not null;index"`
+	UserID         string     `json:"user_id" gorm:"not null;index"`
+	APIKeyID       string     `json:"-"`
+	Role           string     `json:"-"`
+	Type           string     `json:"type" gorm:"not null"`
+	SessionID      string     `json:"session_id"`
+	MessageID      string     `json:"message_id"`
+	Cacheable      bool       `json:"-"`
+	WebhookURL     string     `json:"-"`
+	Status         string     `json:"status" gorm:"not null;index:idx_jobs_claim"`
+	Attempts       int        `json:"attempts"`
+	ReplyID        *string    `json:"reply_id,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	LeaseUntil     *time.Time `json:"-" gorm:"index"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index:idx_jobs_claim"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+// BeforeCreate assigns an ID to the job if none is set
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == "" {
+		j.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for Job
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// Finished reports whether the job has succeeded or failed
+func (j *Job) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
+
+// JobResponse is the state of a job as returned by the jobs endpoint and sent
+// to its webhook. Reply is set once the job has succeeded.
+type JobResponse struct {
+	ID         string               `json:"id"`
+	Status     string               `json:"status"`
+	SessionID  string               `json:"session_id"`
+	MessageID  string               `json:"message_id"`
+	Attempts   int                  `json:"attempts"`
+	Error      string               `json:"error,omitempty"`
+	Reply      *ChatMessageResponse `json:"reply,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	StartedAt  *time.Time           `json:"started_at,omitempty"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// scanJSON decodes a jsonb column value into dst
+func scanJSON(src interface{}, dst interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	default:
+		return fmt.Errorf("models: cannot scan %T into %T", src, dst)
+	}
+}
+
+// valueJSON encodes v for storage in a jsonb column
+func valueJSON(v interface{}) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// StringList is a list of strings stored as a jsonb array
+type StringList []string
+
+// Scan implements sql.Scanner
+func (l *StringList) Scan(src interface{}) error {
+	return scanJSON(src, l)
+}
+
+// Value implements driver.Valuer
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return valueJSON([]string(l))
+}
+
+// GormDataType tells GORM the column type
+func (StringList) GormDataType() string {
+	return "jsonb"
+}
+
+// Contains reports whether s is in the list
+func (l StringList) Contains(s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// KnowledgeBase tracks the version of a knowledge base assistants draw on.
+// The version is bumped whenever its content changes, which invalidates the
+// answers cached while the old content was in use.
+type KnowledgeBase struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"not null;uniqueIndex:idx_knowledge_bases_org_name"`
+	Name           string    `json:"name" gorm:"not null;uniqueIndex:idx_knowledge_bases_org_name"`
+	Version        int       `json:"version" gorm:"not null;default:1"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// BeforeCreate assigns an ID to the knowledge base if none is set
+func (k *KnowledgeBase) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == "" {
+		k.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for KnowledgeBase
+func (KnowledgeBase) TableName() string {
+	return "knowledge_bases"
+}
+
+// Vector is an embedding stored as a jsonb array
+type Vector []float32
+
+// Scan implements sql.Scanner
+func (v *Vector) Scan(src interface{}) error {
+	return scanJSON(src, v)
+}
+
+// Value implements driver.Valuer
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return "[]", nil
+	}
+	return valueJSON([]float32(v))
+}
+
+// GormDataType tells GORM the column type
+func (Vector) GormDataType() string {
+	return "jsonb"
+}
+
+// SemanticCacheEntry is a generated answer kept with the embedding of the
+// question it answered, so similar questions to the same assistant can reuse
+// it. Fingerprint identifies the assistant configuration and knowledge base
+// versions the answer was generated with; KnowledgeBases lists the knowledge
+// bases it drew on, so a change to any of them can drop it. UserID records
+// who asked, so the entry is erased with their data.
+type SemanticCacheEntry struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	OrganizationID string     `json:"organization_id" gorm:"not null;index"`
+	AssistantID    string     `json:"assistant_id" gorm:"not null;index:idx_semantic_cache_scope"`
+	Fingerprint    string     `json:"fingerprint" gorm:"not null;index:idx_semantic_cache_scope"`
+	KnowledgeBases StringList `json:"knowledge_bases"`
+	UserID         string     `json:"user_id" gorm:"index"`
+	Question       string     `json:"question" gorm:"type:text"`
+	Embedding      Vector     `json:"-"`
+	Answer         string     `json:"answer" gorm:"type:text"`
+	Provider       string     `json:"provider"`
+	Model          string     `json:"model"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index:idx_semantic_cache_scope"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty" gorm:"index"`
+}
+
+// BeforeCreate assigns an ID to the entry if none is set
+func (e *SemanticCacheEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for SemanticCacheEntry
+func (SemanticCacheEntry) TableName() string {
+	return "semantic_cache_entries"
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/moderation"
+)
+
+// ModerationReasons is a list of rejection reasons stored as jsonb
+type ModerationReasons []moderation.Reason
+
+// Scan implements sql.Scanner
+func (r *ModerationReasons) Scan(src interface{}) error {
+	return scanJSON(src, r)
+}
+
+// Value implements driver.Valuer
+func (r ModerationReasons) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+	return valueJSON([]moderation.Reason(r))
+}
+
+// GormDataType tells GORM the column type
+func (ModerationReasons) GormDataType() string {
+	return "jsonb"
+}
+
+// ModerationFlag records a message that was rejected by moderation
+type ModerationFlag struct {
+	ID             string            `json:"id" gorm:"primaryKey"`
+	OrganizationID string            `json:"organization_id" gorm:"not null;index"`
+	UserID         string            `json:"user_id" gorm:"not null;index"`
+	SessionID      string            `json:"session_id,omitempty"`
+	Content        string            `json:"content" gorm:"type:text"`
+	Reasons        ModerationReasons `json:"reasons"`
+	CreatedAt      time.Time         `json:"created_at" gorm:"index"`
+}
+
+// BeforeCreate assigns an ID to the flag if none is set
+func (f *ModerationFlag) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == "" {
+		f.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for ModerationFlag
+func (ModerationFlag) TableName() string {
+	return "moderation_flags"
+}
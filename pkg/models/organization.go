@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Organization represents a tenant; all chat data belongs to exactly one organization
+type Organization struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"unique;not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// APIKey represents a bearer token issued to a user within an organization.
+// Only the SHA-256 hash of the token is stored. WebhookSecret signs the job
+// webhooks of requests made with the key; keys issued before it existed have
+// none and their job webhooks are not called.
+type APIKey struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	OrganizationID string     `json:"organization_id" gorm:"not null;index"`
+	UserID         string     `json:"user_id" gorm:"not null;index"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix" gorm:"not null"`
+	KeyHash        string     `json:"-" gorm:"uniqueIndex;not null"`
+	WebhookSecret  string     `json:"-" gorm:"not null;default:''"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// CreateAPIKeyRequest represents the request structure for issuing an API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"max=100"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse carries a newly issued key; the token and webhook
+// secret are only ever returned once
+type CreateAPIKeyResponse struct {
+	APIKey
+	Token         string `json:"token"`
+	WebhookSecret string `json:"webhook_secret"`
+}
+
+// BeforeCreate assigns an ID to the organization if none is set
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == "" {
+		o.ID = NewID()
+	}
+	return nil
+}
+
+// BeforeCreate assigns an ID to the API key if none is set
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == "" {
+		k.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for Organization
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// TableName returns the table name for APIKey
+func (APIKey) TableName() string {
+	return "api_keys"
+}
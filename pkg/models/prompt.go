@@ -0,0 +1,72 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PromptVariable describes a variable a prompt template expects
+type PromptVariable struct {
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Default     string `json:"default,omitempty"`
+}
+
+// PromptVariables is the variables schema of a template, keyed by variable name
+type PromptVariables map[string]PromptVariable
+
+// Scan implements sql.Scanner
+func (v *PromptVariables) Scan(src interface{}) error {
+	return scanJSON(src, v)
+}
+
+// Value implements driver.Valuer
+func (v PromptVariables) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+	return valueJSON(map[string]PromptVariable(v))
+}
+
+// GormDataType tells GORM the column type
+func (PromptVariables) GormDataType() string {
+	return "jsonb"
+}
+
+// PromptTemplate is one version of a named system prompt. Body is a Go
+// text/template; at most one version per name is active.
+type PromptTemplate struct {
+	ID             string          `json:"id" gorm:"primaryKey"`
+	OrganizationID string          `json:"organization_id" gorm:"not null;uniqueIndex:idx_prompt_templates_name_version"`
+	Name           string          `json:"name" gorm:"not null;uniqueIndex:idx_prompt_templates_name_version"`
+	Version        int             `json:"version" gorm:"not null;uniqueIndex:idx_prompt_templates_name_version"`
+	Body           string          `json:"body" gorm:"type:text;not null"`
+	Variables      PromptVariables `json:"variables"`
+	IsActive       bool            `json:"is_active" gorm:"default:false"`
+	CreatedBy      string          `json:"created_by"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// CreatePromptVersionRequest represents the request structure for adding a template version
+type CreatePromptVersionRequest struct {
+	Body      string          `json:"body" binding:"required,max=20000"`
+	Variables PromptVariables `json:"variables"`
+	Activate  bool            `json:"activate"`
+}
+
+// BeforeCreate assigns an ID to the template if none is set
+func (p *PromptTemplate) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for PromptTemplate
+func (PromptTemplate) TableName() string {
+	return "prompt_templates"
+}
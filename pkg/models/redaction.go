@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// MessageVault holds the encrypted original of a message that was stored
+// with personal data redacted
+type MessageVault struct {
+	MessageID      string    `json:"message_id" gorm:"primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"not null;index"`
+	Ciphertext     string    `json:"-" gorm:"type:text;not null"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for MessageVault
+func (MessageVault) TableName() string {
+	return "message_vault"
+}
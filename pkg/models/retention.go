@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RetentionPolicy says how long an organization keeps its data. A policy with
+// an AssistantID applies to that assistant's sessions and overrides the
+// organization-wide policy, which has none. Zero days means keep forever.
+type RetentionPolicy struct {
+	ID             string `json:"id" gorm:"primaryKey"`
+	OrganizationID string `json:"organization_id" gorm:"not null;uniqueIndex:idx_retention_policies_scope"`
+	AssistantID    string `json:"assistant_id,omitempty" gorm:"uniqueIndex:idx_retention_policies_scope"`
+	// MessageDays deletes messages older than this many days
+	MessageDays int `json:"message_days"`
+	// AnonymizeUserDays anonymizes deleted users this many days after deletion.
+	// It only applies to organization-wide policies.
+	AnonymizeUserDays int       `json:"anonymize_user_days"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// SaveRetentionPolicyRequest represents the request structure for creating or
+// replacing a retention policy
+type SaveRetentionPolicyRequest struct {
+	AssistantID       string `json:"assistant_id"`
+	MessageDays       int    `json:"message_days" binding:"min=0"`
+	AnonymizeUserDays int    `json:"anonymize_user_days" binding:"min=0"`
+}
+
+// BeforeCreate assigns an ID to the policy if none is set
+func (p *RetentionPolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for RetentionPolicy
+func (RetentionPolicy) TableName() string {
+	return "retention_policies"
+}
@@ -0,0 +1,61 @@
+package models
+
+import "database/sql/driver"
+
+// ToolCall records a tool the assistant invoked while producing a reply
+type ToolCall struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"`
+	Result    string `json:"result,omitempty"`
+}
+
+// ToolCalls is a list of tool calls stored as jsonb
+type ToolCalls []ToolCall
+
+// Scan implements sql.Scanner
+func (t *ToolCalls) Scan(src interface{}) error {
+	return scanJSON(src, t)
+}
+
+// Value implements driver.Valuer
+func (t ToolCalls) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	return valueJSON([]ToolCall(t))
+}
+
+// GormDataType tells GORM the column type
+func (ToolCalls) GormDataType() string {
+	return "jsonb"
+}
+
+// Citation points at a knowledge base source a reply was grounded on
+type Citation struct {
+	Source  string `json:"source"`
+	Title   string `json:"title,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// Citations is a list of citations stored as jsonb
+type Citations []Citation
+
+// Scan implements sql.Scanner
+func (c *Citations) Scan(src interface{}) error {
+	return scanJSON(src, c)
+}
+
+// Value implements driver.Valuer
+func (c Citations) Value() (driver.Value, error) {
+	if c == nil {
+		return "[]", nil
+	}
+	return valueJSON([]Citation(c))
+}
+
+// GormDataType tells GORM the column type
+func (Citations) GormDataType() string {
+	return "jsonb"
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UsageRecord is the token usage and cost, in US dollars, of one provider call
+type UsageRecord struct {
+	ID               string    `json:"id" gorm:"primaryKey"`
+	OrganizationID   string    `json:"organization_id" gorm:"not null;index:idx_usage_records_org_time"`
+	UserID           string    `json:"user_id" gorm:"index"`
+	APIKeyID         string    `json:"api_key_id,omitempty" gorm:"index"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	Cost             float64   `json:"cost" gorm:"type:numeric(14,6)"`
+	CreatedAt        time.Time `json:"created_at" gorm:"index:idx_usage_records_org_time"`
+}
+
+// BeforeCreate assigns an ID to the record if none is set
+func (r *UsageRecord) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for UsageRecord
+func (UsageRecord) TableName() string {
+	return "usage_records"
+}
+
+// UsageSummary adds up usage records, for a whole period or one group of it
+type UsageSummary struct {
+	// Group is the day, month, model or user the row covers; empty for totals
+	Group            string  `json:"group,omitempty"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
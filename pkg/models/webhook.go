@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySending   = "sending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookEndpoint is a URL a tenant has registered to receive chat events.
+// Deliveries are signed with Secret, which is only shown when the endpoint
+// is created.
+type WebhookEndpoint struct {
+	ID             string         `json:"id" gorm:"primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"not null;index"`
+	URL            string         `json:"url" gorm:"not null"`
+	Description    string         `json:"description"`
+	Events         StringList     `json:"events"`
+	Secret         string         `json:"-" gorm:"not null"`
+	Active         bool           `json:"active" gorm:"default:true"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// BeforeCreate assigns an ID to the endpoint if none is set
+func (e *WebhookEndpoint) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for WebhookEndpoint
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// WebhookDelivery is one event sent, or to be sent, to one endpoint. It logs
+// the outcome of the latest attempt; a failed attempt is retried at
+// NextAttemptAt. UserID is the user the event concerns, so the delivery is
+// erased with their data. Deliveries are claimed across organizations, so
+// like jobs the table is not under row-level security.
+type WebhookDelivery struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	OrganizationID string     `json:"organization_id" gorm:"not null;index"`
+	EndpointID     string     `json:"endpoint_id" gorm:"not null;index"`
+	EventID        string     `json:"event_id" gorm:"not null;index"`
+	Event          string     `json:"event" gorm:"not null"`
+	UserID         string     `json:"user_id,omitempty" gorm:"index"`
+	Payload        string     `json:"payload" gorm:"type:text"`
+	Status         string     `json:"status" gorm:"not null;index:idx_webhook_deliveries_due"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index:idx_webhook_deliveries_due"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	RedeliveryOf   *string    `json:"redelivery_of,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate assigns an ID to the delivery if none is set
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = NewID()
+	}
+	return nil
+}
+
+// TableName returns the table name for WebhookDelivery
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// CreateWebhookRequest represents the request structure for registering a webhook endpoint
+type CreateWebhookRequest struct {
+	URL         string     `json:"url" binding:"required,url,max=2048"`
+	Description string     `json:"description" binding:"max=500"`
+	Events      StringList `json:"events" binding:"required,min=1"`
+}
+
+// UpdateWebhookRequest represents a partial endpoint update; omitted fields are left unchanged
+type UpdateWebhookRequest struct {
+	URL         *string     `json:"url" binding:"omitempty,url,max=2048"`
+	Description *string     `json:"description" binding:"omitempty,max=500"`
+	Events      *StringList `json:"events" binding:"omitempty,min=1"`
+	Active      *bool       `json:"active"`
+}
+
+// CreateWebhookResponse is a newly registered endpoint with its signing secret
+type CreateWebhookResponse struct {
+	WebhookEndpoint
+	Secret string `json:"secret"`
+}
@@ -0,0 +1,65 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+)
+
+// Reason explains why a moderator rejected a message
+type Reason struct {
+	Moderator string `json:"moderator"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+}
+
+// Result is the outcome of running a message through a Chain
+type Result struct {
+	Allowed bool     `json:"allowed"`
+	Reasons []Reason `json:"reasons,omitempty"`
+	// Skipped names moderators that failed and were ignored because the
+	// chain fails open
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Moderator inspects a message and returns the reasons it should be rejected,
+// or none if it is acceptable
+type Moderator interface {
+	Name() string
+	Check(ctx context.Context, text string) ([]Reason, error)
+}
+
+// Chain runs a message through several moderators and collects every reason
+// to reject it
+type Chain struct {
+	moderators []Moderator
+	failOpen   bool
+}
+
+// NewChain creates a chain. When failOpen is set, a moderator that errors is
+// skipped instead of failing the check.
+func NewChain(failOpen bool, moderators ...Moderator) *Chain {
+	return &Chain{
+		moderators: moderators,
+		failOpen:   failOpen,
+	}
+}
+
+// Check runs every moderator in order
+func (c *Chain) Check(ctx context.Context, text string) (Result, error) {
+	result := Result{Allowed: true}
+
+	for _, m := range c.moderators {
+		reasons, err := m.Check(ctx, text)
+		if err != nil {
+			if c.failOpen {
+				result.Skipped = append(result.Skipped, m.Name())
+				continue
+			}
+			return Result{}, fmt.Errorf("moderator %s: %w", m.Name(), err)
+		}
+		result.Reasons = append(result.Reasons, reasons...)
+	}
+
+	result.Allowed = len(result.Reasons) == 0
+	return result, nil
+}
@@ -0,0 +1,182 @@
+package prompts
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	parsetree "text/template/parse"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// SessionVariables are the values a session's system prompt is rendered
+// with. Other variables can only take their default, so they cannot be
+// required.
+var SessionVariables = []string{"date", "session_id", "user_id"}
+
+// Validate checks that a template body parses, that every variable in the
+// schema has a known type, that required variables are supplied by
+// sessions, and that the body only uses declared and session variables and
+// renders with their defaults
+func Validate(body string, vars models.PromptVariables) error {
+	t, err := parse("validate", body)
+	if err != nil {
+		return err
+	}
+	for name, v := range vars {
+		switch v.Type {
+		case "", "string", "number", "boolean":
+		default:
+			return fmt.Errorf("variable %q has unsupported type %q", name, v.Type)
+		}
+		if v.Required && !isSessionVariable(name) {
+			return fmt.Errorf("variable %q cannot be required: sessions only supply %s",
+				name, strings.Join(SessionVariables, ", "))
+		}
+	}
+
+	data := make(map[string]string, len(SessionVariables)+len(vars))
+	for _, name := range SessionVariables {
+		data[name] = ""
+	}
+	for name, v := range vars {
+		data[name] = v.Default
+	}
+	// Branches not taken with these values are not executed, so fields are
+	// also looked up in the parse tree
+	if name := undeclaredField(t.Root, data, true); name != "" {
+		return fmt.Errorf("template uses undeclared variable %q", name)
+	}
+	if err := t.Execute(io.Discard, data); err != nil {
+		return fmt.Errorf("template does not render: %w", err)
+	}
+	return nil
+}
+
+// undeclaredField returns the first variable the template reads from its
+// data that is not in known, or "" if there is none. Inside range and with
+// the dot is no longer the data, so only $ is followed there.
+func undeclaredField(node parsetree.Node, known map[string]string, dotIsData bool) string {
+	var name string
+	switch n := node.(type) {
+	case *parsetree.ListNode:
+		if n == nil {
+			return ""
+		}
+		for _, child := range n.Nodes {
+			if name = undeclaredField(child, known, dotIsData); name != "" {
+				return name
+			}
+		}
+	case *parsetree.ActionNode:
+		name = undeclaredField(n.Pipe, known, dotIsData)
+	case *parsetree.TemplateNode:
+		name = undeclaredField(n.Pipe, known, dotIsData)
+	case *parsetree.IfNode:
+		name = firstOf(
+			undeclaredField(n.Pipe, known, dotIsData),
+			undeclaredField(n.List, known, dotIsData),
+			undeclaredField(n.ElseList, known, dotIsData),
+		)
+	case *parsetree.RangeNode:
+		name = firstOf(
+			undeclaredField(n.Pipe, known, dotIsData),
+			undeclaredField(n.List, known, false),
+			undeclaredField(n.ElseList, known, dotIsData),
+		)
+	case *parsetree.WithNode:
+		name = firstOf(
+			undeclaredField(n.Pipe, known, dotIsData),
+			undeclaredField(n.List, known, false),
+			undeclaredField(n.ElseList, known, dotIsData),
+		)
+	case *parsetree.PipeNode:
+		if n == nil {
+			return ""
+		}
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				if name = undeclaredField(arg, known, dotIsData); name != "" {
+					return name
+				}
+			}
+		}
+	case *parsetree.FieldNode:
+		if _, ok := known[n.Ident[0]]; dotIsData && !ok {
+			name = n.Ident[0]
+		}
+	case *parsetree.VariableNode:
+		// $.name reads the data whatever the dot is
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			if _, ok := known[n.Ident[1]]; !ok {
+				name = n.Ident[1]
+			}
+		}
+	}
+	return name
+}
+
+func firstOf(names ...string) string {
+	for _, name := range names {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func isSessionVariable(name string) bool {
+	for _, v := range SessionVariables {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Render executes a template with the given values. Missing required
+// variables are an error; missing optional ones take their default.
+func Render(tpl *models.PromptTemplate, values map[string]string) (string, error) {
+	data := make(map[string]string, len(values)+len(tpl.Variables))
+	for k, v := range values {
+		data[k] = v
+	}
+
+	var missing []string
+	for name, spec := range tpl.Variables {
+		if _, ok := data[name]; ok {
+			continue
+		}
+		if spec.Required {
+			missing = append(missing, name)
+			continue
+		}
+		data[name] = spec.Default
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", fmt.Errorf("prompt %s v%d: missing required variables: %s",
+			tpl.Name, tpl.Version, strings.Join(missing, ", "))
+	}
+
+	t, err := parse(tpl.Name, tpl.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("prompt %s v%d: %w", tpl.Name, tpl.Version, err)
+	}
+	return b.String(), nil
+}
+
+func parse(name, body string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return t, nil
+}
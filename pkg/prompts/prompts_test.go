@@ -0,0 +1,65 @@
+package prompts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+func TestRender(t *testing.T) {
+	tpl := &models.PromptTemplate{
+		Name:    "support",
+		Version: 2,
+		Body:    "You help {{.company}} customers. Today is {{.date}}.",
+		Variables: models.PromptVariables{
+			"company": {Type: "string", Default: "Acme"},
+			"date":    {Type: "string", Required: true},
+		},
+	}
+
+	out, err := Render(tpl, map[string]string{"date": "2024-01-01"})
+	assert.NoError(t, err)
+	assert.Equal(t, "You help Acme customers. Today is 2024-01-01.", out)
+
+	_, err = Render(tpl, nil)
+	assert.ErrorContains(t, err, "missing required variables: date")
+}
+
+func TestRender_UndeclaredVariable(t *testing.T) {
+	tpl := &models.PromptTemplate{Name: "p", Version: 1, Body: "Hello {{.name}}"}
+
+	_, err := Render(tpl, nil)
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("Hi {{.name}}", models.PromptVariables{"name": {Type: "string"}}))
+	assert.Error(t, Validate("Hi {{.name", nil))
+	assert.Error(t, Validate("Hi", models.PromptVariables{"name": {Type: "object"}}))
+
+	// Sessions supply only the session variables, so no other can be required
+	assert.NoError(t, Validate("{{.date}} {{.tone}}", models.PromptVariables{
+		"date": {Type: "string", Required: true},
+		"tone": {Type: "string", Default: "friendly"},
+	}))
+	assert.ErrorContains(t, Validate("{{.company}}", models.PromptVariables{
+		"company": {Type: "string", Required: true},
+	}), `"company" cannot be required`)
+}
+
+func TestValidate_UndeclaredVariable(t *testing.T) {
+	// Render would fail on every message of a session pinned to these
+	assert.ErrorContains(t, Validate("Hi {{.customer}}", nil), `undeclared variable "customer"`)
+	assert.ErrorContains(t, Validate("{{if .date}}{{.customer}}{{end}}", nil), `undeclared variable "customer"`,
+		"branches not taken are checked too")
+	assert.ErrorContains(t, Validate("{{with .tone}}{{$.customer}}{{end}}", models.PromptVariables{
+		"tone": {Type: "string"},
+	}), `undeclared variable "customer"`)
+
+	assert.NoError(t, Validate("{{.user_id}} {{with .tone}}{{.}}{{else}}{{.company}}{{end}}", models.PromptVariables{
+		"tone":    {Type: "string"},
+		"company": {Type: "string", Default: "Acme"},
+	}))
+}
@@ -0,0 +1,99 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule finds one kind of personal data in text
+type Rule struct {
+	// Name labels the placeholder that replaces a match, e.g. EMAIL
+	Name    string
+	Pattern *regexp.Regexp
+	// Valid, if set, filters out matches that only look like personal data
+	Valid func(match string) bool
+}
+
+var (
+	emailPattern      = regexp.MustCompile(`(?i)\b[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}\b`)
+	creditCardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	phonePattern      = regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)[ .-]?|\b\d{2,4}[ .-])\d{3,4}[ .-]?\d{4}\b|\+\d{8,14}\b`)
+)
+
+// DefaultRules returns the built-in rules for credit card numbers, email
+// addresses and phone numbers. Card numbers come first so their digits are not
+// mistaken for phone numbers.
+func DefaultRules() []Rule {
+	return []Rule{
+		{Name: "CREDIT_CARD", Pattern: creditCardPattern, Valid: luhn},
+		{Name: "EMAIL", Pattern: emailPattern},
+		{Name: "PHONE", Pattern: phonePattern},
+	}
+}
+
+// Redactor replaces personal data in text with placeholders such as
+// [REDACTED_EMAIL]
+type Redactor struct {
+	rules []Rule
+}
+
+// New creates a redactor with the default rules followed by custom patterns,
+// keyed by the name used in their placeholder
+func New(custom map[string]string) (*Redactor, error) {
+	rules := DefaultRules()
+
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pattern, err := regexp.Compile(custom[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", name, err)
+		}
+		rules = append(rules, Rule{Name: strings.ToUpper(name), Pattern: pattern})
+	}
+
+	return &Redactor{rules: rules}, nil
+}
+
+// Redact returns text with every match replaced by its placeholder
+func (r *Redactor) Redact(text string) string {
+	for _, rule := range r.rules {
+		placeholder := "[REDACTED_" + rule.Name + "]"
+		text = rule.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.Valid != nil && !rule.Valid(match) {
+				return match
+			}
+			return placeholder
+		})
+	}
+	return text
+}
+
+// luhn reports whether the digits in s pass the Luhn checksum used by card numbers
+func luhn(s string) bool {
+	sum, digits := 0, 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && sum%10 == 0
+}
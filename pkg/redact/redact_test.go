@@ -0,0 +1,66 @@
+package redact
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	r, err := New(map[string]string{"employee_id": `EMP-\d{6}`})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"email", "mail jane.doe@example.com today", "mail [REDACTED_EMAIL] today"},
+		{"card", "card 4111 1111 1111 1111 please", "card [REDACTED_CREDIT_CARD] please"},
+		{"card failing luhn", "order 4111111111111112", "order 4111111111111112"},
+		{"phone", "call +1 (555) 123-4567", "call [REDACTED_PHONE]"},
+		{"dashed phone", "or 555-123-4567", "or [REDACTED_PHONE]"},
+		{"custom", "badge EMP-123456", "badge [REDACTED_EMPLOYEE_ID]"},
+		{"clean", "the meeting is in room 42 on 2024-05-01", "the meeting is in room 42 on 2024-05-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, r.Redact(tt.in))
+		})
+	}
+}
+
+func TestNewRejectsInvalidPattern(t *testing.T) {
+	_, err := New(map[string]string{"bad": "("})
+	assert.Error(t, err)
+}
+
+func TestVaultRoundTrip(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	v, err := NewVault(key)
+	require.NoError(t, err)
+
+	sealed, err := v.Seal("jane.doe@example.com", "msg-1", "org-1")
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "jane")
+
+	opened, err := v.Open(sealed, "msg-1", "org-1")
+	require.NoError(t, err)
+	assert.Equal(t, "jane.doe@example.com", opened)
+
+	_, err = v.Open(sealed[:len(sealed)-4]+"AAAA", "msg-1", "org-1")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	// A ciphertext moved to another message or organization does not open
+	_, err = v.Open(sealed, "msg-2", "org-1")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+	_, err = v.Open(sealed, "msg-1", "org-2")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	_, err = NewVault(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package redact
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidCiphertext is returned when a sealed value cannot be decrypted
+var ErrInvalidCiphertext = errors.New("redact: invalid ciphertext")
+
+// Vault encrypts original text so authorized users can recover what was
+// redacted. Values are sealed with AES-256-GCM and bound to the message and
+// organization they belong to, so a ciphertext copied onto another row does
+// not open.
+type Vault struct {
+	aead cipher.AEAD
+}
+
+// NewVault creates a vault from a base64-encoded 32-byte key
+func NewVault(encodedKey string) (*Vault, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid vault key: want 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Vault{aead: aead}, nil
+}
+
+// Seal encrypts the original of a message and returns it base64-encoded with
+// its nonce
+func (v *Vault) Seal(plaintext, messageID, organizationID string) (string, error) {
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := v.aead.Seal(nonce, nonce, []byte(plaintext), associatedData(messageID, organizationID))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal for the same message and organization
+func (v *Vault) Open(sealed, messageID, organizationID string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < v.aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+	nonce, ciphertext := data[:v.aead.NonceSize()], data[v.aead.NonceSize():]
+	plaintext, err := v.aead.Open(nil, nonce, ciphertext, associatedData(messageID, organizationID))
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plaintext), nil
+}
+
+// associatedData authenticates the IDs a sealed value belongs to. Both are
+// length-prefixed so no two pairs encode alike.
+func associatedData(messageID, organizationID string) []byte {
+	return []byte(fmt.Sprintf("%d:%s%d:%s", len(messageID), messageID, len(organizationID), organizationID))
+}
+
+// Protect redacts the text of a message with r and, when v is not nil, seals
+// the original for that message. sealed is empty when nothing was redacted or
+// there is no vault. A nil r leaves text unchanged.
+func Protect(r *Redactor, v *Vault, text, messageID, organizationID string) (redacted string, sealed string, err error) {
+	if r == nil {
+		return text, "", nil
+	}
+	redacted = r.Redact(text)
+	if redacted == text || v == nil {
+		return redacted, "", nil
+	}
+	sealed, err = v.Seal(text, messageID, organizationID)
+	if err != nil {
+		return "", "", err
+	}
+	return redacted, sealed, nil
+}
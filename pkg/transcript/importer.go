@@ -0,0 +1,150 @@
+package transcript
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/redact"
+)
+
+// SessionStore persists an imported session and its messages in one step
+type SessionStore interface {
+	ImportSession(ctx context.Context, session *models.ChatSession, messages []models.ChatMessage) error
+}
+
+// AssistantLookup finds the assistant an exported session was started with
+type AssistantLookup interface {
+	GetAssistantByID(ctx context.Context, id string) (*models.Assistant, error)
+}
+
+// Imported describes a session created by an import
+type Imported struct {
+	SessionID string `json:"session_id"`
+	Title     string `json:"title,omitempty"`
+	Messages  int    `json:"messages"`
+}
+
+// Importer creates sessions from parsed transcripts. Redactor and Vault are
+// optional and are applied the same way as to live messages.
+type Importer struct {
+	Store      SessionStore
+	Assistants AssistantLookup
+	Redactor   *redact.Redactor
+	Vault      *redact.Vault
+}
+
+// Import stores each transcript as a new session owned by userID. Records get
+// new IDs, but the message tree and timestamps are kept. Sessions imported
+// before an error are returned with it.
+func (i *Importer) Import(ctx context.Context, userID string, exports []*Export) ([]Imported, error) {
+	imported := make([]Imported, 0, len(exports))
+	for _, e := range exports {
+		session, messages := e.Records(userID)
+
+		if session.AssistantID != nil && i.Assistants != nil {
+			// Assistants from another deployment are not carried over
+			assistant, err := i.Assistants.GetAssistantByID(ctx, *session.AssistantID)
+			if err != nil {
+				return imported, err
+			}
+			if assistant == nil {
+				session.AssistantID = nil
+			}
+		} else {
+			session.AssistantID = nil
+		}
+
+		orgID, _ := auth.OrganizationID(ctx)
+		for j := range messages {
+			redacted, sealed, err := redact.Protect(i.Redactor, i.Vault, messages[j].Message, messages[j].ID, orgID)
+			if err != nil {
+				return imported, err
+			}
+			messages[j].Message = redacted
+			if sealed != "" {
+				messages[j].Vault = &models.MessageVault{Ciphertext: sealed}
+			}
+		}
+
+		if err := i.Store.ImportSession(ctx, session, messages); err != nil {
+			return imported, err
+		}
+		imported = append(imported, Imported{
+			SessionID: session.ID,
+			Title:     session.Title,
+			Messages:  len(messages),
+		})
+	}
+	return imported, nil
+}
+
+// Records converts the export into a new session and messages owned by
+// userID. IDs are replaced and the active message defaults to the latest one;
+// the session is open unless the export says it was closed.
+func (e *Export) Records(userID string) (*models.ChatSession, []models.ChatMessage) {
+	ids := make(map[string]string, len(e.Messages))
+	for _, m := range e.Messages {
+		ids[m.ID] = models.NewID()
+	}
+
+	session := &models.ChatSession{
+		ID:            models.NewID(),
+		UserID:        userID,
+		Title:         e.Session.Title,
+		PromptName:    e.Session.PromptName,
+		PromptVersion: e.Session.PromptVersion,
+		IsActive:      e.Session.IsActive == nil || *e.Session.IsActive,
+		CreatedAt:     e.Session.CreatedAt,
+		UpdatedAt:     e.Session.UpdatedAt,
+	}
+	if e.Session.AssistantID != "" {
+		assistantID := e.Session.AssistantID
+		session.AssistantID = &assistantID
+	}
+
+	messages := make([]models.ChatMessage, len(e.Messages))
+	var first, last time.Time
+	for i, m := range e.Messages {
+		created := m.CreatedAt.UTC()
+		msg := models.ChatMessage{
+			ID:        ids[m.ID],
+			SessionID: session.ID,
+			UserID:    userID,
+			Message:   m.Content,
+			Timestamp: created.Format(time.RFC3339),
+			IsBot:     m.Role == RoleAssistant,
+			Model:     m.Model,
+			ToolCalls: m.ToolCalls,
+			Citations: m.Citations,
+			CreatedAt: created,
+			UpdatedAt: created,
+		}
+		if m.ParentID != "" {
+			parentID := ids[m.ParentID]
+			msg.ParentID = &parentID
+		}
+		messages[i] = msg
+
+		if first.IsZero() || created.Before(first) {
+			first = created
+		}
+		if created.After(last) {
+			last = created
+		}
+	}
+
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = first
+	}
+	if session.UpdatedAt.IsZero() {
+		session.UpdatedAt = last
+	}
+
+	if branch := e.ActiveBranch(); len(branch) > 0 {
+		activeID := ids[branch[len(branch)-1].ID]
+		session.ActiveMessageID = &activeID
+	}
+	return session, messages
+}
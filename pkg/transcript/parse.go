@@ -0,0 +1,379 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// ValidationError reports why a transcript in an import was rejected
+type ValidationError struct {
+	// Transcript is the zero-based position of the transcript in the input
+	Transcript int
+	Message    string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("transcript %d: %s", e.Transcript, e.Message)
+}
+
+// openAIMessage is a chat message in the OpenAI format. Timestamp and
+// CreatedAt are optional extensions, as RFC 3339 strings or Unix seconds.
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    json.RawMessage  `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls"`
+	ToolCallID string           `json:"tool_call_id"`
+	Timestamp  json.RawMessage  `json:"timestamp"`
+	CreatedAt  json.RawMessage  `json:"created_at"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAITranscript struct {
+	Title    string          `json:"title"`
+	Messages []openAIMessage `json:"messages"`
+}
+
+// Parse reads transcripts for import and validates them. It accepts one of
+// our session exports or a list of them, and OpenAI-style transcripts: a
+// list of {role, content} messages, an object with a "messages" list, or a
+// list of either. Every transcript is returned in export form.
+func Parse(data []byte) ([]*Export, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, &ValidationError{Message: "input is empty"}
+	}
+
+	if data[0] == '{' {
+		export, err := parseOne(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []*Export{export}, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, &ValidationError{Message: "input must be a JSON object or array: " + err.Error()}
+	}
+	if len(items) == 0 {
+		return nil, &ValidationError{Message: "input contains no transcripts"}
+	}
+
+	// A list of messages is a single transcript
+	if isMessage(items[0]) {
+		export, err := parseMessages(items, "", 0)
+		if err != nil {
+			return nil, err
+		}
+		return []*Export{export}, nil
+	}
+
+	exports := make([]*Export, len(items))
+	for i, item := range items {
+		export, err := parseOne(item, i)
+		if err != nil {
+			return nil, err
+		}
+		exports[i] = export
+	}
+	return exports, nil
+}
+
+// parseOne parses a session export, a transcript object or a list of messages
+func parseOne(data json.RawMessage, index int) (*Export, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var messages []json.RawMessage
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, &ValidationError{Transcript: index, Message: err.Error()}
+		}
+		return parseMessages(messages, "", index)
+	}
+
+	var probe struct {
+		Format string `json:"format"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, &ValidationError{Transcript: index, Message: "must be a JSON object or array: " + err.Error()}
+	}
+
+	if probe.Format != "" {
+		if probe.Format != Format {
+			return nil, &ValidationError{Transcript: index, Message: fmt.Sprintf("unsupported format %q", probe.Format)}
+		}
+		var export Export
+		if err := json.Unmarshal(data, &export); err != nil {
+			return nil, &ValidationError{Transcript: index, Message: err.Error()}
+		}
+		if err := validateExport(&export); err != nil {
+			return nil, &ValidationError{Transcript: index, Message: err.Error()}
+		}
+		return &export, nil
+	}
+
+	var t struct {
+		Title    string            `json:"title"`
+		Messages []json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, &ValidationError{Transcript: index, Message: err.Error()}
+	}
+	if t.Messages == nil {
+		return nil, &ValidationError{Transcript: index, Message: `expected a "messages" list or a "format" field`}
+	}
+	return parseMessages(t.Messages, t.Title, index)
+}
+
+// isMessage reports whether data is an object with a role
+func isMessage(data json.RawMessage) bool {
+	var probe struct {
+		Role *string `json:"role"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Role != nil
+}
+
+// parseMessages converts an OpenAI-style message list into a single-branch
+// export. System messages are dropped. Tool calls are attached to the next
+// assistant reply, and tool results to their calls. Messages without a
+// timestamp are placed a millisecond after the one before.
+func parseMessages(raw []json.RawMessage, title string, index int) (*Export, error) {
+	fail := func(i int, format string, args ...interface{}) error {
+		return &ValidationError{Transcript: index, Message: fmt.Sprintf("message %d: ", i) + fmt.Sprintf(format, args...)}
+	}
+
+	export := &Export{
+		Format:     Format,
+		ExportedAt: time.Now().UTC(),
+		Session:    Session{Title: title},
+	}
+
+	var (
+		pending  models.ToolCalls
+		previous string
+		clock    = time.Now().UTC()
+		started  bool
+	)
+	for i, item := range raw {
+		var m openAIMessage
+		if err := json.Unmarshal(item, &m); err != nil {
+			return nil, fail(i, "%s", err.Error())
+		}
+		content, err := ContentText(m.Content)
+		if err != nil {
+			return nil, fail(i, "%s", err.Error())
+		}
+
+		at, ok, err := messageTime(m)
+		if err != nil {
+			return nil, fail(i, "%s", err.Error())
+		}
+		switch {
+		case ok:
+			clock = at
+		case started:
+			clock = clock.Add(time.Millisecond)
+		}
+		started = true
+
+		switch m.Role {
+		case "system", "developer":
+			continue
+		case "tool":
+			if !attachResult(pending, export.Messages, m.ToolCallID, content) {
+				return nil, fail(i, "tool result for unknown call %q", m.ToolCallID)
+			}
+			continue
+		case RoleAssistant:
+			for _, call := range m.ToolCalls {
+				pending = append(pending, models.ToolCall{
+					ID:        call.ID,
+					Name:      call.Function.Name,
+					Arguments: call.Function.Arguments,
+				})
+			}
+			if content == "" {
+				if len(m.ToolCalls) == 0 {
+					return nil, fail(i, "assistant message has no content")
+				}
+				// Wait for the reply that follows the tool results
+				continue
+			}
+		case RoleUser:
+			if content == "" {
+				return nil, fail(i, "user message has no content")
+			}
+			if tooLong(m.Role, content) {
+				return nil, fail(i, "user message is longer than %d characters", models.MaxMessageLength)
+			}
+		default:
+			return nil, fail(i, "unknown role %q", m.Role)
+		}
+
+		msg := Message{
+			ID:        fmt.Sprintf("m%d", i),
+			ParentID:  previous,
+			Role:      m.Role,
+			Content:   content,
+			CreatedAt: clock,
+		}
+		if m.Role == RoleAssistant {
+			msg.ToolCalls, pending = pending, nil
+		}
+		export.Messages = append(export.Messages, msg)
+		previous = msg.ID
+	}
+
+	// Tool calls that were never followed by a reply are kept on an empty one
+	if len(pending) > 0 {
+		export.Messages = append(export.Messages, Message{
+			ID:        fmt.Sprintf("m%d", len(raw)),
+			ParentID:  previous,
+			Role:      RoleAssistant,
+			ToolCalls: pending,
+			CreatedAt: clock.Add(time.Millisecond),
+		})
+	}
+
+	if len(export.Messages) == 0 {
+		return nil, &ValidationError{Transcript: index, Message: "no user or assistant messages"}
+	}
+	return export, nil
+}
+
+// attachResult stores a tool result on the call it answers, which is either
+// still pending or on an earlier reply
+func attachResult(pending models.ToolCalls, messages []Message, callID, result string) bool {
+	for i := range pending {
+		if pending[i].ID == callID {
+			pending[i].Result = result
+			return true
+		}
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		for j := range messages[i].ToolCalls {
+			if messages[i].ToolCalls[j].ID == callID {
+				messages[i].ToolCalls[j].Result = result
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ContentText returns message content given as a string, null, or a list of
+// parts of which the text parts are kept
+func ContentText(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", errors.New("content must be a string or a list of parts")
+	}
+	var texts []string
+	for _, p := range parts {
+		if p.Type == "text" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// messageTime reads the optional timestamp of a message
+func messageTime(m openAIMessage) (time.Time, bool, error) {
+	raw := m.Timestamp
+	if len(raw) == 0 {
+		raw = m.CreatedAt
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return time.Time{}, false, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return time.Time{}, false, errors.New("timestamp must be RFC 3339 or Unix seconds")
+		}
+		return t.UTC(), true, nil
+	}
+
+	var secs float64
+	if err := json.Unmarshal(raw, &secs); err != nil {
+		return time.Time{}, false, errors.New("timestamp must be RFC 3339 or Unix seconds")
+	}
+	return time.Unix(0, int64(secs*float64(time.Second))).UTC(), true, nil
+}
+
+// tooLong reports whether a message is a user message longer than a live one
+// may be
+func tooLong(role, content string) bool {
+	return role == RoleUser && utf8.RuneCountInString(content) > models.MaxMessageLength
+}
+
+// validateExport checks that an export's messages form a tree and that no
+// user message is longer than a live one may be
+func validateExport(e *Export) error {
+	if len(e.Messages) == 0 {
+		return errors.New("no messages")
+	}
+
+	byID := make(map[string]Message, len(e.Messages))
+	for i, m := range e.Messages {
+		if m.ID == "" {
+			return fmt.Errorf("message %d: missing id", i)
+		}
+		if _, dup := byID[m.ID]; dup {
+			return fmt.Errorf("message %d: duplicate id %q", i, m.ID)
+		}
+		if m.Role != RoleUser && m.Role != RoleAssistant {
+			return fmt.Errorf("message %d: unknown role %q", i, m.Role)
+		}
+		if tooLong(m.Role, m.Content) {
+			return fmt.Errorf("message %d: user message is longer than %d characters", i, models.MaxMessageLength)
+		}
+		byID[m.ID] = m
+	}
+
+	for i, m := range e.Messages {
+		depth := 0
+		for parent := m.ParentID; parent != ""; parent = byID[parent].ParentID {
+			if _, ok := byID[parent]; !ok {
+				return fmt.Errorf("message %d: unknown parent %q", i, parent)
+			}
+			if depth++; depth > len(e.Messages) {
+				return fmt.Errorf("message %d: parent links form a cycle", i)
+			}
+		}
+	}
+
+	if id := e.Session.ActiveMessageID; id != "" {
+		if _, ok := byID[id]; !ok {
+			return fmt.Errorf("unknown active message %q", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package transcript
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+func TestParseOpenAIMessages(t *testing.T) {
+	input := `[
+		{"role": "system", "content": "Be brief"},
+		{"role": "user", "content": "Weather in Oslo?", "timestamp": "2024-05-01T12:00:00Z"},
+		{"role": "assistant", "content": null, "tool_calls": [
+			{"id": "call_1", "type": "function", "function": {"name": "weather", "arguments": "{\"city\":\"Oslo\"}"}}
+		]},
+		{"role": "tool", "tool_call_id": "call_1", "content": "12C"},
+		{"role": "assistant", "content": [{"type": "text", "text": "It is 12C."}], "created_at": 1714564805}
+	]`
+
+	exports, err := Parse([]byte(input))
+	require.NoError(t, err)
+	require.Len(t, exports, 1)
+
+	messages := exports[0].Messages
+	require.Len(t, messages, 2)
+	assert.Equal(t, RoleUser, messages[0].Role)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), messages[0].CreatedAt)
+
+	assert.Equal(t, RoleAssistant, messages[1].Role)
+	assert.Equal(t, "It is 12C.", messages[1].Content)
+	assert.Equal(t, messages[0].ID, messages[1].ParentID)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 5, 0, time.UTC), messages[1].CreatedAt)
+	require.Len(t, messages[1].ToolCalls, 1)
+	assert.Equal(t, "weather", messages[1].ToolCalls[0].Name)
+	assert.Equal(t, "12C", messages[1].ToolCalls[0].Result)
+}
+
+func TestParseTranscriptList(t *testing.T) {
+	input := `[
+		{"title": "First", "messages": [{"role": "user", "content": "Hi"}]},
+		[{"role": "user", "content": "Hello"}, {"role": "assistant", "content": "Hey"}]
+	]`
+
+	exports, err := Parse([]byte(input))
+	require.NoError(t, err)
+	require.Len(t, exports, 2)
+	assert.Equal(t, "First", exports[0].Session.Title)
+	require.Len(t, exports[1].Messages, 2)
+	assert.True(t, exports[1].Messages[1].CreatedAt.After(exports[1].Messages[0].CreatedAt))
+}
+
+func TestParseExportRoundTrip(t *testing.T) {
+	data, err := json.Marshal(testExport())
+	require.NoError(t, err)
+
+	exports, err := Parse(data)
+	require.NoError(t, err)
+	require.Len(t, exports, 1)
+	assert.Equal(t, testExport().Messages, exports[0].Messages)
+
+	session, messages := exports[0].Records("user-1")
+	require.Len(t, messages, 3)
+	assert.NotEqual(t, "u1", messages[0].ID)
+	assert.Equal(t, messages[0].ID, *messages[2].ParentID)
+	assert.Equal(t, messages[2].ID, *session.ActiveMessageID)
+	assert.Equal(t, "2024-05-01T12:00:02Z", messages[2].Timestamp)
+	assert.Equal(t, messages[0].CreatedAt, session.CreatedAt)
+	for _, m := range messages {
+		assert.Equal(t, session.ID, m.SessionID)
+		assert.Equal(t, "user-1", m.UserID)
+	}
+}
+
+func TestExportSkipsDeletedMessages(t *testing.T) {
+	at := func(sec int) time.Time { return time.Date(2024, 5, 1, 12, 0, sec, 0, time.UTC) }
+	deleted := gorm.DeletedAt{Time: at(10), Valid: true}
+	session := &models.ChatSession{ID: "s1", ActiveMessageID: ptr("a2")}
+	messages := []models.ChatMessage{
+		{ID: "u1", Message: "Hi", CreatedAt: at(0), DeletedAt: deleted},
+		{ID: "a1", ParentID: ptr("u1"), IsBot: true, Message: "Hello", CreatedAt: at(1)},
+		{ID: "u2", ParentID: ptr("a1"), Message: "Help", CreatedAt: at(2), DeletedAt: deleted},
+		{ID: "a2", ParentID: ptr("u2"), IsBot: true, Message: "Sure", CreatedAt: at(3), DeletedAt: deleted},
+	}
+
+	export := NewExport(session, nil, messages)
+	require.Len(t, export.Messages, 1)
+	assert.Equal(t, "a1", export.Messages[0].ID)
+	assert.Empty(t, export.Messages[0].ParentID)
+	assert.Equal(t, "a1", export.Session.ActiveMessageID)
+
+	// A child of a deleted message hangs off the nearest surviving ancestor
+	messages = append(messages, models.ChatMessage{ID: "u3", ParentID: ptr("a2"), Message: "Thanks", CreatedAt: at(4)})
+	session.ActiveMessageID = ptr("u3")
+	export = NewExport(session, nil, messages)
+	require.Len(t, export.Messages, 2)
+	assert.Equal(t, "a1", export.Messages[1].ParentID)
+
+	data, err := json.Marshal(export)
+	require.NoError(t, err)
+	exports, err := Parse(data)
+	require.NoError(t, err)
+	require.Len(t, exports, 1)
+
+	imported, records := exports[0].Records("user-1")
+	require.Len(t, records, 2)
+	assert.Nil(t, records[0].ParentID)
+	assert.Equal(t, records[0].ID, *records[1].ParentID)
+	assert.Equal(t, records[1].ID, *imported.ActiveMessageID)
+}
+
+func TestImportKeepsClosedState(t *testing.T) {
+	export := NewExport(&models.ChatSession{ID: "s1", IsActive: false}, nil, []models.ChatMessage{{ID: "u1", Message: "Hi"}})
+	data, err := json.Marshal(export)
+	require.NoError(t, err)
+
+	exports, err := Parse(data)
+	require.NoError(t, err)
+	session, _ := exports[0].Records("user-1")
+	assert.False(t, session.IsActive)
+
+	// Transcripts that do not say import as open sessions
+	exports, err = Parse([]byte(`[{"role": "user", "content": "Hi"}]`))
+	require.NoError(t, err)
+	session, _ = exports[0].Records("user-1")
+	assert.True(t, session.IsActive)
+}
+
+func TestParseRejectsInvalidInput(t *testing.T) {
+	long := strings.Repeat("a", models.MaxMessageLength+1)
+	tests := map[string]string{
+		"empty":          ``,
+		"no transcripts": `[]`,
+		"unknown role":   `[{"role": "robot", "content": "beep"}]`,
+		"empty content":  `[{"role": "user", "content": ""}]`,
+		"only system":    `[{"role": "system", "content": "Be brief"}]`,
+		"bad timestamp":  `[{"role": "user", "content": "Hi", "timestamp": "yesterday"}]`,
+		"unknown tool":   `[{"role": "user", "content": "Hi"}, {"role": "tool", "tool_call_id": "x", "content": "?"}]`,
+		"wrong format":   `{"format": "other/v1", "messages": []}`,
+		"missing parent": `{"format": "chat-agent.session/v1", "messages": [{"id": "a", "parent_id": "b", "role": "user"}]}`,
+		"cycle": `{"format": "chat-agent.session/v1", "messages": [
+			{"id": "a", "parent_id": "b", "role": "user"}, {"id": "b", "parent_id": "a", "role": "assistant"}]}`,
+		"long message":        `[{"role": "user", "content": "` + long + `"}]`,
+		"long export message": `{"format": "chat-agent.session/v1", "messages": [{"id": "a", "role": "user", "content": "` + long + `"}]}`,
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(input))
+			var verr *ValidationError
+			assert.ErrorAs(t, err, &verr)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package transcript
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+const timeLayout = "2006-01-02 15:04:05 MST"
+
+// speaker names the author of a message
+func (e *Export) speaker(m Message) string {
+	if m.Role != RoleAssistant {
+		return "User"
+	}
+	if e.Session.AssistantName != "" {
+		return e.Session.AssistantName
+	}
+	return "Assistant"
+}
+
+// Markdown writes the active branch of the session as Markdown, suitable for
+// pasting into a ticket
+func Markdown(w io.Writer, e *Export) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", e.Title())
+	fmt.Fprintf(&b, "- Session: `%s`\n", e.Session.ID)
+	if e.Session.AssistantName != "" {
+		fmt.Fprintf(&b, "- Assistant: %s\n", e.Session.AssistantName)
+	}
+	fmt.Fprintf(&b, "- Exported: %s\n", e.ExportedAt.UTC().Format(timeLayout))
+
+	for _, m := range e.ActiveBranch() {
+		b.WriteString("\n---\n\n")
+		fmt.Fprintf(&b, "**%s**", e.speaker(m))
+		if m.Model != "" {
+			fmt.Fprintf(&b, " (%s)", m.Model)
+		}
+		fmt.Fprintf(&b, " · %s\n\n", m.CreatedAt.UTC().Format(timeLayout))
+		b.WriteString(m.Content)
+		b.WriteString("\n")
+
+		if len(m.ToolCalls) > 0 {
+			b.WriteString("\nTool calls:\n\n")
+			for _, call := range m.ToolCalls {
+				fmt.Fprintf(&b, "- `%s`", call.Name)
+				if call.Arguments != "" {
+					fmt.Fprintf(&b, " with `%s`", call.Arguments)
+				}
+				if call.Result != "" {
+					fmt.Fprintf(&b, " → %s", oneLine(call.Result))
+				}
+				b.WriteString("\n")
+			}
+		}
+
+		if len(m.Citations) > 0 {
+			b.WriteString("\nSources:\n\n")
+			for i, c := range m.Citations {
+				title := c.Title
+				if title == "" {
+					title = c.Source
+				}
+				if c.URL != "" {
+					fmt.Fprintf(&b, "%d. [%s](%s)", i+1, title, c.URL)
+				} else {
+					fmt.Fprintf(&b, "%d. %s", i+1, title)
+				}
+				if c.Snippet != "" {
+					fmt.Fprintf(&b, " — %s", oneLine(c.Snippet))
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var htmlTemplate = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.UTC().Format(timeLayout) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Export.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 48rem; margin: 2rem auto; line-height: 1.5; }
+.message { border-top: 1px solid #ddd; padding: 1rem 0; }
+.meta { color: #666; font-size: 0.875rem; }
+.content { white-space: pre-wrap; }
+.assistant .author { color: #0b5cad; }
+</style>
+</head>
+<body>
+<h1>{{.Export.Title}}</h1>
+<p class="meta">Session {{.Export.Session.ID}}{{if .Export.Session.AssistantName}} · Assistant {{.Export.Session.AssistantName}}{{end}} · Exported {{time .Export.ExportedAt}}</p>
+{{range .Messages}}
+<div class="message {{.Role}}">
+<p class="meta"><strong class="author">{{.Speaker}}</strong>{{if .Model}} ({{.Model}}){{end}} · {{time .CreatedAt}}</p>
+<div class="content">{{.Content}}</div>
+{{- if .ToolCalls}}
+<p class="meta">Tool calls</p>
+<ul>{{range .ToolCalls}}<li><code>{{.Name}}</code>{{if .Arguments}} with <code>{{.Arguments}}</code>{{end}}{{if .Result}} → {{.Result}}{{end}}</li>{{end}}</ul>
+{{- end}}
+{{- if .Citations}}
+<p class="meta">Sources</p>
+<ol>{{range .Citations}}<li>{{if .URL}}<a href="{{.URL}}">{{if .Title}}{{.Title}}{{else}}{{.Source}}{{end}}</a>{{else}}{{if .Title}}{{.Title}}{{else}}{{.Source}}{{end}}{{end}}{{if .Snippet}} — {{.Snippet}}{{end}}</li>{{end}}</ol>
+{{- end}}
+</div>
+{{end}}
+</body>
+</html>
+`))
+
+// htmlMessage is a message with its author's display name
+type htmlMessage struct {
+	Message
+	Speaker string
+}
+
+// HTML writes the active branch of the session as a standalone HTML page
+func HTML(w io.Writer, e *Export) error {
+	branch := e.ActiveBranch()
+	messages := make([]htmlMessage, len(branch))
+	for i, m := range branch {
+		messages[i] = htmlMessage{Message: m, Speaker: e.speaker(m)}
+	}
+
+	return htmlTemplate.Execute(w, struct {
+		Export   *Export
+		Messages []htmlMessage
+	}{e, messages})
+}
@@ -0,0 +1,174 @@
+package transcript
+
+import (
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// Format identifies the JSON session export format, so an import can tell it
+// apart from other transcripts
+const Format = "chat-agent.session/v1"
+
+// Message roles used in transcripts
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Session describes the exported session. IsActive is false for a closed
+// session; transcripts without it import as open sessions.
+type Session struct {
+	ID              string    `json:"id"`
+	Title           string    `json:"title,omitempty"`
+	UserID          string    `json:"user_id,omitempty"`
+	AssistantID     string    `json:"assistant_id,omitempty"`
+	AssistantName   string    `json:"assistant_name,omitempty"`
+	PromptName      string    `json:"prompt_name,omitempty"`
+	PromptVersion   int       `json:"prompt_version,omitempty"`
+	ActiveMessageID string    `json:"active_message_id,omitempty"`
+	IsActive        *bool     `json:"is_active,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Message is one message of a transcript. ParentID links it into the
+// session's tree of branches.
+type Message struct {
+	ID        string           `json:"id"`
+	ParentID  string           `json:"parent_id,omitempty"`
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Model     string           `json:"model,omitempty"`
+	ToolCalls models.ToolCalls `json:"tool_calls,omitempty"`
+	Citations models.Citations `json:"citations,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// Export is a lossless copy of a session: every branch of the conversation,
+// with tool calls and citations
+type Export struct {
+	Format     string    `json:"format"`
+	ExportedAt time.Time `json:"exported_at"`
+	Session    Session   `json:"session"`
+	Messages   []Message `json:"messages"`
+}
+
+// NewExport builds an export from a session, its assistant, which may be nil,
+// and all of its messages. Deleted messages are left out; their children are
+// attached to the nearest surviving ancestor, and an active message that was
+// deleted is replaced by that ancestor too, so the export stays one valid tree.
+func NewExport(session *models.ChatSession, assistant *models.Assistant, messages []models.ChatMessage) *Export {
+	isActive := session.IsActive
+	export := &Export{
+		Format:     Format,
+		ExportedAt: time.Now().UTC(),
+		Session: Session{
+			ID:            session.ID,
+			Title:         session.Title,
+			UserID:        session.UserID,
+			PromptName:    session.PromptName,
+			PromptVersion: session.PromptVersion,
+			IsActive:      &isActive,
+			CreatedAt:     session.CreatedAt,
+			UpdatedAt:     session.UpdatedAt,
+		},
+		Messages: make([]Message, 0, len(messages)),
+	}
+	if session.AssistantID != nil {
+		export.Session.AssistantID = *session.AssistantID
+	}
+	if assistant != nil {
+		export.Session.AssistantName = assistant.Name
+	}
+
+	byID := make(map[string]models.ChatMessage, len(messages))
+	for _, m := range messages {
+		byID[m.ID] = m
+	}
+	if session.ActiveMessageID != nil {
+		export.Session.ActiveMessageID = survivingAncestor(byID, *session.ActiveMessageID)
+	}
+
+	for _, m := range messages {
+		if m.DeletedAt.Valid {
+			continue
+		}
+		msg := Message{
+			ID:        m.ID,
+			Role:      RoleUser,
+			Content:   m.Message,
+			Model:     m.Model,
+			ToolCalls: m.ToolCalls,
+			Citations: m.Citations,
+			CreatedAt: m.CreatedAt,
+		}
+		if m.IsBot {
+			msg.Role = RoleAssistant
+		}
+		if m.ParentID != nil {
+			msg.ParentID = survivingAncestor(byID, *m.ParentID)
+		}
+		export.Messages = append(export.Messages, msg)
+	}
+	return export
+}
+
+// survivingAncestor returns id if that message is live, or else its nearest
+// live ancestor. It returns "" when none is left or the chain leaves the
+// given messages.
+func survivingAncestor(byID map[string]models.ChatMessage, id string) string {
+	for seen := 0; seen <= len(byID); seen++ {
+		m, ok := byID[id]
+		if !ok {
+			return ""
+		}
+		if !m.DeletedAt.Valid {
+			return id
+		}
+		if m.ParentID == nil {
+			return ""
+		}
+		id = *m.ParentID
+	}
+	return ""
+}
+
+// ActiveBranch returns the messages on the session's active branch, oldest
+// first. Without an active message, the branch of the latest message is used.
+func (e *Export) ActiveBranch() []Message {
+	if len(e.Messages) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]Message, len(e.Messages))
+	latest := e.Messages[0]
+	for _, m := range e.Messages {
+		byID[m.ID] = m
+		if m.CreatedAt.After(latest.CreatedAt) {
+			latest = m
+		}
+	}
+
+	leaf, ok := byID[e.Session.ActiveMessageID]
+	if !ok {
+		leaf = latest
+	}
+
+	var branch []Message
+	for m, ok := leaf, true; ok && len(branch) <= len(e.Messages); m, ok = byID[m.ParentID] {
+		branch = append(branch, m)
+	}
+	for i, j := 0, len(branch)-1; i < j; i, j = i+1, j-1 {
+		branch[i], branch[j] = branch[j], branch[i]
+	}
+	return branch
+}
+
+// Title returns the session title, or a name derived from its ID
+func (e *Export) Title() string {
+	if e.Session.Title != "" {
+		return e.Session.Title
+	}
+	return "Conversation " + e.Session.ID
+}
@@ -0,0 +1,63 @@
+package transcript
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+func ptr(s string) *string { return &s }
+
+func testExport() *Export {
+	at := func(sec int) time.Time { return time.Date(2024, 5, 1, 12, 0, sec, 0, time.UTC) }
+	session := &models.ChatSession{ID: "s1", ActiveMessageID: ptr("a2")}
+	assistant := &models.Assistant{Name: "Helpdesk"}
+	messages := []models.ChatMessage{
+		{ID: "u1", Message: "Reset my <password>", CreatedAt: at(0)},
+		{ID: "a1", ParentID: ptr("u1"), IsBot: true, Message: "First answer", CreatedAt: at(1)},
+		{ID: "a2", ParentID: ptr("u1"), IsBot: true, Message: "Second answer", Model: "gpt-x", CreatedAt: at(2),
+			ToolCalls: models.ToolCalls{{Name: "reset_password", Arguments: `{"user":"jane"}`, Result: "ok"}},
+			Citations: models.Citations{{Source: "kb-1", Title: "Password policy", URL: "https://kb.example.com/1"}},
+		},
+	}
+	return NewExport(session, assistant, messages)
+}
+
+func TestActiveBranch(t *testing.T) {
+	e := testExport()
+
+	branch := e.ActiveBranch()
+	require.Len(t, branch, 2)
+	assert.Equal(t, "u1", branch[0].ID)
+	assert.Equal(t, "a2", branch[1].ID)
+
+	e.Session.ActiveMessageID = ""
+	branch = e.ActiveBranch()
+	assert.Equal(t, "a2", branch[len(branch)-1].ID)
+}
+
+func TestMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Markdown(&buf, testExport()))
+
+	out := buf.String()
+	assert.Contains(t, out, "# Conversation s1")
+	assert.Contains(t, out, "**Helpdesk** (gpt-x) · 2024-05-01 12:00:02 UTC")
+	assert.Contains(t, out, "- `reset_password` with `{\"user\":\"jane\"}` → ok")
+	assert.Contains(t, out, "1. [Password policy](https://kb.example.com/1)")
+	assert.NotContains(t, out, "First answer")
+}
+
+func TestHTMLEscapesContent(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, HTML(&buf, testExport()))
+
+	out := buf.String()
+	assert.Contains(t, out, "Reset my &lt;password&gt;")
+	assert.Contains(t, out, `<a href="https://kb.example.com/1">Password policy</a>`)
+}
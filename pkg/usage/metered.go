@@ -0,0 +1,137 @@
+package usage
+
+import (
+	"context"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// Recorder stores usage records
+type Recorder interface {
+	RecordUsage(ctx context.Context, record *models.UsageRecord) error
+}
+
+// MeteredProvider records the tokens and cost of every completion made by the
+// provider it wraps, attributed to the identity in the request context
+type MeteredProvider struct {
+	next     llm.Provider
+	recorder Recorder
+	prices   PriceTable
+	logger   logger.Logger
+}
+
+// NewMeteredProvider wraps next so that its usage is recorded
+func NewMeteredProvider(next llm.Provider, recorder Recorder, prices PriceTable, logger logger.Logger) *MeteredProvider {
+	return &MeteredProvider{
+		next:     next,
+		recorder: recorder,
+		prices:   prices,
+		logger:   logger,
+	}
+}
+
+// Name implements llm.Provider
+func (p *MeteredProvider) Name() string {
+	return p.next.Name()
+}
+
+// Complete implements llm.Provider. A failure to record usage is logged and
+// does not fail the completion.
+func (p *MeteredProvider) Complete(ctx context.Context, req llm.Request) (*llm.Response, error) {
+	resp, err := p.next.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	p.record(ctx, req, resp)
+	return resp, nil
+}
+
+// Stream implements llm.Streamer. Usage is recorded once the stream has
+// completed.
+func (p *MeteredProvider) Stream(ctx context.Context, req llm.Request, onDelta func(string) error) (*llm.Response, error) {
+	resp, err := llm.Stream(ctx, p.next, req, onDelta)
+	if err != nil {
+		return nil, err
+	}
+	p.record(ctx, req, resp)
+	return resp, nil
+}
+
+func (p *MeteredProvider) record(ctx context.Context, req llm.Request, resp *llm.Response) {
+	model := resp.Model
+	if model == "" {
+		model = req.Model
+	}
+	record(ctx, p.recorder, p.prices, p.logger, resp.Provider, model, resp.Usage)
+}
+
+// MeteredEmbedder records the tokens and cost of every embedding made by the
+// embedder it wraps, like MeteredProvider does for completions
+type MeteredEmbedder struct {
+	next     llm.Embedder
+	recorder Recorder
+	prices   PriceTable
+	logger   logger.Logger
+}
+
+// NewMeteredEmbedder wraps next so that its usage is recorded
+func NewMeteredEmbedder(next llm.Embedder, recorder Recorder, prices PriceTable, logger logger.Logger) *MeteredEmbedder {
+	return &MeteredEmbedder{
+		next:     next,
+		recorder: recorder,
+		prices:   prices,
+		logger:   logger,
+	}
+}
+
+// Name implements llm.Embedder
+func (e *MeteredEmbedder) Name() string {
+	return e.next.Name()
+}
+
+// Embed implements llm.Embedder. A failure to record usage is logged and does
+// not fail the embedding.
+func (e *MeteredEmbedder) Embed(ctx context.Context, req llm.EmbedRequest) (*llm.EmbedResponse, error) {
+	resp, err := e.next.Embed(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = req.Model
+	}
+	record(ctx, e.recorder, e.prices, e.logger, resp.Provider, model, resp.Usage)
+	return resp, nil
+}
+
+// record stores the usage of one provider call, attributed to the identity in
+// ctx
+func record(ctx context.Context, recorder Recorder, prices PriceTable, log logger.Logger, provider, model string, u llm.Usage) {
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		log.Warn("Provider call without an identity is not metered", logger.F("model", model))
+		return
+	}
+
+	usageRecord := &models.UsageRecord{
+		OrganizationID:   identity.OrganizationID,
+		UserID:           identity.UserID,
+		APIKeyID:         identity.APIKeyID,
+		Provider:         provider,
+		Model:            model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.PromptTokens + u.CompletionTokens,
+		Cost:             prices.Cost(model, u.PromptTokens, u.CompletionTokens),
+	}
+	if err := recorder.RecordUsage(ctx, usageRecord); err != nil {
+		log.Error("Failed to record usage",
+			logger.F("model", model),
+			logger.F("error", err.Error()),
+		)
+	}
+}
@@ -0,0 +1,164 @@
+// Package usage prices provider calls and enforces token quotas
+package usage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+)
+
+// Price is what a model costs in US dollars per million tokens
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
+
+// PriceTable maps model names to prices
+type PriceTable map[string]Price
+
+// Cost returns the price of a call to model. Unknown models cost nothing.
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := t[model]
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1e6
+}
+
+// Scope is who a quota applies to
+type Scope string
+
+// Quota scopes
+const (
+	ScopeUser   Scope = "user"
+	ScopeKey    Scope = "key"
+	ScopeTenant Scope = "tenant"
+)
+
+// Quota periods
+const (
+	PeriodDaily   = "daily"
+	PeriodMonthly = "monthly"
+)
+
+// Limits caps the tokens used per UTC day and calendar month; zero is unlimited
+type Limits struct {
+	Daily   int64 `json:"daily"`
+	Monthly int64 `json:"monthly"`
+}
+
+// Quotas holds the limits for each scope
+type Quotas map[Scope]Limits
+
+// Counts is the number of tokens used so far in the current day and month
+type Counts struct {
+	Daily   int64 `json:"daily"`
+	Monthly int64 `json:"monthly"`
+}
+
+// Totals holds the token counts of each scope
+type Totals map[Scope]Counts
+
+// Store adds up the tokens used by an identity's user, key and tenant since
+// the start of the given day and month
+type Store interface {
+	UsageTotals(ctx context.Context, identity auth.Identity, day, month time.Time) (Totals, error)
+}
+
+// QuotaError reports a quota that has been used up
+type QuotaError struct {
+	Scope   Scope     `json:"scope"`
+	Period  string    `json:"period"`
+	Limit   int64     `json:"limit"`
+	Used    int64     `json:"used"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s %s token quota of %d exceeded", e.Scope, e.Period, e.Limit)
+}
+
+// Periods returns the start of the UTC day and month containing now and the
+// times they reset
+func Periods(now time.Time) (day, month, nextDay, nextMonth time.Time) {
+	now = now.UTC()
+	day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, month, day.AddDate(0, 0, 1), month.AddDate(0, 1, 0)
+}
+
+// Check returns a QuotaError for the first limit totals have reached. Scopes
+// without totals, such as the key scope for session-less callers, are skipped.
+func (q Quotas) Check(totals Totals, now time.Time) *QuotaError {
+	_, _, nextDay, nextMonth := Periods(now)
+	for _, scope := range []Scope{ScopeUser, ScopeKey, ScopeTenant} {
+		counts, ok := totals[scope]
+		if !ok {
+			continue
+		}
+		limits := q[scope]
+		if limits.Daily > 0 && counts.Daily >= limits.Daily {
+			return &QuotaError{Scope: scope, Period: PeriodDaily, Limit: limits.Daily, Used: counts.Daily, ResetAt: nextDay}
+		}
+		if limits.Monthly > 0 && counts.Monthly >= limits.Monthly {
+			return &QuotaError{Scope: scope, Period: PeriodMonthly, Limit: limits.Monthly, Used: counts.Monthly, ResetAt: nextMonth}
+		}
+	}
+	return nil
+}
+
+// enabled reports whether any limit is set
+func (q Quotas) enabled() bool {
+	for _, limits := range q {
+		if limits.Daily > 0 || limits.Monthly > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Enforcer checks callers against their quotas before provider calls
+type Enforcer struct {
+	store  Store
+	quotas Quotas
+	now    func() time.Time
+}
+
+// NewEnforcer creates an enforcer for the given quotas
+func NewEnforcer(store Store, quotas Quotas) *Enforcer {
+	return &Enforcer{
+		store:  store,
+		quotas: quotas,
+		now:    time.Now,
+	}
+}
+
+// Quotas returns the configured limits
+func (e *Enforcer) Quotas() Quotas {
+	return e.quotas
+}
+
+// Totals returns the tokens the identity's user, key and tenant have used in
+// the current day and month
+func (e *Enforcer) Totals(ctx context.Context, identity auth.Identity) (Totals, error) {
+	day, month, _, _ := Periods(e.now())
+	return e.store.UsageTotals(ctx, identity, day, month)
+}
+
+// Allow returns a QuotaError when the identity has used up a quota. Other
+// errors come from the store.
+func (e *Enforcer) Allow(ctx context.Context, identity auth.Identity) error {
+	if !e.quotas.enabled() {
+		return nil
+	}
+	totals, err := e.Totals(ctx, identity)
+	if err != nil {
+		return err
+	}
+	if exceeded := e.quotas.Check(totals, e.now()); exceeded != nil {
+		return exceeded
+	}
+	return nil
+}
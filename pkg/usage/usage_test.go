@@ -0,0 +1,105 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/llm"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+func TestPriceTableCost(t *testing.T) {
+	prices := PriceTable{"gpt-x": {Prompt: 2, Completion: 8}}
+
+	assert.InDelta(t, 0.0036, prices.Cost("gpt-x", 1000, 200), 1e-9)
+	assert.Zero(t, prices.Cost("unknown", 1000, 200))
+}
+
+func TestPeriods(t *testing.T) {
+	day, month, nextDay, nextMonth := Periods(time.Date(2024, 12, 31, 23, 30, 0, 0, time.UTC))
+
+	assert.Equal(t, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), day)
+	assert.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), month)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), nextDay)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), nextMonth)
+}
+
+func TestQuotasCheck(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	quotas := Quotas{
+		ScopeUser:   {Daily: 100},
+		ScopeKey:    {Daily: 50},
+		ScopeTenant: {Monthly: 1000},
+	}
+
+	tests := []struct {
+		name   string
+		totals Totals
+		scope  Scope
+		period string
+	}{
+		{"within limits", Totals{ScopeUser: {Daily: 99}, ScopeTenant: {Monthly: 999}}, "", ""},
+		{"user daily", Totals{ScopeUser: {Daily: 100}}, ScopeUser, PeriodDaily},
+		{"key daily", Totals{ScopeUser: {Daily: 10}, ScopeKey: {Daily: 60}}, ScopeKey, PeriodDaily},
+		{"key skipped without a key", Totals{ScopeUser: {Daily: 10}}, "", ""},
+		{"tenant monthly", Totals{ScopeTenant: {Daily: 5, Monthly: 1000}}, ScopeTenant, PeriodMonthly},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exceeded := quotas.Check(tt.totals, now)
+			if tt.scope == "" {
+				assert.Nil(t, exceeded)
+				return
+			}
+			require.NotNil(t, exceeded)
+			assert.Equal(t, tt.scope, exceeded.Scope)
+			assert.Equal(t, tt.period, exceeded.Period)
+		})
+	}
+
+	exceeded := quotas.Check(Totals{ScopeTenant: {Monthly: 1000}}, now)
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), exceeded.ResetAt)
+}
+
+type fakeRecorder struct {
+	records []*models.UsageRecord
+}
+
+func (r *fakeRecorder) RecordUsage(ctx context.Context, record *models.UsageRecord) error {
+	r.records = append(r.records, record)
+	return nil
+}
+
+func TestMeteredProviderRecordsUsage(t *testing.T) {
+	recorder := &fakeRecorder{}
+	provider := NewMeteredProvider(llm.NewEchoProvider(), recorder,
+		PriceTable{"gpt-x": {Prompt: 1, Completion: 1}}, logger.NewLogrusLogger("error", "text"))
+
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: "u1", OrganizationID: "org1", APIKeyID: "k1"})
+	resp, err := provider.Complete(ctx, llm.Request{
+		Model:    "gpt-x",
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: "two words"}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, recorder.records, 1)
+	record := recorder.records[0]
+	assert.Equal(t, "u1", record.UserID)
+	assert.Equal(t, "k1", record.APIKeyID)
+	assert.Equal(t, "gpt-x", record.Model)
+	assert.Equal(t, resp.Usage.PromptTokens+resp.Usage.CompletionTokens, record.TotalTokens)
+	assert.InDelta(t, float64(record.TotalTokens)/1e6, record.Cost, 1e-12)
+
+	_, err = provider.Complete(context.Background(), llm.Request{
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: "anonymous"}},
+	})
+	require.NoError(t, err)
+	assert.Len(t, recorder.records, 1)
+}
@@ -0,0 +1,293 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+// maxResponseBody is how much of an endpoint's response is kept in the log
+const maxResponseBody = 1024
+
+// Store keeps endpoints and the delivery log
+type Store interface {
+	// SubscribedEndpoints returns the active endpoints of the organization in
+	// the context that subscribe to event
+	SubscribedEndpoints(ctx context.Context, event string) ([]models.WebhookEndpoint, error)
+	// GetEndpoint returns an endpoint of the organization in the context, or
+	// nil
+	GetEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error)
+	CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	// ClaimDeliveries marks up to limit due deliveries of any organization as
+	// sending for lease and returns them, with their attempt counted
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	// RecordAttempt stores the outcome of a claimed delivery attempt. It is
+	// ignored if the delivery has since been claimed again.
+	RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error
+	// PruneDeliveries deletes finished deliveries created before cutoff
+	PruneDeliveries(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// Options configures a Dispatcher. A failed delivery is retried after
+// BaseDelay, doubling up to MaxDelay with jitter, until it has been tried
+// MaxAttempts times. Finished deliveries are kept in the log for Retention;
+// zero keeps them forever. Endpoints on loopback and private addresses are
+// only called with AllowPrivateNetworks.
+type Options struct {
+	PollInterval         time.Duration
+	BatchSize            int
+	Timeout              time.Duration
+	MaxAttempts          int
+	BaseDelay            time.Duration
+	MaxDelay             time.Duration
+	Retention            time.Duration
+	AllowPrivateNetworks bool
+}
+
+// Dispatcher records events for the endpoints subscribed to them and
+// delivers them in the background
+type Dispatcher struct {
+	store  Store
+	opts   Options
+	client *http.Client
+	logger logger.Logger
+	wake   chan struct{}
+	now    func() time.Time
+}
+
+// NewDispatcher creates a dispatcher
+func NewDispatcher(store Store, opts Options, logger logger.Logger) *Dispatcher {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = 1
+	}
+	return &Dispatcher{
+		store:  store,
+		opts:   opts,
+		client: NewClient(opts.Timeout, opts.AllowPrivateNetworks),
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+		now:    time.Now,
+	}
+}
+
+// Publish records an event of the organization in the context for every
+// endpoint subscribed to it. userID is the user the event concerns. Failures
+// are logged: a chat request never fails because of a webhook.
+func (d *Dispatcher) Publish(ctx context.Context, event, userID string, data interface{}) {
+	if err := d.publish(ctx, event, userID, data); err != nil {
+		d.logger.Error("Failed to publish webhook event",
+			logger.F("event", event),
+			logger.F("error", err.Error()),
+		)
+	}
+}
+
+func (d *Dispatcher) publish(ctx context.Context, event, userID string, data interface{}) error {
+	endpoints, err := d.store.SubscribedEndpoints(ctx, event)
+	if err != nil || len(endpoints) == 0 {
+		return err
+	}
+
+	orgID, _ := auth.OrganizationID(ctx)
+	now := d.now().UTC()
+	envelope := Envelope{
+		ID:             models.NewID(),
+		Type:           event,
+		OrganizationID: orgID,
+		CreatedAt:      now,
+		Data:           data,
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(endpoints))
+	for i, endpoint := range endpoints {
+		deliveries[i] = models.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventID:       envelope.ID,
+			Event:         event,
+			UserID:        userID,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+		}
+	}
+	if err := d.store.CreateDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+	d.Wake()
+	return nil
+}
+
+// Redeliver queues another delivery of the event a logged delivery carried
+func (d *Dispatcher) Redeliver(ctx context.Context, original *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{{
+		EndpointID:    original.EndpointID,
+		EventID:       original.EventID,
+		Event:         original.Event,
+		UserID:        original.UserID,
+		Payload:       original.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: d.now().UTC(),
+		RedeliveryOf:  &original.ID,
+	}}
+	if err := d.store.CreateDeliveries(ctx, deliveries); err != nil {
+		return nil, err
+	}
+	d.Wake()
+	return &deliveries[0], nil
+}
+
+// Wake makes the delivery loop check for due deliveries now
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start delivers due events until ctx is cancelled
+func (d *Dispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// RunOnce sends every delivery that is due and prunes the log. A batch is
+// sent one delivery after another, each taking up to Timeout, so it is leased
+// for that long plus one Timeout to spare.
+func (d *Dispatcher) RunOnce(ctx context.Context) {
+	lease := d.opts.Timeout * time.Duration(d.opts.BatchSize+1)
+	for ctx.Err() == nil {
+		deliveries, err := d.store.ClaimDeliveries(ctx, d.opts.BatchSize, lease)
+		if err != nil {
+			d.logger.Error("Failed to claim webhook deliveries", logger.F("error", err.Error()))
+			return
+		}
+		for i := range deliveries {
+			d.deliver(ctx, &deliveries[i])
+		}
+		if len(deliveries) < d.opts.BatchSize {
+			break
+		}
+	}
+
+	if d.opts.Retention > 0 {
+		if _, err := d.store.PruneDeliveries(ctx, d.now().Add(-d.opts.Retention)); err != nil {
+			d.logger.Error("Failed to prune webhook deliveries", logger.F("error", err.Error()))
+		}
+	}
+}
+
+// deliver makes one attempt at a claimed delivery and records its outcome
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	ctx = auth.ForOrganization(ctx, delivery.OrganizationID)
+
+	// Attempts stays as claimed; the store matches the outcome against it
+	var final bool
+	endpoint, err := d.store.GetEndpoint(ctx, delivery.EndpointID)
+	switch {
+	case err != nil:
+		delivery.Error = "failed to load endpoint"
+	case endpoint == nil:
+		delivery.Error = "endpoint was deleted"
+		final = true
+	case !endpoint.Active:
+		delivery.Error = "endpoint is disabled"
+		final = true
+	default:
+		d.send(ctx, endpoint, delivery)
+	}
+
+	now := d.now().UTC()
+	switch {
+	case delivery.Error == "":
+		delivery.Status = models.DeliverySucceeded
+		delivery.DeliveredAt = &now
+	case final || delivery.Attempts >= d.opts.MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+	default:
+		delivery.Status = models.DeliveryPending
+		delivery.NextAttemptAt = now.Add(d.Backoff(delivery.Attempts))
+	}
+
+	if err := d.store.RecordAttempt(ctx, delivery); err != nil {
+		d.logger.Error("Failed to record webhook delivery",
+			logger.F("delivery_id", delivery.ID),
+			logger.F("error", err.Error()),
+		)
+	}
+}
+
+// send posts a delivery to its endpoint, recording the response on it
+func (d *Dispatcher) send(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) {
+	delivery.Error = ""
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, d.now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	delivery.ResponseStatus = resp.StatusCode
+	delivery.ResponseBody = strings.ToValidUTF8(string(respBody), "")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		delivery.Error = fmt.Sprintf("endpoint responded with status %d", resp.StatusCode)
+	}
+}
+
+// Backoff returns how long to wait before the attempt after the given one:
+// exponential from BaseDelay up to MaxDelay, with up to a fifth of jitter
+func (d *Dispatcher) Backoff(attempt int) time.Duration {
+	delay := d.opts.BaseDelay
+	for i := 1; i < attempt && delay < d.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.opts.MaxDelay {
+		delay = d.opts.MaxDelay
+	}
+	if jitter := int64(delay / 5); jitter > 0 {
+		delay += time.Duration(rand.Int63n(jitter))
+	}
+	return delay
+}
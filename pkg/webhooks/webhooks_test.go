@@ -0,0 +1,244 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/auth"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/logger"
+	"github.com/Ai-chat-agent/Chat-Agent.git/pkg/models"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"type":"message.created"}`)
+	header := Sign("whsec_test", now, body)
+
+	assert.True(t, strings.HasPrefix(header, "t=1714564800,v1="))
+	assert.NoError(t, Verify("whsec_test", header, body, now.Add(time.Minute), 5*time.Minute))
+
+	assert.ErrorIs(t, Verify("whsec_other", header, body, now, 0), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("whsec_test", header, []byte(`{}`), now, 0), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("whsec_test", header, body, now.Add(time.Hour), 5*time.Minute), ErrInvalidSignature, "stale signatures are replays")
+	assert.ErrorIs(t, Verify("whsec_test", "garbage", body, now, 0), ErrInvalidSignature)
+}
+
+func TestBackoffDoublesUpToMaxDelay(t *testing.T) {
+	d := NewDispatcher(nil, Options{BaseDelay: 10 * time.Second, MaxDelay: time.Minute}, logger.NewLogrusLogger("error", "text"))
+
+	for attempt, base := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second, 4: time.Minute, 10: time.Minute} {
+		delay := d.Backoff(attempt)
+		assert.GreaterOrEqual(t, delay, base, "attempt %d", attempt)
+		assert.Less(t, delay, base+base/5+1, "attempt %d", attempt)
+	}
+}
+
+// memoryStore is a Store for one organization's endpoints
+type memoryStore struct {
+	mu         sync.Mutex
+	endpoints  []models.WebhookEndpoint
+	deliveries []models.WebhookDelivery
+	lease      time.Duration
+}
+
+func (s *memoryStore) SubscribedEndpoints(ctx context.Context, event string) ([]models.WebhookEndpoint, error) {
+	var subscribed []models.WebhookEndpoint
+	for _, e := range s.endpoints {
+		for _, ev := range e.Events {
+			if ev == event && e.Active {
+				subscribed = append(subscribed, e)
+			}
+		}
+	}
+	return subscribed, nil
+}
+
+func (s *memoryStore) GetEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	for i := range s.endpoints {
+		if s.endpoints[i].ID == id {
+			return &s.endpoints[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orgID, _ := auth.OrganizationID(ctx)
+	for i := range deliveries {
+		deliveries[i].ID = models.NewID()
+		deliveries[i].OrganizationID = orgID
+		s.deliveries = append(s.deliveries, deliveries[i])
+	}
+	return nil
+}
+
+func (s *memoryStore) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lease = lease
+	var claimed []models.WebhookDelivery
+	for i := range s.deliveries {
+		d := &s.deliveries[i]
+		if d.Status == models.DeliveryPending && !d.NextAttemptAt.After(time.Now()) && len(claimed) < limit {
+			d.Status = models.DeliverySending
+			d.Attempts++
+			claimed = append(claimed, *d)
+		}
+	}
+	return claimed, nil
+}
+
+func (s *memoryStore) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.deliveries {
+		stored := s.deliveries[i]
+		if stored.ID == delivery.ID && stored.Status == models.DeliverySending && stored.Attempts == delivery.Attempts {
+			s.deliveries[i] = *delivery
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) PruneDeliveries(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (s *memoryStore) delivery(id string) models.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.deliveries {
+		if d.ID == id {
+			return d
+		}
+	}
+	return models.WebhookDelivery{}
+}
+
+func TestDispatcherSignsAndRetriesDeliveries(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []*http.Request
+		bodies   [][]byte
+		status   = http.StatusInternalServerError
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	store := &memoryStore{endpoints: []models.WebhookEndpoint{
+		{ID: "ep1", OrganizationID: "org1", URL: server.URL, Secret: "whsec_test", Events: models.StringList{EventMessageCreated}, Active: true},
+		{ID: "ep2", OrganizationID: "org1", URL: server.URL, Secret: "whsec_test", Events: models.StringList{EventSessionClosed}, Active: true},
+	}}
+	d := NewDispatcher(store, Options{BatchSize: 10, Timeout: time.Second, MaxAttempts: 2, AllowPrivateNetworks: true}, logger.NewLogrusLogger("error", "text"))
+
+	ctx := auth.ForOrganization(context.Background(), "org1")
+	d.Publish(ctx, EventMessageCreated, "u1", map[string]string{"id": "m1"})
+	require.Len(t, store.deliveries, 1, "only subscribed endpoints receive the event")
+	id := store.deliveries[0].ID
+
+	d.RunOnce(context.Background())
+	first := store.delivery(id)
+	assert.Equal(t, models.DeliveryPending, first.Status)
+	assert.Equal(t, 1, first.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, first.ResponseStatus)
+	assert.NotEmpty(t, first.Error)
+
+	status = http.StatusOK
+	d.RunOnce(context.Background())
+	second := store.delivery(id)
+	assert.Equal(t, models.DeliverySucceeded, second.Status)
+	assert.Equal(t, 2, second.Attempts)
+	assert.NotNil(t, second.DeliveredAt)
+
+	require.Len(t, requests, 2)
+	r := requests[1]
+	assert.Equal(t, EventMessageCreated, r.Header.Get(HeaderEvent))
+	assert.Equal(t, id, r.Header.Get(HeaderDelivery))
+	assert.NoError(t, Verify("whsec_test", r.Header.Get(HeaderSignature), bodies[1], time.Now(), time.Minute))
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(bodies[1], &envelope))
+	assert.Equal(t, EventMessageCreated, envelope.Type)
+	assert.Equal(t, "org1", envelope.OrganizationID)
+	assert.Equal(t, second.EventID, envelope.ID)
+}
+
+func TestDispatcherGivesUpAfterMaxAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	store := &memoryStore{endpoints: []models.WebhookEndpoint{
+		{ID: "ep1", OrganizationID: "org1", URL: server.URL, Secret: "whsec_test", Events: models.StringList{EventFeedbackSubmitted}, Active: true},
+	}}
+	d := NewDispatcher(store, Options{BatchSize: 10, Timeout: time.Second, MaxAttempts: 1, AllowPrivateNetworks: true}, logger.NewLogrusLogger("error", "text"))
+
+	ctx := auth.ForOrganization(context.Background(), "org1")
+	d.Publish(ctx, EventFeedbackSubmitted, "u1", nil)
+	d.RunOnce(context.Background())
+
+	failed := store.deliveries[0]
+	assert.Equal(t, models.DeliveryFailed, failed.Status)
+
+	redelivery, err := d.Redeliver(ctx, &failed)
+	require.NoError(t, err)
+	assert.NotEmpty(t, redelivery.ID)
+	assert.Equal(t, failed.EventID, redelivery.EventID, "redeliveries keep the event ID")
+	require.NotNil(t, redelivery.RedeliveryOf)
+	assert.Equal(t, failed.ID, *redelivery.RedeliveryOf)
+	assert.Equal(t, models.DeliveryPending, store.delivery(redelivery.ID).Status)
+}
+
+func TestDispatcherLeasesWholeBatch(t *testing.T) {
+	store := &memoryStore{}
+	d := NewDispatcher(store, Options{BatchSize: 10, Timeout: time.Second}, logger.NewLogrusLogger("error", "text"))
+
+	d.RunOnce(context.Background())
+	assert.Equal(t, 11*time.Second, store.lease, "a batch is leased for every delivery in it")
+}
+
+func TestRecordAttemptIgnoresStaleAttempts(t *testing.T) {
+	store := &memoryStore{deliveries: []models.WebhookDelivery{
+		{ID: "d1", Status: models.DeliverySending, Attempts: 2},
+	}}
+
+	// The outcome of attempt 1 arrives after the delivery was claimed again
+	require.NoError(t, store.RecordAttempt(context.Background(), &models.WebhookDelivery{ID: "d1", Status: models.DeliveryFailed, Attempts: 1}))
+	assert.Equal(t, models.DeliverySending, store.delivery("d1").Status)
+
+	require.NoError(t, store.RecordAttempt(context.Background(), &models.WebhookDelivery{ID: "d1", Status: models.DeliverySucceeded, Attempts: 2}))
+	assert.Equal(t, models.DeliverySucceeded, store.delivery("d1").Status)
+}
+
+func TestPublicAddr(t *testing.T) {
+	for _, addr := range []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"} {
+		assert.True(t, PublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+	for _, addr := range []string{
+		"127.0.0.1", "::1", "10.0.0.1", "172.16.5.4", "192.168.1.1", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "::", "fe80::1", "fc00::1", "::ffff:127.0.0.1", "224.0.0.1",
+	} {
+		assert.False(t, PublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}